@@ -6,7 +6,9 @@ import (
 	"fmt"
 	"io"
 	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/image"
@@ -122,6 +124,30 @@ func (c *Client) ContainerRemoveForce(ctx context.Context, containerId string) e
 	})
 }
 
+// ContainerStop stops the container and keeps its volumes. Docker kills the container when it does not
+// stop in the given timeout.
+func (c *Client) ContainerStop(ctx context.Context, containerId string, timeout time.Duration) error {
+	timeoutSeconds := int(timeout.Seconds())
+
+	return c.apiClient.ContainerStop(ctx, containerId, container.StopOptions{
+		Timeout: &timeoutSeconds,
+	})
+}
+
+// StartExistingContainer starts the container created before, e.g. stopped with the ContainerStop.
+func (c *Client) StartExistingContainer(ctx context.Context, containerId string) error {
+	fullContainerId, err := c.fullContainerId(ctx, containerId)
+	if err != nil {
+		return fmt.Errorf("failed to get full container name: %w", err)
+	}
+
+	if err := c.apiClient.ContainerStart(ctx, fullContainerId, container.StartOptions{}); err != nil {
+		return fmt.Errorf("failed to start container: %w", err)
+	}
+
+	return nil
+}
+
 func (c *Client) RunContainer(ctx context.Context, config config.ContainerConfig) error {
 	envs := []string{}
 	for k, v := range config.Environment {
@@ -175,17 +201,23 @@ func (c *Client) RunContainer(ctx context.Context, config config.ContainerConfig
 	return nil
 }
 
-func (c *Client) logs(ctx context.Context, containerId string, logType OutputType, follow bool) (io.ReadCloser, error) {
+// logs returns the container output produced after the since time, all of the output when since is zero.
+func (c *Client) logs(ctx context.Context, containerId string, logType OutputType, follow bool, since time.Time) (io.ReadCloser, error) {
 	fullContainerId, err := c.fullContainerId(ctx, containerId)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get full container name: %w", err)
 	}
 
-	logStream, err := c.apiClient.ContainerLogs(ctx, fullContainerId, container.LogsOptions{
+	logsOptions := container.LogsOptions{
 		Follow:     follow,
 		ShowStdout: logType == Stdout,
 		ShowStderr: logType == Stderr,
-	})
+	}
+	if !since.IsZero() {
+		logsOptions.Since = strconv.FormatInt(since.Unix(), 10)
+	}
+
+	logStream, err := c.apiClient.ContainerLogs(ctx, fullContainerId, logsOptions)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to get container logs stream: %w", err)
@@ -194,10 +226,10 @@ func (c *Client) logs(ctx context.Context, containerId string, logType OutputTyp
 	return logStream, nil
 }
 
-func (c *Client) Stdout(ctx context.Context, containerId string, follow bool) (io.ReadCloser, error) {
-	return c.logs(ctx, containerId, Stdout, follow)
+func (c *Client) Stdout(ctx context.Context, containerId string, follow bool, since time.Time) (io.ReadCloser, error) {
+	return c.logs(ctx, containerId, Stdout, follow, since)
 }
 
-func (c *Client) Stderr(ctx context.Context, containerId string, follow bool) (io.ReadCloser, error) {
-	return c.logs(ctx, containerId, Stderr, follow)
+func (c *Client) Stderr(ctx context.Context, containerId string, follow bool, since time.Time) (io.ReadCloser, error) {
+	return c.logs(ctx, containerId, Stderr, follow, since)
 }
@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"github.com/vegaprotocol/snapshot-testing/components"
+)
+
+// ExitCode is returned by the command, so CI can tell the test outcome without parsing the results.
+type ExitCode int
+
+const (
+	ExitSuccess ExitCode = 0
+	// ExitInternalError means the tool failed, e.g. because of invalid flags or config
+	ExitInternalError ExitCode = 1
+	ExitUnhealthy     ExitCode = 2
+	ExitMaybeHealthy  ExitCode = 3
+	ExitSetupFailed   ExitCode = 4
+	// ExitSkippableFailure means the test failed, but the local node cannot be blamed, e.g. the whole network halted
+	ExitSkippableFailure ExitCode = 5
+	// ExitInterrupted means the test was stopped with SIGINT or SIGTERM before the end
+	ExitInterrupted ExitCode = 6
+)
+
+// Values of the --fail-on flag
+const (
+	FailOnUnhealthy = "unhealthy"
+	FailOnMaybe     = "maybe"
+	FailOnSkippable = "skippable"
+)
+
+var failOn []string
+
+func addExitCodeFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringSliceVar(
+		&failOn,
+		"fail-on",
+		[]string{FailOnUnhealthy, FailOnSkippable},
+		fmt.Sprintf(
+			"test outcomes returned with the non-zero exit code, available values are: %s, %s, %s. Setup failures and interruptions always fail",
+			FailOnUnhealthy,
+			FailOnMaybe,
+			FailOnSkippable,
+		),
+	)
+}
+
+func validateFailOn() error {
+	for _, value := range failOn {
+		if !slices.Contains([]string{FailOnUnhealthy, FailOnMaybe, FailOnSkippable}, value) {
+			return fmt.Errorf("unknown --fail-on value %q", value)
+		}
+	}
+
+	return nil
+}
+
+// ExitError is returned when the command must exit with the given code.
+type ExitError struct {
+	Code ExitCode
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// exitWith exits the process with the code matching the error. Errors without the exit code are internal errors.
+func exitWith(err error) {
+	if err == nil {
+		return
+	}
+
+	code := ExitInternalError
+	exitErr := &ExitError{}
+	if errors.As(err, &exitErr) {
+		code = exitErr.Code
+	}
+
+	fmt.Fprintf(os.Stderr, "Error: %s\n", err.Error())
+	os.Exit(int(code))
+}
+
+// testOutcome describes how the test finished.
+type testOutcome struct {
+	Status            components.HealthyStatus
+	Reason            string
+	SetupFailed       bool
+	ShouldSkipFailure bool
+	Interrupted       bool
+}
+
+func (o testOutcome) exitCode() ExitCode {
+	failsOn := func(outcome string, code ExitCode) ExitCode {
+		if slices.Contains(failOn, outcome) {
+			return code
+		}
+		return ExitSuccess
+	}
+
+	switch {
+	case o.Interrupted:
+		return ExitInterrupted
+	case o.Status == components.Healthy:
+		return ExitSuccess
+	case o.ShouldSkipFailure:
+		return failsOn(FailOnSkippable, ExitSkippableFailure)
+	case o.SetupFailed:
+		return ExitSetupFailed
+	case o.Status == components.MaybeHealthy:
+		return failsOn(FailOnMaybe, ExitMaybeHealthy)
+	default:
+		return failsOn(FailOnUnhealthy, ExitUnhealthy)
+	}
+}
+
+// err returns the error with the exit code of the outcome, or nil when the outcome is not a failure.
+func (o testOutcome) err() error {
+	code := o.exitCode()
+	if code == ExitSuccess {
+		return nil
+	}
+
+	msg := fmt.Sprintf("test finished with the %s status", o.Status)
+	if o.Interrupted {
+		msg = "test interrupted"
+	}
+	if o.Reason != "" {
+		msg = fmt.Sprintf("%s: %s", msg, o.Reason)
+	}
+
+	return &ExitError{Code: code, Err: errors.New(msg)}
+}
+
+// interruptContext returns the context cancelled on SIGINT or SIGTERM, so the test can stop the components
+// and write the results. The next signal kills the process.
+func interruptContext() (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	return ctx, stop
+}
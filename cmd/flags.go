@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// optionalFlag sets the pointer only when the flag is given, so the explicit zero value differs
+// from the flag that was not set.
+type optionalFlag[T any] struct {
+	target   **T
+	typeName string
+	parse    func(value string) (T, error)
+}
+
+func optionalUint64Flag(target **uint64) *optionalFlag[uint64] {
+	return &optionalFlag[uint64]{
+		target:   target,
+		typeName: "uint64",
+		parse: func(value string) (uint64, error) {
+			return strconv.ParseUint(value, 10, 64)
+		},
+	}
+}
+
+func optionalIntFlag(target **int) *optionalFlag[int] {
+	return &optionalFlag[int]{
+		target:   target,
+		typeName: "int",
+		parse:    strconv.Atoi,
+	}
+}
+
+func (of *optionalFlag[T]) String() string {
+	if *of.target == nil {
+		return ""
+	}
+
+	return fmt.Sprint(**of.target)
+}
+
+func (of *optionalFlag[T]) Set(value string) error {
+	parsed, err := of.parse(value)
+	if err != nil {
+		return err
+	}
+	*of.target = &parsed
+
+	return nil
+}
+
+func (of *optionalFlag[T]) Type() string {
+	return of.typeName
+}
+
+// positiveDurationFlag rejects zero and negative durations, the empty flag keeps the zero value.
+type positiveDurationFlag struct {
+	target *time.Duration
+}
+
+func (pdf positiveDurationFlag) String() string {
+	return pdf.target.String()
+}
+
+func (pdf positiveDurationFlag) Set(value string) error {
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	if duration <= 0 {
+		return fmt.Errorf("duration must be positive")
+	}
+	*pdf.target = duration
+
+	return nil
+}
+
+func (pdf positiveDurationFlag) Type() string {
+	return "duration"
+}
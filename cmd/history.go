@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/vegaprotocol/snapshot-testing/networkutils"
+	"github.com/vegaprotocol/snapshot-testing/report"
+)
+
+var (
+	historyOutput           string
+	historyFilter           report.HistoryFilter
+	historyThresholds       report.HistoryThresholds
+	historyFailOnRegression bool
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show trends of the runs recorded in the history file.",
+	Long: `The command groups runs recorded in the history file by the environment and the app version,
+shows the median catch-up duration and the failure rate of each version and reports regressions
+between consecutive versions.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		exitWith(runHistory())
+	},
+}
+
+func init() {
+	historyCmd.PersistentFlags().StringVar(
+		&historyOutput,
+		"output",
+		report.FormatTable,
+		fmt.Sprintf("output format, available values are: %s, %s", report.FormatTable, report.FormatJSON),
+	)
+	historyCmd.PersistentFlags().StringVar(&historyFilter.Environment, "filter-environment", "", "show only runs of the environment")
+	historyCmd.PersistentFlags().StringVar(&historyFilter.ChainID, "filter-chain-id", "", "show only runs of the chain")
+	historyCmd.PersistentFlags().StringVar(&historyFilter.AppVersion, "filter-app-version", "", "show only runs of the app version")
+	historyCmd.PersistentFlags().Float64Var(
+		&historyThresholds.CatchUpDurationPercent,
+		"catch-up-regression",
+		report.DefaultHistoryThresholds.CatchUpDurationPercent,
+		"max increase of the median catch-up duration against the previous version in percent",
+	)
+	historyCmd.PersistentFlags().Float64Var(
+		&historyThresholds.FailureRatePoints,
+		"failure-rate-regression",
+		report.DefaultHistoryThresholds.FailureRatePoints,
+		"max increase of the failure rate against the previous version in percentage points",
+	)
+	historyCmd.PersistentFlags().BoolVar(
+		&historyFailOnRegression,
+		"fail-on-regression",
+		false,
+		"return error when any regression is found",
+	)
+}
+
+func runHistory() error {
+	entries, err := report.LoadHistory(historyFilePath(networkutils.NewPathManager(workDir)), historyFilter)
+	if err != nil {
+		return fmt.Errorf("failed to load history: %w", err)
+	}
+
+	history := report.NewHistory(entries, historyThresholds)
+
+	switch historyOutput {
+	case report.FormatTable:
+		if err := history.WriteTable(os.Stdout); err != nil {
+			return err
+		}
+	case report.FormatJSON:
+		if err := history.WriteJSON(os.Stdout); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown output format %q", historyOutput)
+	}
+
+	if historyFailOnRegression && len(history.Regressions) > 0 {
+		return fmt.Errorf("found %d regressions between app versions", len(history.Regressions))
+	}
+
+	return nil
+}
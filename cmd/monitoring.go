@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"context"
+	"time"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+
+	"github.com/vegaprotocol/snapshot-testing/components"
+	"github.com/vegaprotocol/snapshot-testing/monitoring"
+)
+
+var (
+	metricsAddr string
+	statusAddr  string
+)
+
+func addMonitoringFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVar(
+		&metricsAddr,
+		"metrics-addr",
+		"",
+		"address of the HTTP server exposing Prometheus metrics of the run on the /metrics path, e.g. :2112. Disabled when empty",
+	)
+	cmd.PersistentFlags().StringVar(
+		&statusAddr,
+		"status-addr",
+		"",
+		"address of the HTTP server exposing the live status of the run on the /status and /events paths, e.g. :2113. Disabled when empty",
+	)
+}
+
+// startMonitoring starts the monitoring servers enabled by flags. Endpoints with the same address
+// are served by one server. The returned function stops the servers.
+func startMonitoring(mainLogger *zap.Logger, monitor *components.Monitor) (func(), error) {
+	servers := []*monitoring.Server{}
+	stop := func() {
+		for _, server := range servers {
+			stopCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			if err := server.Stop(stopCtx); err != nil {
+				mainLogger.Error("Failed to stop monitoring server", zap.Error(err))
+			}
+			cancel()
+		}
+	}
+
+	serversByAddr := map[string]*monitoring.Server{}
+	serverFor := func(addr string) *monitoring.Server {
+		if server, ok := serversByAddr[addr]; ok {
+			return server
+		}
+		server := monitoring.NewServer(addr, monitor, mainLogger.Named("monitoring-server"))
+		serversByAddr[addr] = server
+		servers = append(servers, server)
+
+		return server
+	}
+
+	if metricsAddr != "" {
+		serverFor(metricsAddr).ServeMetrics()
+	}
+	if statusAddr != "" {
+		serverFor(statusAddr).ServeStatus()
+	}
+
+	for idx, server := range servers {
+		if err := server.Start(); err != nil {
+			// Only started servers are stopped
+			servers = servers[:idx]
+			return stop, err
+		}
+	}
+
+	return stop, nil
+}
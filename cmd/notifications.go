@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+
+	"github.com/vegaprotocol/snapshot-testing/config"
+	"github.com/vegaprotocol/snapshot-testing/notifications"
+)
+
+var (
+	webhookURL     string
+	webhookHeaders []string
+	webhookEvents  []string
+)
+
+func addNotificationFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVar(
+		&webhookURL,
+		"webhook-url",
+		"",
+		"URL notified about the test outcome, added to the webhooks from the network config",
+	)
+	cmd.PersistentFlags().StringArrayVar(
+		&webhookHeaders,
+		"webhook-header",
+		nil,
+		`header of the webhook request in the "Name: value" form, can be repeated`,
+	)
+	cmd.PersistentFlags().StringSliceVar(
+		&webhookEvents,
+		"webhook-events",
+		nil,
+		fmt.Sprintf(
+			"events sent to the webhook, available values are: %s, %s, %s (default %s)",
+			config.NotificationRunFailed,
+			config.NotificationRunFinished,
+			config.NotificationStateChanged,
+			config.NotificationRunFailed,
+		),
+	)
+}
+
+// createNotifier creates the notifier for webhooks from the network config and flags.
+func createNotifier(mainLogger *zap.Logger, networkConfig config.Network, runName string) (*notifications.Notifier, error) {
+	conf := networkConfig.Notifications
+
+	if webhookURL != "" {
+		webhook := config.Webhook{
+			URL:     webhookURL,
+			Headers: map[string]string{},
+		}
+		for _, header := range webhookHeaders {
+			name, value, found := strings.Cut(header, ":")
+			if !found {
+				return nil, fmt.Errorf("invalid webhook header %q, expected \"Name: value\"", header)
+			}
+			webhook.Headers[strings.TrimSpace(name)] = strings.TrimSpace(value)
+		}
+		for _, event := range webhookEvents {
+			webhook.Events = append(webhook.Events, config.NotificationEvent(event))
+		}
+		conf.Webhooks = append(conf.Webhooks, webhook)
+	}
+
+	notifier, err := notifications.NewNotifier(conf, runName, environment, mainLogger.Named("notifications"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create notifier: %w", err)
+	}
+
+	return notifier, nil
+}
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/spf13/cobra"
@@ -16,18 +17,32 @@ var prepareCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		pathManager := networkutils.NewPathManager(workDir)
 		if err := pathManager.CreateDirectoryStructure(); err != nil {
-			panic(err)
+			exitWith(err)
 		}
 
 		// We do not want to log this to file
 		stdoutOnlyLogger := logging.CreateLogger(zap.InfoLevel, logging.DoNotLogToFile, true, true)
+		stopTracing, err := startTracing(stdoutOnlyLogger)
+		if err != nil {
+			stdoutOnlyLogger.Fatal("failed to start tracing", zap.Error(err))
+		}
+		defer stopTracing()
+
 		networkConfig, err := config.NetworkConfigForGivenInput(environment, configPath, workDir)
 		if err != nil {
 			stdoutOnlyLogger.Fatal("failed to get network config", zap.Error(err))
 		}
 
-		if err := prepareNetwork(stdoutOnlyLogger, pathManager, *networkConfig, config.DefaultCredentials, externalAddress); err != nil {
-			stdoutOnlyLogger.Fatal("failed to setup local network", zap.Error(err))
+		if _, err := prepareNetwork(
+			context.Background(),
+			stdoutOnlyLogger,
+			pathManager,
+			*networkConfig,
+			config.DefaultCredentials,
+			externalAddress,
+		); err != nil {
+			stopTracing()
+			exitWith(&ExitError{Code: ExitSetupFailed, Err: fmt.Errorf("failed to setup local network: %w", err)})
 		}
 
 		stdoutOnlyLogger.Info("")
@@ -37,21 +52,26 @@ var prepareCmd = &cobra.Command{
 	},
 }
 
+func init() {
+	addTracingFlags(prepareCmd)
+}
+
 func prepareNetwork(
+	ctx context.Context,
 	logger *zap.Logger,
 	pathManager networkutils.PathManager,
 	networkConfig config.Network,
 	postgreSQLCredentials config.PostgreSQLCreds,
 	externalAddress string,
-) error {
+) (networkutils.SetupReport, error) {
 	network, err := networkutils.NewNetwork(logger, networkConfig, pathManager, networkutils.DefaultRESTClient())
 	if err != nil {
-		return fmt.Errorf("failed to create network utils: %w", err)
+		return networkutils.SetupReport{}, fmt.Errorf("failed to create network utils: %w", err)
 	}
 
-	if err := network.SetupLocalNode(postgreSQLCredentials, externalAddress); err != nil {
-		return fmt.Errorf("failed to setup local node: %w", err)
+	if err := network.SetupLocalNode(ctx, postgreSQLCredentials, externalAddress); err != nil {
+		return network.SetupReport(), fmt.Errorf("failed to setup local node: %w", err)
 	}
 
-	return nil
+	return network.SetupReport(), nil
 }
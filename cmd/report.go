@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/vegaprotocol/snapshot-testing/report"
+)
+
+var (
+	reportOutput           string
+	reportThresholds       report.Thresholds
+	reportFailOnRegression bool
+)
+
+var reportCmd = &cobra.Command{
+	Use:   "report <work-dir>...",
+	Short: "Render results stored in the work dirs and compare them.",
+	Long: `The command loads results and watchdog metrics stored in the given work dirs and renders
+them. When several runs are given, they are compared with the first one and regressions are reported.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		exitWith(runReport(args))
+	},
+}
+
+func init() {
+	reportCmd.PersistentFlags().StringVar(
+		&reportOutput,
+		"output",
+		report.FormatTable,
+		fmt.Sprintf("output format, available values are: %s, %s, %s", report.FormatTable, report.FormatJSON, report.FormatHTML),
+	)
+	reportCmd.PersistentFlags().Float64Var(
+		&reportThresholds.CatchUpDurationPercent,
+		"catch-up-regression",
+		report.DefaultThresholds.CatchUpDurationPercent,
+		"max increase of the catch-up duration against the baseline in percent",
+	)
+	reportCmd.PersistentFlags().Float64Var(
+		&reportThresholds.MaxLagPercent,
+		"max-lag-regression",
+		report.DefaultThresholds.MaxLagPercent,
+		"max increase of the max core and data-node lag against the baseline in percent",
+	)
+	reportCmd.PersistentFlags().Uint64Var(
+		&reportThresholds.MinLagIncrease,
+		"min-lag-regression",
+		report.DefaultThresholds.MinLagIncrease,
+		"min increase of the max core and data-node lag against the baseline in blocks to report it as a regression",
+	)
+	reportCmd.PersistentFlags().BoolVar(
+		&reportFailOnRegression,
+		"fail-on-regression",
+		false,
+		"return error when any regression is found",
+	)
+}
+
+func runReport(workDirs []string) error {
+	runs := []report.Run{}
+	for _, workDir := range workDirs {
+		workDirRuns, err := report.LoadRuns(workDir)
+		if err != nil {
+			return fmt.Errorf("failed to load results from %s: %w", workDir, err)
+		}
+		runs = append(runs, workDirRuns...)
+	}
+
+	comparison := report.NewComparison(runs, reportThresholds)
+
+	switch reportOutput {
+	case report.FormatTable:
+		if err := comparison.WriteTable(os.Stdout); err != nil {
+			return err
+		}
+	case report.FormatJSON:
+		if err := comparison.WriteJSON(os.Stdout); err != nil {
+			return err
+		}
+	case report.FormatHTML:
+		// Single run is rendered with all the details, several runs are only compared
+		if len(runs) == 1 {
+			if err := report.RenderHTML(os.Stdout, "snapshot-testing "+runs[0].Name, runs[0].Results, runs[0].Metrics); err != nil {
+				return err
+			}
+		} else if err := comparison.WriteHTML(os.Stdout, "snapshot-testing comparison"); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown output format %q", reportOutput)
+	}
+
+	if reportFailOnRegression && len(comparison.Regressions) > 0 {
+		return fmt.Errorf("found %d regressions against the baseline", len(comparison.Regressions))
+	}
+
+	return nil
+}
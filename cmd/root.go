@@ -1,12 +1,22 @@
 package cmd
 
-import "github.com/spf13/cobra"
+import (
+	"fmt"
+	"slices"
+
+	"github.com/spf13/cobra"
+
+	"github.com/vegaprotocol/snapshot-testing/networkutils"
+	"github.com/vegaprotocol/snapshot-testing/report"
+)
 
 var (
 	workDir         string
 	environment     string
 	configPath      string
 	externalAddress string
+	reportFormats   []string
+	historyFile     string
 
 	rootCmd = &cobra.Command{
 		Use:   "snapshot-testing",
@@ -16,6 +26,26 @@ starts it and runs it for given time.`,
 	}
 )
 
+// validateReportFormats checks values of the --report-format flag.
+func validateReportFormats() error {
+	for _, format := range reportFormats {
+		if !slices.Contains([]string{report.FormatJSON, report.FormatJUnit, report.FormatHTML}, format) {
+			return fmt.Errorf("unknown report format %q", format)
+		}
+	}
+
+	return nil
+}
+
+// historyFilePath returns the path of the history file from the --history-file flag.
+func historyFilePath(pathManager networkutils.PathManager) string {
+	if historyFile != "" {
+		return historyFile
+	}
+
+	return pathManager.History()
+}
+
 // Execute executes the root command.
 func Execute() error {
 	return rootCmd.Execute()
@@ -48,7 +78,28 @@ func init() {
 		"",
 		"external address that needs to be set in the tendermint config when the node is running behind the nat",
 	)
+	rootCmd.PersistentFlags().StringSliceVar(
+		&reportFormats,
+		"report-format",
+		[]string{report.FormatJSON},
+		fmt.Sprintf(
+			"formats of the report written into the work dir, available values are: %s, %s, %s. The json report is always written",
+			report.FormatJSON,
+			report.FormatJUnit,
+			report.FormatHTML,
+		),
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&historyFile,
+		"history-file",
+		"",
+		"the append-only file each run is recorded in, the history.jsonl file in the work dir is used when empty",
+	)
 
 	rootCmd.AddCommand(prepareCmd)
 	rootCmd.AddCommand(runCmd)
+	rootCmd.AddCommand(scenarioCmd)
+	rootCmd.AddCommand(reportCmd)
+	rootCmd.AddCommand(historyCmd)
+	rootCmd.AddCommand(statusCmd)
 }
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"slices"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -16,25 +17,150 @@ import (
 	"github.com/vegaprotocol/snapshot-testing/config"
 	"github.com/vegaprotocol/snapshot-testing/logging"
 	"github.com/vegaprotocol/snapshot-testing/networkutils"
+	"github.com/vegaprotocol/snapshot-testing/report"
 )
 
-var testDuration time.Duration
+var (
+	testDuration     time.Duration
+	visorStopTimeout time.Duration
+	// Non-empty values override the watchdog config from the network config
+	watchdogFlags config.Watchdog
+	// Non-empty values override the consistency config from the network config
+	consistencyFlags config.Consistency
+)
 
 var runCmd = &cobra.Command{
 	Use:   "run",
 	Short: "Prepare local node and run it for given time.",
 	Run: func(cmd *cobra.Command, args []string) {
-		if err := runSnapshotTesting(testDuration); err != nil {
-			panic(err)
-		}
+		exitWith(runSnapshotTesting(testDuration))
 	},
 }
 
 func init() {
 	runCmd.PersistentFlags().DurationVar(&testDuration, "duration", 15*time.Minute, "duration of test")
+	runCmd.PersistentFlags().DurationVar(
+		&visorStopTimeout,
+		"visor-stop-timeout",
+		60*time.Second,
+		"time given to the vegavisor to exit after SIGTERM before it is killed with its child processes",
+	)
+	addWatchdogFlags(runCmd)
+	addConsistencyFlags(runCmd)
+	addMonitoringFlags(runCmd)
+	addNotificationFlags(runCmd)
+	addTracingFlags(runCmd)
+	addExitCodeFlags(runCmd)
+}
+
+func addConsistencyFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVar(
+		&consistencyFlags.LocalRPCURL,
+		"consistency-local-rpc-url",
+		"",
+		fmt.Sprintf("tendermint RPC URL of the local node (default %s)", config.DefaultConsistency.LocalRPCURL),
+	)
+	cmd.PersistentFlags().DurationVar(
+		&consistencyFlags.Interval,
+		"consistency-interval",
+		0,
+		fmt.Sprintf("how often the local chain state is compared with the network (default %s)", config.DefaultConsistency.Interval),
+	)
+	cmd.PersistentFlags().IntVar(
+		&consistencyFlags.Peers,
+		"consistency-peers",
+		0,
+		fmt.Sprintf("max number of RPC peers the local blocks are compared with (default %d)", config.DefaultConsistency.Peers),
+	)
+}
+
+func addWatchdogFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVar(
+		&watchdogFlags.LocalRESTURL,
+		"watchdog-local-rest-url",
+		"",
+		fmt.Sprintf("data-node REST URL of the local node (default %s)", config.DefaultWatchdog.LocalRESTURL),
+	)
+	cmd.PersistentFlags().StringVar(
+		&watchdogFlags.LocalCoreURL,
+		"watchdog-local-core-url",
+		"",
+		"optional core REST URL of the local node, when set the core height is taken from it",
+	)
+	cmd.PersistentFlags().Var(
+		positiveDurationFlag{&watchdogFlags.Interval},
+		"watchdog-interval",
+		fmt.Sprintf("how often the watchdog probes the local node (default %s)", config.DefaultWatchdog.Interval),
+	)
+	cmd.PersistentFlags().Var(
+		optionalUint64Flag(&watchdogFlags.CoreLag),
+		"watchdog-core-lag",
+		fmt.Sprintf("max number of blocks the local core can be behind the network (default %d)", *config.DefaultWatchdog.CoreLag),
+	)
+	cmd.PersistentFlags().Var(
+		optionalUint64Flag(&watchdogFlags.DataNodeLag),
+		"watchdog-data-node-lag",
+		fmt.Sprintf("max number of blocks the local data-node can be behind the local core (default %d)", *config.DefaultWatchdog.DataNodeLag),
+	)
+	cmd.PersistentFlags().Var(
+		positiveDurationFlag{&watchdogFlags.StallWindow},
+		"watchdog-stall-window",
+		fmt.Sprintf("the node is stalled when its height did not increase for this time (default %s)", config.DefaultWatchdog.StallWindow),
+	)
+	cmd.PersistentFlags().Var(
+		positiveDurationFlag{&watchdogFlags.VegaTimeLag},
+		"watchdog-vega-time-lag",
+		fmt.Sprintf("max time the local node vega time can be behind the network (default %s)", config.DefaultWatchdog.VegaTimeLag),
+	)
+	cmd.PersistentFlags().Var(
+		positiveDurationFlag{&watchdogFlags.SpeedWindow},
+		"watchdog-speed-window",
+		fmt.Sprintf("moving window used to measure the replay speed of the local node (default %s)", config.DefaultWatchdog.SpeedWindow),
+	)
+	cmd.PersistentFlags().Var(
+		positiveDurationFlag{&watchdogFlags.CatchUpTimeout},
+		"watchdog-catch-up-timeout",
+		"max time for the local node to catch the network up, the test fails early when the node is not going to make it (default test duration)",
+	)
+	cmd.PersistentFlags().DurationVar(
+		&watchdogFlags.PassCriteria.MinHealthyDuration,
+		"min-healthy-duration",
+		0,
+		"pass criterion: min time the node must be continuously healthy at the end of the test (default not checked)",
+	)
+	cmd.PersistentFlags().DurationVar(
+		&watchdogFlags.PassCriteria.MaxCatchUpDuration,
+		"max-catch-up-duration",
+		0,
+		"pass criterion: max time from the start of the test to the catch-up (default not checked)",
+	)
+	cmd.PersistentFlags().Var(
+		optionalIntFlag(&watchdogFlags.PassCriteria.MaxLagEpisodes),
+		"max-lag-episodes",
+		"pass criterion: max number of times the node started lagging after the catch-up, 0 means no lag allowed (default not checked)",
+	)
+	cmd.PersistentFlags().DurationVar(
+		&watchdogFlags.PassCriteria.MaxLaggingTime,
+		"max-lagging-time",
+		0,
+		"pass criterion: max total time the node was lagging after the catch-up (default not checked)",
+	)
+	cmd.PersistentFlags().IntVar(
+		&watchdogFlags.EventsLimit,
+		"events-limit",
+		0,
+		fmt.Sprintf("max number of watchdog events reported in the results, older events are dropped (default %d)", config.DefaultWatchdog.EventsLimit),
+	)
 }
 
 func runSnapshotTesting(duration time.Duration) error {
+	if err := validateReportFormats(); err != nil {
+		return err
+	}
+	if err := validateFailOn(); err != nil {
+		return err
+	}
+
 	pathManager := networkutils.NewPathManager(workDir)
 	if err := pathManager.CreateDirectoryStructure(); err != nil {
 		return fmt.Errorf("failed to prepare working directory: %w", err)
@@ -46,111 +172,423 @@ func runSnapshotTesting(duration time.Duration) error {
 	if err != nil {
 		return fmt.Errorf("failed to get network config: %w", err)
 	}
+	if err := applyConfigFlags(networkConfig); err != nil {
+		return err
+	}
 
-	if err := prepareNetwork(
+	stopTracing, err := startTracing(mainLogger)
+	if err != nil {
+		return err
+	}
+	defer stopTracing()
+
+	notifier, err := createNotifier(mainLogger, *networkConfig, workDir)
+	if err != nil {
+		return err
+	}
+
+	monitor := components.NewMonitor()
+	monitor.AddStateListener(notifier)
+	stopMonitoring, err := startMonitoring(mainLogger, monitor)
+	defer stopMonitoring()
+	if err != nil {
+		return fmt.Errorf("failed to start monitoring: %w", err)
+	}
+
+	ctx, stop := interruptContext()
+	defer stop()
+
+	testStart := time.Now()
+	monitor.SetPhase(string(config.PhasePrepare), string(config.PhasePrepare))
+	setupReport, err := prepareNetwork(
+		ctx,
 		mainLogger.Named("prepare-network"),
 		pathManager,
 		*networkConfig,
 		config.DefaultCredentials,
-		externalAddress); err != nil {
-		if shouldSkipFailure(err) {
-			snapshotTestingResults := map[string]any{
-				"should-skip-failure": true,
-			}
-			if err := writeResult(duration, mainLogger, snapshotTestingResults, pathManager); err != nil {
-				return err
-			}
+		externalAddress)
+	if err != nil {
+		snapshotTestingResults := components.NewResults(testStart)
+		// Test components were not created, but the environment is still needed, e.g. for the history
+		snapshotTestingResults.Config = runConfigResults(duration, nil)
+		snapshotTestingResults.Setup = components.NewSetupResults(setupReport)
+		snapshotTestingResults.SetStatus(components.Unhealthy, components.ReasonSetupFailed, err.Error())
+		snapshotTestingResults.ShouldSkipFailure = shouldSkipFailure(err)
+		snapshotTestingResults.Interrupted = ctx.Err() != nil
+		snapshotTestingResults.Finish(time.Now())
+		if err := writeRunReports(duration, mainLogger, snapshotTestingResults, pathManager); err != nil {
+			return err
 		}
-		return fmt.Errorf("failed to setup local network: %w", err)
+		recordHistory(mainLogger, workDir, snapshotTestingResults, pathManager)
+		notifier.RunFinished(snapshotTestingResults)
+		return resultsOutcome(snapshotTestingResults).err()
 	}
 
-	dockerClient, err := docker.NewClient()
+	testsComponents, err := createTestComponents(mainLogger, pathManager, *networkConfig, nil, config.ComponentOptions{})
 	if err != nil {
-		return fmt.Errorf("failed to create docker client: %w", err)
+		return err
 	}
 
-	psqlStdoutLogger := logging.CreateLogger(zap.InfoLevel, pathManager.LogFile("psql-stdout.log"), false, false)
-	psqlStderrLogger := logging.CreateLogger(zap.InfoLevel, pathManager.LogFile("psql-stderr.log"), false, false)
+	monitor.SetPhase(string(config.PhaseRun), string(config.PhaseRun))
+	// Results are reported even when the components could not run
+	snapshotTestingResults, _, runErr := runTestComponents(ctx, duration, mainLogger, pathManager, testsComponents, monitor)
+	snapshotTestingResults.Interrupted = ctx.Err() != nil
+	snapshotTestingResults.Setup = components.NewSetupResults(setupReport)
+	// The local node cannot be blamed when the whole network stopped producing blocks
+	snapshotTestingResults.ShouldSkipFailure = snapshotTestingResults.Status == components.NetworkHalted
 
-	postgresql, err := components.NewPostgresql(
-		dockerClient,
-		config.DefaultCredentials,
-		mainLogger.Named("postgresql"),
-		psqlStdoutLogger,
-		psqlStderrLogger,
-	)
-	if err != nil {
-		return fmt.Errorf("failed to create postgresql component: %w", err)
+	if err := writeRunReports(duration, mainLogger, snapshotTestingResults, pathManager); err != nil {
+		return err
 	}
+	recordHistory(mainLogger, workDir, snapshotTestingResults, pathManager)
+	notifier.RunFinished(snapshotTestingResults)
 
-	visorStdoutLogger := logging.CreateLogger(zap.InfoLevel, pathManager.LogFile("visor-stdout.log"), false, false)
-	visorStderrLogger := logging.CreateLogger(zap.InfoLevel, pathManager.LogFile("visor-stderr.log"), false, false)
+	// Components that failed to start are the setup failure, other errors are failures of the tool
+	if runErr != nil && snapshotTestingResults.ReasonCode != components.ReasonSetupFailed {
+		return runErr
+	}
 
-	visor, err := components.NewVisor(
-		pathManager.VisorBin(),
-		pathManager.VisorHome(),
-		mainLogger.Named("visor"),
-		visorStdoutLogger,
-		visorStderrLogger,
-	)
-	if err != nil {
-		return fmt.Errorf("failed to create visor component: %w", err)
+	return resultsOutcome(snapshotTestingResults).err()
+}
+
+// resultsOutcome describes how the run finished based on its results.
+func resultsOutcome(results *components.Results) testOutcome {
+	return testOutcome{
+		Status:            results.Status,
+		Reason:            results.Reason,
+		SetupFailed:       results.ReasonCode == components.ReasonSetupFailed,
+		ShouldSkipFailure: results.ShouldSkipFailure,
+		Interrupted:       results.Interrupted,
 	}
+}
 
-	watchdog, err := components.NewWatchdog(networkConfig.DataNodesREST, mainLogger.Named("watchdog"))
-	if err != nil {
-		return fmt.Errorf("failed to create watchdog component: %w", err)
+// writeRunReports writes the results of the run in all of the requested report formats.
+func writeRunReports(duration time.Duration, mainLogger *zap.Logger, snapshotTestingResults *components.Results, pathManager networkutils.PathManager) error {
+	if err := writeResult(duration, mainLogger, snapshotTestingResults, pathManager); err != nil {
+		return err
 	}
 
-	testsComponents := []components.Component{
-		postgresql,
-		visor,
-		watchdog,
+	if slices.Contains(reportFormats, report.FormatJUnit) {
+		junitReport := report.NewJUnitTestSuites("snapshot-testing", report.RunTestSuite(environment, snapshotTestingResults))
+		if err := writeJUnitReport(mainLogger, junitReport, pathManager); err != nil {
+			return err
+		}
 	}
 
-	testCtx, testCancel := context.WithTimeout(context.Background(), duration)
-	defer testCancel()
+	if slices.Contains(reportFormats, report.FormatHTML) {
+		if err := writeHTMLReport(mainLogger, "report.html", "snapshot-testing "+environment, snapshotTestingResults, pathManager); err != nil {
+			return err
+		}
+	}
 
-	err = components.Run(testCtx, pathManager, mainLogger.Named("controller"), testsComponents)
-	componentsFailed := false
-	if err != nil {
-		componentsFailed = true
-		if errors.Is(err, components.ComponentFailureErr) {
-			// component failed but it is expected and We still want to have results
-			mainLogger.Error("failed to run test components", zap.Error(err))
-		} else {
-			return fmt.Errorf("failed to run test components: %w", err)
+	return nil
+}
+
+// applyConfigFlags overrides the network config with the non-empty flags and validates the result.
+func applyConfigFlags(networkConfig *config.Network) error {
+	networkConfig.Watchdog = watchdogFlags.Merge(networkConfig.Watchdog)
+	networkConfig.Consistency = consistencyFlags.Merge(networkConfig.Consistency)
+
+	if err := networkConfig.Validate(); err != nil {
+		return fmt.Errorf("invalid config after applying flags: %w", err)
+	}
+
+	return nil
+}
+
+// createTestComponents creates the test components with given names. All of the built-in
+// components and exec components from the network config are created when names is empty.
+// Non-empty options override the flags and the network config.
+func createTestComponents(
+	mainLogger *zap.Logger,
+	pathManager networkutils.PathManager,
+	networkConfig config.Network,
+	names []string,
+	options config.ComponentOptions,
+) ([]components.Component, error) {
+	selected := func(name string) bool {
+		return len(names) == 0 || slices.Contains(names, name)
+	}
+
+	testsComponents := []components.Component{}
+
+	if selected(components.ComponentNamePostgresql) {
+		dockerClient, err := docker.NewClient()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create docker client: %w", err)
+		}
+
+		psqlStdoutLogger := logging.CreateLogger(zap.InfoLevel, pathManager.LogFile("psql-stdout.log"), false, false)
+		psqlStderrLogger := logging.CreateLogger(zap.InfoLevel, pathManager.LogFile("psql-stderr.log"), false, false)
+
+		postgresql, err := components.NewPostgresql(
+			dockerClient,
+			config.DefaultCredentials,
+			options.Postgresql.KeepDatabase != nil && *options.Postgresql.KeepDatabase,
+			mainLogger.Named("postgresql"),
+			psqlStdoutLogger,
+			psqlStderrLogger,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create postgresql component: %w", err)
 		}
+
+		testsComponents = append(testsComponents, postgresql)
+	}
+
+	if selected(components.ComponentNameVisor) {
+		visorStdoutLogger := logging.CreateLogger(zap.InfoLevel, pathManager.LogFile("visor-stdout.log"), false, false)
+		visorStderrLogger := logging.CreateLogger(zap.InfoLevel, pathManager.LogFile("visor-stderr.log"), false, false)
+
+		stopTimeout := visorStopTimeout
+		if options.Vegavisor.StopTimeout > 0 {
+			stopTimeout = options.Vegavisor.StopTimeout
+		}
+
+		visor, err := components.NewVisor(
+			pathManager.VisorBin(),
+			pathManager.VisorHome(),
+			stopTimeout,
+			mainLogger.Named("visor"),
+			visorStdoutLogger,
+			visorStderrLogger,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create visor component: %w", err)
+		}
+
+		testsComponents = append(testsComponents, visor)
+	}
+
+	if selected(components.ComponentNameWatchdog) {
+		watchdog, err := components.NewWatchdog(
+			networkConfig.DataNodesREST,
+			options.Watchdog.Merge(networkConfig.Watchdog),
+			pathManager.WatchdogMetrics(),
+			mainLogger.Named("watchdog"),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create watchdog component: %w", err)
+		}
+
+		testsComponents = append(testsComponents, watchdog)
+	}
+
+	if selected(components.ComponentNameConsistency) {
+		consistencyChecker, err := components.NewConsistencyChecker(
+			networkConfig.RPCPeers,
+			networkConfig.DataNodesREST,
+			options.Consistency.Merge(networkConfig.Consistency),
+			mainLogger.Named("consistency"),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create consistency component: %w", err)
+		}
+
+		testsComponents = append(testsComponents, consistencyChecker)
+	}
+
+	if selected(components.ComponentNameSmokeTests) {
+		smokeTests, err := components.NewSmokeTests(
+			networkConfig.DataNodesREST,
+			options.SmokeTests.Merge(networkConfig.SmokeTests),
+			mainLogger.Named("api-smoke-tests"),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create api smoke tests component: %w", err)
+		}
+
+		testsComponents = append(testsComponents, smokeTests)
+	}
+
+	for _, execConfig := range networkConfig.ExecComponents {
+		if !selected(execConfig.Name) {
+			continue
+		}
+
+		stdoutLogFile := execConfig.StdoutLogFile
+		if stdoutLogFile == "" {
+			stdoutLogFile = fmt.Sprintf("%s-stdout.log", execConfig.Name)
+		}
+		stderrLogFile := execConfig.StderrLogFile
+		if stderrLogFile == "" {
+			stderrLogFile = fmt.Sprintf("%s-stderr.log", execConfig.Name)
+		}
+
+		execComponent, err := components.NewExec(
+			execConfig,
+			mainLogger.Named(execConfig.Name),
+			logging.CreateLogger(zap.InfoLevel, pathManager.LogFile(stdoutLogFile), false, false),
+			logging.CreateLogger(zap.InfoLevel, pathManager.LogFile(stderrLogFile), false, false),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create %s exec component: %w", execConfig.Name, err)
+		}
+
+		testsComponents = append(testsComponents, execComponent)
+	}
+
+	for _, name := range names {
+		if !slices.ContainsFunc(testsComponents, func(component components.Component) bool { return component.Name() == name }) {
+			return nil, fmt.Errorf("unknown test component: %s", name)
+		}
+	}
+
+	return testsComponents, nil
+}
+
+// runTestComponents runs the test components for the given duration, or until the context is done,
+// and collects their results together with the range of snapshots produced by the local node. It also
+// tells if any of the components failed during the test. The failed results are returned together with
+// the error, when the components could not run or the snapshots could not be read, so they can still be reported.
+func runTestComponents(
+	ctx context.Context,
+	duration time.Duration,
+	mainLogger *zap.Logger,
+	pathManager networkutils.PathManager,
+	testsComponents []components.Component,
+	monitor *components.Monitor,
+) (*components.Results, bool, error) {
+	snapshotTestingResults := components.NewResults(time.Now())
+	snapshotTestingResults.Config = runConfigResults(duration, testsComponents)
+	testCtx, testCancel := context.WithTimeout(ctx, duration)
+	defer testCancel()
+
+	runErr := components.Run(testCtx, pathManager, mainLogger.Named("controller"), testsComponents, monitor)
+	componentsFailed := runErr != nil
+	if errors.Is(runErr, components.ComponentFailureErr) {
+		// component failed but it is expected and We still want to have results
+		mainLogger.Error("failed to run test components", zap.Error(runErr))
+		runErr = nil
+	}
+
+	for _, component := range testsComponents {
+		component.Result(snapshotTestingResults)
+	}
+	snapshotTestingResults.Finish(time.Now())
+
+	if runErr != nil {
+		// Components were not cleaned up or started, so the test did not run at all
+		err := fmt.Errorf("failed to run test components: %w", runErr)
+		snapshotTestingResults.SetStatus(components.Unhealthy, components.ReasonSetupFailed, err.Error())
+		return snapshotTestingResults, componentsFailed, err
+	}
+
+	explainVisorExit(snapshotTestingResults)
+	explainStateDivergence(snapshotTestingResults)
+	explainSmokeTestsFailure(snapshotTestingResults)
+
+	// Local node did not run, so there is no snapshots to check
+	if !slices.ContainsFunc(testsComponents, func(component components.Component) bool {
+		return component.Name() == components.ComponentNameVisor
+	}) {
+		return snapshotTestingResults, componentsFailed, nil
 	}
 
 	// Run post-snapshot-testing actions
-	snapshotMin, snapshotMax, err := networkutils.LocalSnapshotsRange(&pathManager)
+	snapshotHeights, err := networkutils.LocalSnapshots(&pathManager)
 	if err != nil {
+		switch {
+		// The interrupted run is reported as interrupted, the node may not have produced any snapshot yet
+		case ctx.Err() != nil:
+			mainLogger.Error("failed to get snapshot range of the interrupted run", zap.Error(err))
 		// There is expected error when network did not start, snapshot db is empty or not created
-		if componentsFailed && errors.Is(err, networkutils.SnapshotDatabaseDoesNotExistErr) {
+		case componentsFailed && errors.Is(err, networkutils.SnapshotDatabaseDoesNotExistErr):
 			mainLogger.Error("failed to get snapshot range", zap.Error(err))
-		} else {
-			return fmt.Errorf("failed to get snapshot range: %w", err)
+		default:
+			// No snapshots found fails the snapshot production check of the reports
+			snapshotTestingResults.Snapshots = components.NewSnapshotsResults(nil)
+			return snapshotTestingResults, componentsFailed, fmt.Errorf("failed to get snapshot range: %w", err)
 		}
 	}
 
-	snapshotTestingResults := components.MergeResults(
-		postgresql.Result(),
-		watchdog.Result(),
-		visor.Result(),
-	)
-	snapshotTestingResults["snapshot-min"] = snapshotMin
-	snapshotTestingResults["snapshot-max"] = snapshotMax
-	snapshotTestingResults["should-skip-failure"] = false
+	snapshotTestingResults.Snapshots = components.NewSnapshotsResults(snapshotHeights)
 
-	return writeResult(duration, mainLogger, snapshotTestingResults, pathManager)
+	return snapshotTestingResults, componentsFailed, nil
+}
+
+// runConfigResults describes the config of the run with the given test components.
+func runConfigResults(duration time.Duration, testsComponents []components.Component) *components.RunConfigResults {
+	res := &components.RunConfigResults{
+		Environment:     environment,
+		ConfigPath:      configPath,
+		DurationSeconds: duration.Seconds(),
+		Components:      []string{},
+	}
+	for _, component := range testsComponents {
+		res.Components = append(res.Components, component.Name())
+	}
+
+	return res
+}
+
+// explainVisorExit replaces the watchdog reason with the vegavisor exit details when the node died
+// before the end of the test. The watchdog only knows the node crashed, but not how the process exited.
+func explainVisorExit(results *components.Results) {
+	if results.Visor == nil || !results.Visor.ExitReason.EarlyTermination() {
+		return
+	}
+
+	exitCode := "N/A"
+	if results.Visor.ExitCode != nil {
+		exitCode = fmt.Sprint(*results.Visor.ExitCode)
+	}
+	exitTime := "N/A"
+	if results.Visor.ExitedAt != nil {
+		exitTime = results.Visor.ExitedAt.Format(time.RFC3339)
+	}
+
+	results.SetStatus(components.Unhealthy, components.ReasonCrashed, fmt.Sprintf(
+		"Node died: vegavisor exited(%s) with code %s, signal %q at %s",
+		results.Visor.ExitReason,
+		exitCode,
+		results.Visor.ExitSignal,
+		exitTime,
+	))
+}
+
+// explainStateDivergence marks the test as failed when the local node state differs from the network. The
+// watchdog only compares heights, so the node may look healthy.
+func explainStateDivergence(results *components.Results) {
+	if results.Consistency == nil || results.Consistency.Status != components.ConsistencyDiverged {
+		return
+	}
+
+	firstDivergentHeight := "N/A"
+	if results.Consistency.FirstDivergentHeight != nil {
+		firstDivergentHeight = fmt.Sprint(*results.Consistency.FirstDivergentHeight)
+	}
+
+	results.SetStatus(components.StateDiverged, components.ReasonStateDiverged, fmt.Sprintf(
+		"Local state diverged from the network, first divergent block: %s",
+		firstDivergentHeight,
+	))
+}
+
+// explainSmokeTestsFailure marks the test as failed when the caught up data-node serves broken APIs.
+func explainSmokeTestsFailure(results *components.Results) {
+	if results.SmokeTests == nil || results.SmokeTests.Status != components.SmokeTestsFailed {
+		return
+	}
+
+	// Node failed for more important reason
+	if results.Status != components.Healthy && results.Status != components.MaybeHealthy {
+		return
+	}
+
+	results.SetStatus(
+		components.Unhealthy,
+		components.ReasonSmokeTestsFailed,
+		"Data-node API smoke tests failed after the node caught up",
+	)
 }
 
 func shouldSkipFailure(err error) bool {
 	return environment == config.NetworkNameDevnet1 && (errors.Is(err, networkutils.ErrNoHealthyNodeFound) || errors.Is(err, networkutils.ErrNoSnapshotForRestartFound))
 }
 
-func writeResult(duration time.Duration, mainLogger *zap.Logger, snapshotTestingResults components.ComponentResults, pathManager networkutils.PathManager) error {
+// writeResult writes the results of the run or the scenario into the results file.
+func writeResult(duration time.Duration, mainLogger *zap.Logger, snapshotTestingResults any, pathManager networkutils.PathManager) error {
 	mainLogger.Sugar().Infof("Snapshot testing finished after %s", duration.String())
 	jsonResults, err := json.MarshalIndent(snapshotTestingResults, "", "    ")
 	if err != nil {
@@ -163,3 +601,48 @@ func writeResult(duration time.Duration, mainLogger *zap.Logger, snapshotTesting
 	}
 	return nil
 }
+
+func writeJUnitReport(mainLogger *zap.Logger, junitReport report.JUnitTestSuites, pathManager networkutils.PathManager) error {
+	mainLogger.Sugar().Infof("Writing junit report to the %s file", pathManager.JUnitReport())
+	if err := report.WriteJUnit(pathManager.JUnitReport(), junitReport); err != nil {
+		return fmt.Errorf("failed to write junit report: %w", err)
+	}
+	return nil
+}
+
+// writeHTMLReport writes the HTML report with charts drawn from the current watchdog metrics file.
+func writeHTMLReport(
+	mainLogger *zap.Logger,
+	fileName string,
+	title string,
+	snapshotTestingResults *components.Results,
+	pathManager networkutils.PathManager,
+) error {
+	metrics, err := components.ReadProbeMetrics(pathManager.WatchdogMetrics())
+	if err != nil {
+		// Watchdog did not run, the report is still useful without charts
+		mainLogger.Sugar().Infof("Watchdog metrics are not available for the html report: %s", err.Error())
+	}
+
+	mainLogger.Sugar().Infof("Writing html report to the %s file", pathManager.HTMLReport(fileName))
+	if err := report.WriteHTML(pathManager.HTMLReport(fileName), title, snapshotTestingResults, metrics); err != nil {
+		return fmt.Errorf("failed to write html report: %w", err)
+	}
+	return nil
+}
+
+// recordHistory appends the run to the history file. The history is not essential for the test, so
+// failures are only logged.
+func recordHistory(mainLogger *zap.Logger, runName string, snapshotTestingResults *components.Results, pathManager networkutils.PathManager) {
+	historyFile := historyFilePath(pathManager)
+	entry := report.NewHistoryEntry(report.Run{
+		Name:    runName,
+		WorkDir: workDir,
+		Results: snapshotTestingResults,
+	}, time.Now())
+
+	mainLogger.Sugar().Infof("Recording the run in the %s history file", historyFile)
+	if err := report.AppendHistory(historyFile, entry); err != nil {
+		mainLogger.Error("failed to record the run in history", zap.Error(err))
+	}
+}
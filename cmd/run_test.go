@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/vegaprotocol/snapshot-testing/components"
+	"github.com/vegaprotocol/snapshot-testing/networkutils"
+)
+
+// fakeComponent runs until the context is done, or fails to start with the given error.
+type fakeComponent struct {
+	name     string
+	startErr error
+}
+
+func (fc *fakeComponent) Name() string {
+	return fc.name
+}
+
+func (fc *fakeComponent) Start(ctx context.Context) error {
+	if fc.startErr != nil {
+		return fc.startErr
+	}
+
+	<-ctx.Done()
+	return nil
+}
+
+func (fc *fakeComponent) Stop(ctx context.Context) error {
+	return nil
+}
+
+func (fc *fakeComponent) Healthy() (bool, error) {
+	return true, nil
+}
+
+func (fc *fakeComponent) Cleanup(ctx context.Context) error {
+	return nil
+}
+
+func (fc *fakeComponent) Result(results *components.Results) {
+	results.SetStatus(components.Healthy, components.ReasonNone, "")
+}
+
+func TestRunTestComponentsStartFailure(t *testing.T) {
+	testsComponents := []components.Component{
+		&fakeComponent{name: components.ComponentNamePostgresql, startErr: errors.New("port already allocated")},
+		&fakeComponent{name: components.ComponentNameVisor},
+	}
+
+	results, componentsFailed, err := runTestComponents(
+		context.Background(),
+		time.Minute,
+		zap.NewNop(),
+		networkutils.NewPathManager(t.TempDir()),
+		testsComponents,
+		nil,
+	)
+	if err == nil || !componentsFailed {
+		t.Fatalf("expected the failed components with the error, got %t and %v", componentsFailed, err)
+	}
+	if results == nil {
+		t.Fatal("expected the results to be reported with the error")
+	}
+	if results.Status != components.Unhealthy || results.ReasonCode != components.ReasonSetupFailed {
+		t.Errorf("expected the unhealthy status with the setup failure, got %s with %s", results.Status, results.ReasonCode)
+	}
+	if !resultsOutcome(results).SetupFailed {
+		t.Error("expected the setup failure outcome")
+	}
+	if results.Config == nil || len(results.Config.Components) != len(testsComponents) {
+		t.Errorf("expected the config with %d components, got %+v", len(testsComponents), results.Config)
+	}
+	if results.FinishedAt.IsZero() {
+		t.Error("expected the finish time")
+	}
+}
@@ -0,0 +1,433 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+
+	"github.com/vegaprotocol/snapshot-testing/components"
+	"github.com/vegaprotocol/snapshot-testing/config"
+	"github.com/vegaprotocol/snapshot-testing/logging"
+	"github.com/vegaprotocol/snapshot-testing/networkutils"
+	"github.com/vegaprotocol/snapshot-testing/notifications"
+	"github.com/vegaprotocol/snapshot-testing/report"
+)
+
+var scenarioCmd = &cobra.Command{
+	Use:   "scenario",
+	Short: "Run snapshot-testing described by the scenario file.",
+}
+
+var scenarioRunCmd = &cobra.Command{
+	Use:   "run <scenario-file>",
+	Short: "Run all phases from the scenario file.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		exitWith(runScenario(args[0]))
+	},
+}
+
+func init() {
+	scenarioRunCmd.PersistentFlags().DurationVar(
+		&visorStopTimeout,
+		"visor-stop-timeout",
+		60*time.Second,
+		"time given to the vegavisor to exit after SIGTERM before it is killed with its child processes",
+	)
+	addWatchdogFlags(scenarioRunCmd)
+	addConsistencyFlags(scenarioRunCmd)
+	addMonitoringFlags(scenarioRunCmd)
+	addNotificationFlags(scenarioRunCmd)
+	addTracingFlags(scenarioRunCmd)
+	addExitCodeFlags(scenarioRunCmd)
+
+	scenarioCmd.AddCommand(scenarioRunCmd)
+}
+
+// ScenarioResults are written into the results file by the scenario command.
+type ScenarioResults struct {
+	SchemaVersion     int                      `json:"schema_version"`
+	Scenario          string                   `json:"scenario"`
+	Status            components.HealthyStatus `json:"status"`
+	ShouldSkipFailure bool                     `json:"should_skip_failure"`
+	// The scenario was stopped with SIGINT or SIGTERM before the end, next phases were not executed
+	Interrupted bool           `json:"interrupted"`
+	Phases      []PhaseResults `json:"phases"`
+}
+
+type PhaseResults struct {
+	Name   string           `json:"name"`
+	Type   config.PhaseType `json:"type"`
+	Passed bool             `json:"passed"`
+	Error  string           `json:"error,omitempty"`
+	// Steps of the prepare phase
+	Setup *components.SetupResults `json:"setup,omitempty"`
+	// Results of the run phase
+	Results *components.Results `json:"results,omitempty"`
+	// Results of the assert phase
+	Assertions []assertionResult `json:"assertions,omitempty"`
+}
+
+type assertionResult struct {
+	Phase  string `json:"phase"`
+	Key    string `json:"key"`
+	Equals string `json:"equals"`
+	Actual string `json:"actual"`
+	Passed bool   `json:"passed"`
+}
+
+func runScenario(scenarioPath string) error {
+	if err := validateReportFormats(); err != nil {
+		return err
+	}
+	if err := validateFailOn(); err != nil {
+		return err
+	}
+
+	scenario, err := config.LoadScenario(scenarioPath)
+	if err != nil {
+		return fmt.Errorf("failed to load scenario: %w", err)
+	}
+
+	pathManager := networkutils.NewPathManager(workDir)
+	if err := pathManager.CreateDirectoryStructure(); err != nil {
+		return fmt.Errorf("failed to prepare working directory: %w", err)
+	}
+
+	mainLogger := logging.CreateLogger(zap.InfoLevel, pathManager.LogFile("main.log"), true, true)
+	networkConfig, err := config.NetworkConfigForGivenInput(environment, configPath, workDir)
+	if err != nil {
+		return fmt.Errorf("failed to get network config: %w", err)
+	}
+	if err := applyConfigFlags(networkConfig); err != nil {
+		return err
+	}
+
+	stopTracing, err := startTracing(mainLogger)
+	if err != nil {
+		return err
+	}
+	defer stopTracing()
+
+	notifier, err := createNotifier(mainLogger, *networkConfig, workDir)
+	if err != nil {
+		return err
+	}
+
+	monitor := components.NewMonitor()
+	monitor.AddStateListener(notifier)
+	stopMonitoring, err := startMonitoring(mainLogger, monitor)
+	defer stopMonitoring()
+	if err != nil {
+		return fmt.Errorf("failed to start monitoring: %w", err)
+	}
+
+	ctx, stop := interruptContext()
+	defer stop()
+
+	scenarioStart := time.Now()
+	phasesResults := []PhaseResults{}
+	// Results of the already finished phases by the phase name, used by assertions
+	resultsByPhase := map[string]*components.Results{}
+	allPassed := true
+	shouldSkip := false
+	// The database of the run phase is kept for the next run phases until the node is prepared again
+	runSincePrepare := false
+
+	for _, phase := range scenario.Phases {
+		mainLogger.Sugar().Infof("Starting the %s phase(%s)", phase.Name, phase.Type)
+		phaseLogger := mainLogger.Named(phase.Name)
+		monitor.SetPhase(phase.Name, string(phase.Type))
+
+		passed := true
+		phaseResult := PhaseResults{
+			Name: phase.Name,
+			Type: phase.Type,
+		}
+
+		var phaseErr error
+		switch phase.Type {
+		case config.PhasePrepare:
+			var setupReport networkutils.SetupReport
+			setupReport, phaseErr = prepareNetwork(
+				ctx,
+				phaseLogger.Named("prepare-network"),
+				pathManager,
+				*networkConfig,
+				config.DefaultCredentials,
+				externalAddress,
+			)
+			phaseResult.Setup = components.NewSetupResults(setupReport)
+			runSincePrepare = false
+			if phaseErr != nil && shouldSkipFailure(phaseErr) {
+				shouldSkip = true
+			}
+
+		case config.PhaseRun:
+			phaseErr = func() error {
+				options := phase.Options
+				if options.Postgresql.KeepDatabase == nil {
+					// The data-node restarted from the local snapshot needs the data it produced before
+					keepDatabase := runSincePrepare
+					options.Postgresql.KeepDatabase = &keepDatabase
+				}
+				runSincePrepare = true
+
+				testsComponents, err := createTestComponents(phaseLogger, pathManager, *networkConfig, phase.Components, options)
+				if err != nil {
+					return err
+				}
+
+				results, componentsFailed, err := runTestComponents(ctx, phase.Duration, phaseLogger, pathManager, testsComponents, monitor)
+				phaseResult.Results = results
+				resultsByPhase[phase.Name] = results
+				recordHistory(phaseLogger, fmt.Sprintf("%s:%s", workDir, phase.Name), results, pathManager)
+				if err != nil {
+					return err
+				}
+
+				// Each run phase has its own report with the probes recorded during the phase
+				if slices.Contains(reportFormats, report.FormatHTML) {
+					if err := writeHTMLReport(
+						phaseLogger,
+						fmt.Sprintf("report-%s.html", phase.Name),
+						fmt.Sprintf("snapshot-testing %s: %s", scenario.Name, phase.Name),
+						results,
+						pathManager,
+					); err != nil {
+						return err
+					}
+				}
+
+				if results.Status == components.NetworkHalted {
+					shouldSkip = true
+				}
+				if componentsFailed || (results.Status != "" && results.Status != components.Healthy) {
+					passed = false
+				}
+
+				return nil
+			}()
+
+		case config.PhaseRestartFromLocalSnapshot:
+			phaseErr = networkutils.PrepareLocalSnapshotRestart(&pathManager)
+
+		case config.PhaseAssert:
+			assertions := []assertionResult{}
+			for _, assertion := range phase.Assertions {
+				actual := assertionActualValue(resultsByPhase[assertion.Phase], assertion.Key)
+
+				result := assertionResult{
+					Phase:  assertion.Phase,
+					Key:    assertion.Key,
+					Equals: assertion.Equals,
+					Actual: actual,
+					Passed: actual == assertion.Equals,
+				}
+				if !result.Passed {
+					phaseLogger.Sugar().Errorf(
+						"Assertion failed: %s from the %s phase is %s, expected %s",
+						assertion.Key,
+						assertion.Phase,
+						actual,
+						assertion.Equals,
+					)
+					passed = false
+				}
+				assertions = append(assertions, result)
+			}
+			phaseResult.Assertions = assertions
+		}
+
+		if phaseErr != nil {
+			phaseLogger.Error("Phase failed", zap.Error(phaseErr))
+			passed = false
+			phaseResult.Error = phaseErr.Error()
+		}
+
+		phaseResult.Passed = passed
+		phasesResults = append(phasesResults, phaseResult)
+		allPassed = allPassed && passed
+
+		// There is no point to run next phases when the current one could not be executed
+		if phaseErr != nil {
+			break
+		}
+
+		if ctx.Err() != nil {
+			mainLogger.Sugar().Infof("Scenario interrupted during the %s phase", phase.Name)
+			break
+		}
+	}
+
+	status := components.Healthy
+	if !allPassed {
+		status = components.Unhealthy
+	}
+
+	scenarioResults := ScenarioResults{
+		SchemaVersion:     components.ResultsSchemaVersion,
+		Scenario:          scenario.Name,
+		Status:            status,
+		ShouldSkipFailure: shouldSkip,
+		Interrupted:       ctx.Err() != nil,
+		Phases:            phasesResults,
+	}
+
+	if err := writeResult(time.Since(scenarioStart), mainLogger, scenarioResults, pathManager); err != nil {
+		return err
+	}
+
+	if slices.Contains(reportFormats, report.FormatJUnit) {
+		if err := writeJUnitReport(mainLogger, scenarioJUnitReport(scenarioResults), pathManager); err != nil {
+			return err
+		}
+	}
+
+	notifier.Send(scenarioNotification(scenarioResults))
+
+	return scenarioOutcome(scenarioResults).err()
+}
+
+// scenarioOutcome describes how the scenario finished. Failed prepare phase counts as the setup failure.
+func scenarioOutcome(scenarioResults ScenarioResults) testOutcome {
+	outcome := testOutcome{
+		Status:            scenarioResults.Status,
+		ShouldSkipFailure: scenarioResults.ShouldSkipFailure,
+		Interrupted:       scenarioResults.Interrupted,
+	}
+
+	failedPhases := []string{}
+	for _, phase := range scenarioResults.Phases {
+		if phase.Passed {
+			continue
+		}
+		failedPhases = append(failedPhases, phase.Name)
+		if phase.Type == config.PhasePrepare && phase.Error != "" {
+			outcome.SetupFailed = true
+		}
+	}
+	if len(failedPhases) > 0 {
+		outcome.Reason = fmt.Sprintf("failed phases: %s", strings.Join(failedPhases, ", "))
+	}
+
+	return outcome
+}
+
+// scenarioNotification summarises the scenario outcome with the failed phases.
+func scenarioNotification(scenarioResults ScenarioResults) notifications.Notification {
+	failedPhases := []string{}
+	notification := notifications.Notification{
+		Time:              time.Now(),
+		Run:               workDir,
+		Environment:       environment,
+		Status:            scenarioResults.Status,
+		ShouldSkipFailure: scenarioResults.ShouldSkipFailure,
+	}
+
+	for _, phase := range scenarioResults.Phases {
+		if phase.Setup != nil {
+			notification.ChainID = phase.Setup.ChainID
+			notification.AppVersion = phase.Setup.AppVersion
+		}
+		if !phase.Passed {
+			failedPhases = append(failedPhases, phase.Name)
+		}
+	}
+
+	notification.Event = config.NotificationRunFinished
+	if notification.Failed() {
+		notification.Event = config.NotificationRunFailed
+	}
+	if len(failedPhases) > 0 {
+		notification.Reason = fmt.Sprintf("failed phases: %s", strings.Join(failedPhases, ", "))
+	}
+	notification.Message = fmt.Sprintf(
+		"Snapshot testing scenario %s of %s finished with the %s status",
+		scenarioResults.Scenario,
+		environment,
+		scenarioResults.Status,
+	)
+	if notification.Reason != "" {
+		notification.Message += ": " + notification.Reason
+	}
+	if notification.ShouldSkipFailure {
+		notification.Message += " (failure should be skipped)"
+	}
+
+	return notification
+}
+
+// scenarioJUnitReport maps each phase of the scenario to the test suite.
+func scenarioJUnitReport(scenarioResults ScenarioResults) report.JUnitTestSuites {
+	suites := []report.JUnitTestSuite{}
+	for _, phase := range scenarioResults.Phases {
+		suite := report.NewJUnitTestSuite(phase.Name, time.Time{}, 0, nil)
+		switch {
+		case phase.Results != nil:
+			suite = report.RunTestSuite(phase.Name, phase.Results)
+		case phase.Type == config.PhasePrepare:
+			suite = report.NewJUnitTestSuite(phase.Name, time.Time{}, 0, report.SetupTestCases(phase.Name, phase.Setup))
+		case phase.Type == config.PhaseAssert:
+			testCases := []report.JUnitTestCase{}
+			for _, assertion := range phase.Assertions {
+				testCase := report.JUnitTestCase{
+					ClassName: phase.Name,
+					Name:      fmt.Sprintf("%s/%s", assertion.Phase, assertion.Key),
+				}
+				if !assertion.Passed {
+					testCase.Fail("ASSERTION_FAILED", fmt.Sprintf(
+						"%s from the %s phase is %s, expected %s",
+						assertion.Key,
+						assertion.Phase,
+						assertion.Actual,
+						assertion.Equals,
+					), "")
+				}
+				testCases = append(testCases, testCase)
+			}
+			suite = report.NewJUnitTestSuite(phase.Name, time.Time{}, 0, testCases)
+		}
+
+		// Phase that could not be executed may have no test cases describing the error
+		if suite.Tests == 0 || (phase.Error != "" && suite.Failures == 0) {
+			testCase := report.JUnitTestCase{
+				ClassName: phase.Name,
+				Name:      string(phase.Type),
+			}
+			if phase.Error != "" {
+				testCase.Fail("PHASE_FAILED", phase.Error, "")
+			}
+			suite = report.NewJUnitTestSuite(phase.Name, time.Time{}, suite.Time, append(suite.TestCases, testCase))
+		}
+
+		suites = append(suites, suite)
+	}
+
+	return report.NewJUnitTestSuites(scenarioResults.Scenario, suites...)
+}
+
+// assertionActualValue returns value from the phase results for the dot separated key, e.g.
+// `watchdog.state` or `snapshots.max`. N/A is returned when the value does not exist.
+func assertionActualValue(results *components.Results, key string) string {
+	if results == nil {
+		return "N/A"
+	}
+
+	document, err := json.Marshal(results)
+	if err != nil {
+		return "N/A"
+	}
+
+	value, err := networkutils.GetJSONField(document, key)
+	if err != nil || value == nil {
+		return "N/A"
+	}
+
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"slices"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/vegaprotocol/snapshot-testing/components"
+	"github.com/vegaprotocol/snapshot-testing/monitoring"
+	"github.com/vegaprotocol/snapshot-testing/report"
+)
+
+var (
+	statusServerAddr string
+	statusOutput     string
+	statusFollow     bool
+)
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the live status of the run started with the --status-addr flag.",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		exitWith(runStatus())
+	},
+}
+
+func init() {
+	statusCmd.PersistentFlags().StringVar(
+		&statusServerAddr,
+		"addr",
+		"localhost:2113",
+		"address of the status server set with the --status-addr flag of the run",
+	)
+	statusCmd.PersistentFlags().StringVar(
+		&statusOutput,
+		"output",
+		report.FormatTable,
+		fmt.Sprintf("output format, available values are: %s, %s", report.FormatTable, report.FormatJSON),
+	)
+	statusCmd.PersistentFlags().BoolVar(
+		&statusFollow,
+		"follow",
+		false,
+		"print events of the watchdog timeline as they happen until the run finishes",
+	)
+}
+
+func runStatus() error {
+	if statusOutput != report.FormatTable && statusOutput != report.FormatJSON {
+		return fmt.Errorf("unknown output format %q", statusOutput)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	client := monitoring.NewClient(statusServerAddr)
+	status, err := client.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	if statusOutput == report.FormatJSON {
+		if err := writeStatusJSON(os.Stdout, status); err != nil {
+			return err
+		}
+	} else {
+		if err := writeStatusTable(os.Stdout, status); err != nil {
+			return err
+		}
+	}
+
+	if !statusFollow {
+		return nil
+	}
+
+	if statusOutput == report.FormatTable {
+		fmt.Fprintln(os.Stdout)
+		fmt.Fprintln(os.Stdout, "Events:")
+	}
+
+	return client.FollowEvents(ctx, func(event components.EventResults) {
+		if statusOutput == report.FormatJSON {
+			// One event per line, so the output can be piped to other tools
+			content, err := json.Marshal(event)
+			if err != nil {
+				return
+			}
+			fmt.Fprintln(os.Stdout, string(content))
+			return
+		}
+
+		fmt.Fprintf(os.Stdout, "%s  %-18s x%-4d %s\n", event.LastTime.Format(time.RFC3339), event.Type, event.Count, event.Message)
+	})
+}
+
+func writeStatusJSON(out io.Writer, status *monitoring.Status) error {
+	content, err := json.MarshalIndent(status, "", "    ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal status into JSON: %w", err)
+	}
+
+	if _, err := fmt.Fprintln(out, string(content)); err != nil {
+		return fmt.Errorf("failed to write status: %w", err)
+	}
+
+	return nil
+}
+
+func writeStatusTable(out io.Writer, status *monitoring.Status) error {
+	now := time.Now()
+
+	table := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(table, "Started at:\t%s (%s ago)\n", status.StartedAt.Format(time.RFC3339), now.Sub(status.StartedAt).Round(time.Second))
+	fmt.Fprintf(table, "Phase:\t%s (%s) for %s\n", status.Phase, status.PhaseType, now.Sub(status.PhaseStartedAt).Round(time.Second))
+	fmt.Fprintf(table, "Node state:\t%s\n", status.NodeState)
+	if status.PartialResults != nil && status.PartialResults.Status != "" {
+		fmt.Fprintf(table, "Status so far:\t%s\n", status.PartialResults.Status)
+	}
+	if probe := status.LastProbe; probe != nil {
+		fmt.Fprintf(table, "Last probe:\t%s\n", probe.Time.Format(time.RFC3339))
+		fmt.Fprintf(table, "Local node up:\t%t\n", probe.LocalNodeUp)
+		if probe.LocalDataNodeDown {
+			fmt.Fprintf(table, "Heights:\tcore %d, data-node down, network %d\n", probe.LocalCoreHeight, probe.NetworkHeight)
+			fmt.Fprintf(table, "Lags:\tcore %d, data-node down\n", probe.CoreLag)
+		} else {
+			fmt.Fprintf(table, "Heights:\tcore %d, data-node %d, network %d\n", probe.LocalCoreHeight, probe.LocalDataNodeHeight, probe.NetworkHeight)
+			fmt.Fprintf(table, "Lags:\tcore %d, data-node %d\n", probe.CoreLag, probe.DataNodeLag)
+		}
+	} else {
+		fmt.Fprintf(table, "Last probe:\tN/A\n")
+	}
+	if status.Snapshots >= 0 {
+		fmt.Fprintf(table, "Local snapshots:\t%d\n", status.Snapshots)
+	}
+	fmt.Fprintf(table, "Visor starts:\t%d\n", status.VisorStarts)
+	if err := table.Flush(); err != nil {
+		return fmt.Errorf("failed to write status table: %w", err)
+	}
+
+	if len(status.Components) == 0 {
+		return nil
+	}
+
+	names := []string{}
+	for name := range status.Components {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	fmt.Fprintln(out)
+	table = tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "COMPONENT\tHEALTH\tCHECKED AT\tERROR")
+	for _, name := range names {
+		health := status.Components[name]
+		checkedAt := "N/A"
+		if health.CheckedAt != nil {
+			checkedAt = health.CheckedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\n", name, health.Status, checkedAt, health.Error)
+	}
+	if err := table.Flush(); err != nil {
+		return fmt.Errorf("failed to write components table: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+
+	"github.com/vegaprotocol/snapshot-testing/tracing"
+)
+
+var tracingConfig tracing.Config
+
+func addTracingFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVar(
+		&tracingConfig.OTLPEndpoint,
+		"tracing-otlp-endpoint",
+		"",
+		"URL of the OTLP/HTTP collector the setup traces are exported to, e.g. http://localhost:4318",
+	)
+	cmd.PersistentFlags().StringVar(
+		&tracingConfig.File,
+		"tracing-file",
+		"",
+		"file the setup traces are appended to as JSON lines",
+	)
+}
+
+// startTracing sets up tracing enabled by flags. The returned function exports the remaining spans.
+func startTracing(mainLogger *zap.Logger) (func(), error) {
+	conf := tracingConfig
+	conf.Attributes = []attribute.KeyValue{attribute.String("vega.environment", environment)}
+
+	shutdown, err := tracing.Setup(context.Background(), conf)
+	if err != nil {
+		return func() {}, fmt.Errorf("failed to setup tracing: %w", err)
+	}
+
+	return func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		if err := shutdown(shutdownCtx); err != nil {
+			mainLogger.Error("Failed to export traces", zap.Error(err))
+		}
+	}, nil
+}
@@ -2,32 +2,32 @@ package components
 
 import (
 	"context"
+	"time"
+
+	"github.com/vegaprotocol/snapshot-testing/config"
 )
 
+// Names of the built-in components
 const (
-	ResultKeySnapshotMin string = "snapshot-min"
-	ResultKeySnapshotMax string = "snapshot-max"
+	ComponentNamePostgresql  = config.ComponentNamePostgresql
+	ComponentNameVisor       = config.ComponentNameVisor
+	ComponentNameWatchdog    = config.ComponentNameWatchdog
+	ComponentNameConsistency = config.ComponentNameConsistency
+	ComponentNameSmokeTests  = config.ComponentNameSmokeTests
 )
 
-type ComponentResults map[string]any
-
 type Component interface {
 	Name() string
 	Start(ctx context.Context) error
 	Stop(ctx context.Context) error
 	Healthy() (bool, error)
 	Cleanup(ctx context.Context) error
-	Result() ComponentResults
+	// Result adds the component section to the results. It is called after the component is stopped.
+	Result(results *Results)
 }
 
-func MergeResults(results ...ComponentResults) ComponentResults {
-	finalResult := ComponentResults{}
-
-	for idx, singleComponentResults := range results {
-		for k := range singleComponentResults {
-			finalResult[k] = results[idx][k]
-		}
-	}
-
-	return finalResult
+// GracefulStopper is implemented by components that need more time to stop than
+// the controller gives by default.
+type GracefulStopper interface {
+	StopTimeout() time.Duration
 }
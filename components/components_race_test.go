@@ -0,0 +1,131 @@
+package components
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/vegaprotocol/snapshot-testing/config"
+	"go.uber.org/zap"
+)
+
+// newFakeNodeServer serves the data-node REST API and the Tendermint RPC of the node producing a block
+// on every statistics query, so the same server can be used as the local node and as the network.
+func newFakeNodeServer(t *testing.T) *httptest.Server {
+	height := atomic.Uint64{}
+	height.Store(100)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/statistics":
+			blockHeight := height.Add(1)
+			now := time.Now().UTC().Format(time.RFC3339Nano)
+			w.Header().Set("x-block-height", strconv.FormatUint(blockHeight, 10))
+			fmt.Fprintf(w, `{"statistics": {"blockHeight": "%d", "currentTime": "%s", "vegaTime": "%s", "chainId": "test-chain", "appVersion": "v0.0.1"}}`, blockHeight, now, now)
+		case "/status":
+			fmt.Fprintf(w, `{"result": {"sync_info": {"latest_block_height": "%d"}}}`, height.Load())
+		case "/block":
+			blockHeight := r.URL.Query().Get("height")
+			fmt.Fprintf(w, `{"result": {"block_id": {"hash": "H%s"}, "block": {"header": {"height": "%s", "app_hash": "A%s"}}}}`, blockHeight, blockHeight, blockHeight)
+		case "/api/v2/markets":
+			fmt.Fprint(w, `{"markets": {"edges": [{"node": {"id": "m1"}}, {"node": {"id": "m2"}}]}}`)
+		case "/api/v2/snapshots":
+			fmt.Fprint(w, `{"coreSnapshots": {"edges": [{"node": {"blockHeight": "100"}}]}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+// TestRunBuiltInComponentsConcurrently runs the real components against the fake node while the monitor
+// collects the partial results, so the race detector sees the Result calls concurrent with the components.
+func TestRunBuiltInComponentsConcurrently(t *testing.T) {
+	shortenControllerIntervals(t)
+
+	server := newFakeNodeServer(t)
+	interval := 20 * time.Millisecond
+
+	watchdog, err := NewWatchdog([]string{server.URL}, config.Watchdog{
+		LocalRESTURL: server.URL,
+		LocalCoreURL: server.URL,
+		Interval:     interval,
+		SpeedWindow:  100 * time.Millisecond,
+	}, filepath.Join(t.TempDir(), "watchdog-metrics.jsonl"), zap.NewNop())
+	if err != nil {
+		t.Fatalf("failed to create watchdog: %v", err)
+	}
+
+	consistency, err := NewConsistencyChecker(
+		[]config.EndpointWithREST{{Endpoint: server.URL, CoreREST: server.URL}},
+		[]string{server.URL},
+		config.Consistency{
+			LocalRPCURL:       server.URL,
+			LocalRESTURL:      server.URL,
+			Interval:          interval,
+			DataNodeResources: []string{"/api/v2/markets"},
+		},
+		zap.NewNop(),
+	)
+	if err != nil {
+		t.Fatalf("failed to create consistency checker: %v", err)
+	}
+
+	smokeTests, err := NewSmokeTests([]string{server.URL}, config.SmokeTests{
+		LocalRESTURL: server.URL,
+		Interval:     interval,
+		Queries:      []config.SmokeTestQuery{{Name: "markets", Path: "/api/v2/markets", Fields: []string{"markets.edges.0.node.id"}}},
+	}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("failed to create smoke tests: %v", err)
+	}
+
+	execComponent, err := NewExec(config.ExecComponent{
+		Name:    "sleeper",
+		Command: "sh",
+		Args:    []string{"-c", "echo started; sleep 10"},
+	}, zap.NewNop(), zap.NewNop(), zap.NewNop())
+	if err != nil {
+		t.Fatalf("failed to create exec component: %v", err)
+	}
+
+	components := []Component{watchdog, consistency, smokeTests, execComponent}
+	monitor := NewMonitor()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	stopReading := readConcurrently(monitor)
+	err = runComponents(ctx, components, monitor)
+	stopReading()
+
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	results := NewResults(time.Now())
+	for _, component := range components {
+		component.Result(results)
+	}
+
+	if results.Watchdog == nil || results.Watchdog.Metrics.Probes == 0 {
+		t.Errorf("expected the watchdog probes in the results, got %+v", results.Watchdog)
+	}
+	if results.Consistency == nil || results.Consistency.Checks == 0 {
+		t.Errorf("expected the consistency checks in the results, got %+v", results.Consistency)
+	}
+	if results.SmokeTests == nil || results.SmokeTests.Status == SmokeTestsNotRun {
+		t.Errorf("expected the smoke tests to run, got %+v", results.SmokeTests)
+	}
+	if exec := results.Exec["sleeper"]; exec == nil || exec.ExitCode == nil {
+		t.Errorf("expected the exec component to be stopped, got %+v", exec)
+	}
+}
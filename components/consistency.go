@@ -0,0 +1,425 @@
+package components
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vegaprotocol/snapshot-testing/config"
+	"github.com/vegaprotocol/snapshot-testing/networkutils"
+	"go.uber.org/zap"
+)
+
+// ConsistencyMismatchesLimit is the max number of mismatches reported in the results
+const ConsistencyMismatchesLimit = 100
+
+type ConsistencyStatus string
+
+const (
+	ConsistencyNotChecked ConsistencyStatus = "NOT_CHECKED"
+	ConsistencyConsistent ConsistencyStatus = "CONSISTENT"
+	ConsistencyDiverged   ConsistencyStatus = "STATE_DIVERGED"
+)
+
+type MismatchKind string
+
+const (
+	MismatchBlockHash MismatchKind = "block-hash"
+	MismatchAppHash   MismatchKind = "app-hash"
+	MismatchDataNode  MismatchKind = "data-node"
+)
+
+type consistencyMismatch struct {
+	time   time.Time
+	kind   MismatchKind
+	height uint64
+	// Tendermint RPC or data-node REST of the remote node
+	remote      string
+	localValue  string
+	remoteValue string
+}
+
+func (cm consistencyMismatch) toResults() ConsistencyMismatchResults {
+	return ConsistencyMismatchResults{
+		Time:        cm.time,
+		Kind:        cm.kind,
+		Height:      cm.height,
+		Remote:      cm.remote,
+		LocalValue:  cm.localValue,
+		RemoteValue: cm.remoteValue,
+	}
+}
+
+type consistencyChecker struct {
+	logger        *zap.Logger
+	conf          config.Consistency
+	rpcPeers      []config.EndpointWithREST
+	dataNodesREST []string
+
+	// mut protects all of the fields below
+	mut                  sync.Mutex
+	stop                 context.CancelFunc
+	checks               uint64
+	lastCheck            time.Time
+	lastMatchedHeight    uint64
+	diverged             bool
+	blocksDiverged       bool
+	firstDivergentHeight uint64
+	mismatches           []consistencyMismatch
+}
+
+func NewConsistencyChecker(
+	rpcPeers []config.EndpointWithREST,
+	dataNodesREST []string,
+	conf config.Consistency,
+	mainLogger *zap.Logger,
+) (Component, error) {
+	if len(rpcPeers) < 1 {
+		return nil, fmt.Errorf("at least one rpc peer is required")
+	}
+
+	return &consistencyChecker{
+		logger:        mainLogger,
+		conf:          conf.Merge(config.DefaultConsistency),
+		rpcPeers:      rpcPeers,
+		dataNodesREST: dataNodesREST,
+		lastCheck:     time.Now(),
+	}, nil
+}
+
+func (cc *consistencyChecker) Name() string {
+	return ComponentNameConsistency
+}
+
+func (cc *consistencyChecker) Start(ctx context.Context) error {
+	restClient := networkutils.DefaultRESTClient()
+
+	checkerCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	cc.mut.Lock()
+	cc.stop = cancel
+	cc.mut.Unlock()
+
+	ticker := time.NewTicker(cc.conf.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-checkerCtx.Done():
+			return nil
+		}
+
+		cc.checkBlocks(checkerCtx, restClient)
+		cc.checkDataNode(checkerCtx, restClient)
+
+		cc.mut.Lock()
+		cc.checks++
+		cc.lastCheck = time.Now()
+		cc.mut.Unlock()
+	}
+}
+
+// checkBlocks compares the latest local block with the same block on the remote RPC peers. The local
+// state diverged when most of the peers disagree with the local node.
+func (cc *consistencyChecker) checkBlocks(ctx context.Context, httpClient *http.Client) {
+	height, err := networkutils.GetTendermintHeight(ctx, httpClient, cc.conf.LocalRPCURL)
+	if err != nil {
+		cc.logger.Info("Skipping blocks consistency check: local node unavailable", zap.Error(err))
+		return
+	}
+
+	localBlock, err := networkutils.GetTendermintBlock(ctx, httpClient, cc.conf.LocalRPCURL, height)
+	if err != nil {
+		cc.logger.Info("Skipping blocks consistency check: failed to get local block", zap.Error(err))
+		return
+	}
+
+	matched := 0
+	mismatches := []consistencyMismatch{}
+	for _, peer := range cc.rpcPeers {
+		if matched+len(mismatches) >= cc.conf.Peers {
+			break
+		}
+
+		remoteBlock, err := networkutils.GetTendermintBlock(ctx, httpClient, peer.Endpoint, height)
+		if err != nil {
+			cc.logger.Sugar().Debugf("The %s peer cannot be used for consistency check: %s", peer.Endpoint, err.Error())
+			continue
+		}
+
+		if mismatch := compareBlocks(localBlock, remoteBlock, peer.Endpoint); mismatch != nil {
+			mismatches = append(mismatches, *mismatch)
+		} else {
+			matched++
+		}
+	}
+
+	if matched+len(mismatches) == 0 {
+		cc.logger.Sugar().Infof("Skipping blocks consistency check: no healthy peer returned block %d", height)
+		return
+	}
+
+	if len(mismatches) <= matched {
+		cc.logger.Sugar().Infof("Local block %d matches %d of %d peers", height, matched, matched+len(mismatches))
+
+		cc.mut.Lock()
+		cc.pushMismatches(mismatches...)
+		if !cc.blocksDiverged {
+			cc.lastMatchedHeight = height
+		}
+		cc.mut.Unlock()
+		return
+	}
+
+	cc.logger.Sugar().Errorf("Local block %d does not match %d of %d peers", height, len(mismatches), matched+len(mismatches))
+
+	cc.mut.Lock()
+	alreadyDiverged := cc.blocksDiverged
+	lastMatchedHeight := cc.lastMatchedHeight
+	cc.diverged = true
+	cc.blocksDiverged = true
+	cc.pushMismatches(mismatches...)
+	cc.mut.Unlock()
+
+	if alreadyDiverged {
+		return
+	}
+
+	firstDivergentHeight := cc.findFirstDivergentHeight(ctx, httpClient, mismatches[0].remote, lastMatchedHeight, height)
+	cc.logger.Sugar().Errorf("Local state diverged from the network at block %d", firstDivergentHeight)
+
+	cc.mut.Lock()
+	if cc.firstDivergentHeight == 0 || firstDivergentHeight < cc.firstDivergentHeight {
+		cc.firstDivergentHeight = firstDivergentHeight
+	}
+	cc.mut.Unlock()
+}
+
+// findFirstDivergentHeight bisects blocks between the last matched and the divergent height. The
+// divergent height is returned when there is no matched block to start from.
+func (cc *consistencyChecker) findFirstDivergentHeight(
+	ctx context.Context,
+	httpClient *http.Client,
+	peerEndpoint string,
+	matchedHeight, divergentHeight uint64,
+) uint64 {
+	if matchedHeight == 0 {
+		return divergentHeight
+	}
+
+	for divergentHeight-matchedHeight > 1 {
+		height := matchedHeight + (divergentHeight-matchedHeight)/2
+
+		localBlock, err := networkutils.GetTendermintBlock(ctx, httpClient, cc.conf.LocalRPCURL, height)
+		if err != nil {
+			cc.logger.Info("Failed to get local block when looking for the first divergent block", zap.Error(err))
+			break
+		}
+
+		remoteBlock, err := networkutils.GetTendermintBlock(ctx, httpClient, peerEndpoint, height)
+		if err != nil {
+			cc.logger.Info("Failed to get remote block when looking for the first divergent block", zap.Error(err))
+			break
+		}
+
+		if compareBlocks(localBlock, remoteBlock, peerEndpoint) == nil {
+			matchedHeight = height
+		} else {
+			divergentHeight = height
+		}
+	}
+
+	return divergentHeight
+}
+
+func compareBlocks(localBlock, remoteBlock *networkutils.TendermintBlock, remote string) *consistencyMismatch {
+	mismatch := &consistencyMismatch{
+		time:   time.Now(),
+		height: localBlock.Height,
+		remote: remote,
+	}
+
+	switch {
+	case localBlock.BlockHash != remoteBlock.BlockHash:
+		mismatch.kind = MismatchBlockHash
+		mismatch.localValue = localBlock.BlockHash
+		mismatch.remoteValue = remoteBlock.BlockHash
+	case localBlock.AppHash != remoteBlock.AppHash:
+		mismatch.kind = MismatchAppHash
+		mismatch.localValue = localBlock.AppHash
+		mismatch.remoteValue = remoteBlock.AppHash
+	default:
+		return nil
+	}
+
+	return mismatch
+}
+
+// dataNodeConfirmations is the number of remote data-nodes that must miss the local item before
+// the local data-node is considered diverged, when that many remote data-nodes are available.
+const dataNodeConfirmations = 2
+
+// checkDataNode spot checks the data-node resources. All pages of the resources are compared.
+func (cc *consistencyChecker) checkDataNode(ctx context.Context, httpClient *http.Client) {
+	for _, resource := range cc.conf.DataNodeResources {
+		cc.checkDataNodeResource(ctx, httpClient, resource)
+	}
+}
+
+// checkDataNodeResource lists the local items first and then compares them with the remote data-nodes
+// that reached the local height after the listing, so the local data-node cannot move past the remote one
+// in the meantime. The local item is reported only when none of the remote data-nodes know it.
+func (cc *consistencyChecker) checkDataNodeResource(ctx context.Context, httpClient *http.Client, resource string) {
+	localIDs, err := networkutils.GetDataNodeResourceIDs(ctx, httpClient, cc.conf.LocalRESTURL, resource)
+	if err != nil {
+		cc.logger.Sugar().Infof("Skipping %s consistency check: %s", resource, err.Error())
+		return
+	}
+
+	// Local items were listed before this height, so the remote data-node at it must know all of them
+	localStatistics, err := networkutils.GetStatistics(ctx, httpClient, cc.conf.LocalRESTURL)
+	if err != nil {
+		cc.logger.Info("Skipping data-node consistency check: local data-node unavailable", zap.Error(err))
+		return
+	}
+
+	missingIDs := localIDs
+	remotes := []string{}
+	for _, restURL := range cc.dataNodesREST {
+		if len(remotes) >= dataNodeConfirmations || len(missingIDs) == 0 {
+			break
+		}
+
+		remoteStatistics, err := networkutils.GetStatistics(ctx, httpClient, restURL)
+		if err != nil || remoteStatistics.DataNodeHeight < localStatistics.DataNodeHeight {
+			continue
+		}
+
+		remoteIDs, err := networkutils.GetDataNodeResourceIDs(ctx, httpClient, restURL, resource)
+		if err != nil {
+			cc.logger.Sugar().Debugf("The %s data-node cannot be used for %s consistency check: %s", restURL, resource, err.Error())
+			continue
+		}
+
+		remotes = append(remotes, restURL)
+		missingIDs = slices.DeleteFunc(missingIDs, func(id string) bool {
+			return slices.Contains(remoteIDs, id)
+		})
+	}
+
+	if len(remotes) == 0 {
+		cc.logger.Sugar().Infof("Skipping %s consistency check: no remote data-node at the local data-node height", resource)
+		return
+	}
+	if len(missingIDs) == 0 {
+		return
+	}
+	if len(remotes) < min(dataNodeConfirmations, len(cc.dataNodesREST)) {
+		cc.logger.Sugar().Infof("Skipping %s consistency check: %d local items unknown to the %s data-node are not confirmed by other data-node", resource, len(missingIDs), remotes[0])
+		return
+	}
+
+	remote := strings.Join(remotes, ", ")
+	cc.mut.Lock()
+	defer cc.mut.Unlock()
+
+	for _, id := range missingIDs {
+		cc.logger.Sugar().Errorf("The %s item from the local %s is unknown to the %s data-nodes", id, resource, remote)
+		cc.pushMismatches(consistencyMismatch{
+			time:        time.Now(),
+			kind:        MismatchDataNode,
+			height:      localStatistics.DataNodeHeight,
+			remote:      remote,
+			localValue:  fmt.Sprintf("%s: %s", resource, id),
+			remoteValue: "N/A",
+		})
+	}
+	cc.diverged = true
+	// Item was created at or before the local data-node height, the blocks may tell the exact height
+	if cc.firstDivergentHeight == 0 || localStatistics.DataNodeHeight < cc.firstDivergentHeight {
+		cc.firstDivergentHeight = localStatistics.DataNodeHeight
+	}
+}
+
+// pushMismatches records mismatches up to the ConsistencyMismatchesLimit. Caller must hold the cc.mut lock.
+func (cc *consistencyChecker) pushMismatches(mismatches ...consistencyMismatch) {
+	for _, mismatch := range mismatches {
+		if len(cc.mismatches) >= ConsistencyMismatchesLimit {
+			return
+		}
+		cc.mismatches = append(cc.mismatches, mismatch)
+	}
+}
+
+func (cc *consistencyChecker) Stop(ctx context.Context) error {
+	cc.mut.Lock()
+	stop := cc.stop
+	cc.mut.Unlock()
+
+	if stop != nil {
+		stop()
+	}
+
+	return nil
+}
+
+// Healthy implements Component. Divergence is reported in the results, We do not stop the test because of it.
+func (cc *consistencyChecker) Healthy() (bool, error) {
+	cc.mut.Lock()
+	lastCheckDiff := time.Since(cc.lastCheck)
+	cc.mut.Unlock()
+
+	// Single check is slow when the peers do not respond, so We give it few intervals
+	if lastCheckDiff > 3*cc.conf.Interval+time.Minute {
+		return false, fmt.Errorf("consistency check did not finish for %s", lastCheckDiff.String())
+	}
+
+	return true, nil
+}
+
+func (cc *consistencyChecker) Cleanup(ctx context.Context) error {
+	return nil
+}
+
+func (cc *consistencyChecker) Result(results *Results) {
+	cc.mut.Lock()
+	defer cc.mut.Unlock()
+
+	status := ConsistencyNotChecked
+	if cc.diverged {
+		status = ConsistencyDiverged
+	} else if cc.lastMatchedHeight > 0 {
+		status = ConsistencyConsistent
+	}
+
+	mismatches := []ConsistencyMismatchResults{}
+	for _, mismatch := range cc.mismatches {
+		mismatches = append(mismatches, mismatch.toResults())
+	}
+
+	res := &ConsistencyResults{
+		Status:            status,
+		Checks:            cc.checks,
+		LastMatchedHeight: cc.lastMatchedHeight,
+		Mismatches:        mismatches,
+		Config: ConsistencyConfigResults{
+			LocalRPCURL:       cc.conf.LocalRPCURL,
+			LocalRESTURL:      cc.conf.LocalRESTURL,
+			IntervalSeconds:   cc.conf.Interval.Seconds(),
+			Peers:             cc.conf.Peers,
+			DataNodeResources: cc.conf.DataNodeResources,
+		},
+	}
+
+	if cc.firstDivergentHeight > 0 {
+		firstDivergentHeight := cc.firstDivergentHeight
+		res.FirstDivergentHeight = &firstDivergentHeight
+	}
+
+	results.Consistency = res
+}
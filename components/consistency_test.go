@@ -0,0 +1,130 @@
+package components
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/vegaprotocol/snapshot-testing/config"
+	"github.com/vegaprotocol/snapshot-testing/networkutils"
+)
+
+// newFakeDataNode serves the statistics at the given height and the markets with the given IDs.
+func newFakeDataNode(t *testing.T, height uint64, marketIDs ...string) string {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/statistics":
+			now := time.Now().UTC().Format(time.RFC3339Nano)
+			w.Header().Set("x-block-height", fmt.Sprint(height))
+			fmt.Fprintf(w, `{"statistics": {"blockHeight": "%d", "currentTime": "%s", "vegaTime": "%s"}}`, height, now, now)
+		case "/api/v2/markets":
+			edges := []string{}
+			for _, id := range marketIDs {
+				edges = append(edges, fmt.Sprintf(`{"node": {"id": "%s"}}`, id))
+			}
+			fmt.Fprintf(w, `{"markets": {"edges": [%s]}}`, strings.Join(edges, ","))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server.URL
+}
+
+func TestConsistencyCheckDataNode(t *testing.T) {
+	testCases := []struct {
+		name                         string
+		localMarkets                 []string
+		remotes                      func(t *testing.T) []string
+		expectedDiverged             bool
+		expectedFirstDivergentHeight uint64
+	}{
+		{
+			name:         "remote knows all local items",
+			localMarkets: []string{"m1", "m2"},
+			remotes: func(t *testing.T) []string {
+				return []string{newFakeDataNode(t, 110, "m1", "m2", "m3")}
+			},
+		},
+		{
+			name:         "item missing on the only remote",
+			localMarkets: []string{"m1", "m2"},
+			remotes: func(t *testing.T) []string {
+				return []string{newFakeDataNode(t, 110, "m1")}
+			},
+			expectedDiverged:             true,
+			expectedFirstDivergentHeight: 100,
+		},
+		{
+			name:         "item missing on both remotes",
+			localMarkets: []string{"m1", "m2", "m3"},
+			remotes: func(t *testing.T) []string {
+				return []string{newFakeDataNode(t, 110, "m1"), newFakeDataNode(t, 105, "m1", "m2")}
+			},
+			expectedDiverged:             true,
+			expectedFirstDivergentHeight: 100,
+		},
+		{
+			name:         "item known to the second remote",
+			localMarkets: []string{"m1", "m2"},
+			remotes: func(t *testing.T) []string {
+				return []string{newFakeDataNode(t, 110, "m1"), newFakeDataNode(t, 105, "m1", "m2")}
+			},
+		},
+		{
+			name:         "not confirmed by the second remote",
+			localMarkets: []string{"m1", "m2"},
+			remotes: func(t *testing.T) []string {
+				return []string{newFakeDataNode(t, 110, "m1"), newFakeDataNode(t, 90, "m1")}
+			},
+		},
+		{
+			name:         "remote behind the local data-node",
+			localMarkets: []string{"m1", "m2"},
+			remotes: func(t *testing.T) []string {
+				return []string{newFakeDataNode(t, 90, "m1")}
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			local := newFakeDataNode(t, 100, tc.localMarkets...)
+
+			component, err := NewConsistencyChecker(
+				[]config.EndpointWithREST{{Endpoint: local}},
+				tc.remotes(t),
+				config.Consistency{LocalRESTURL: local, DataNodeResources: []string{"/api/v2/markets"}},
+				zap.NewNop(),
+			)
+			if err != nil {
+				t.Fatalf("failed to create consistency checker: %v", err)
+			}
+			cc := component.(*consistencyChecker)
+
+			cc.checkDataNode(context.Background(), networkutils.DefaultRESTClient())
+
+			results := NewResults(time.Now())
+			cc.Result(results)
+			diverged := results.Consistency.Status == ConsistencyDiverged
+			if diverged != tc.expectedDiverged {
+				t.Errorf("expected diverged %t, got %+v", tc.expectedDiverged, results.Consistency)
+			}
+
+			firstDivergentHeight := uint64(0)
+			if results.Consistency.FirstDivergentHeight != nil {
+				firstDivergentHeight = *results.Consistency.FirstDivergentHeight
+			}
+			if firstDivergentHeight != tc.expectedFirstDivergentHeight {
+				t.Errorf("expected first divergent height %d, got %d", tc.expectedFirstDivergentHeight, firstDivergentHeight)
+			}
+		})
+	}
+}
@@ -13,7 +13,18 @@ var (
 	ComponentFailureErr error = fmt.Errorf("one or more tests components failed")
 )
 
-func Run(ctx context.Context, pathManager networkutils.PathManager, mainLogger *zap.Logger, components []Component) error {
+const DefaultStopTimeout = 10 * time.Second
+
+var (
+	// healthCheckInterval is how often the controller checks health of the components
+	healthCheckInterval = 90 * time.Second
+	// baseStopTimeout is the time every component gets to stop, GracefulStopper components get more
+	baseStopTimeout = DefaultStopTimeout
+)
+
+// Run starts the components and checks their health until the context is done. The monitor is optional,
+// it follows the components when it is not nil.
+func Run(ctx context.Context, pathManager networkutils.PathManager, mainLogger *zap.Logger, components []Component, monitor *Monitor) error {
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
@@ -26,36 +37,43 @@ func Run(ctx context.Context, pathManager networkutils.PathManager, mainLogger *
 		}
 	}
 
+	// The watchdog follows the vegavisor process to tell crashed node from the unresponsive one
+	connectVisorListeners(components)
+	connectMonitor(components, monitor)
+
 	mainLogger.Info("Starting the snapshot-testing components")
-	// Start all of the components
+	// Start all of the components. The first start error stops the test, the buffer lets other
+	// components finish their Start when nobody reads errors anymore.
+	startErrors := make(chan error, len(components))
 	for idx, component := range components {
 		mainLogger.Sugar().Infof("Starting the %s component", component.Name())
 		go func(component Component) {
 			if err := component.Start(ctx); err != nil {
-				mainLogger.Fatal(fmt.Sprintf("failed to start the %s component:", component.Name()), zap.Error(err))
+				startErrors <- fmt.Errorf("failed to start the %s component: %w", component.Name(), err)
 			}
 		}(components[idx])
 	}
 
-	// Stop components when they are not needed anymore
+	// Stop components when they are not needed anymore. Components are stopped in the reverse order,
+	// e.g. vegavisor must exit before We remove the postgresql it writes to.
 	defer func(components []Component) {
-		stopCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		defer cancel()
-
-		for _, component := range components {
-			if err := component.Stop(stopCtx); err != nil {
-				mainLogger.Error(fmt.Sprintf("Failed to stop the %s component", component.Name()), zap.Error(err))
-			}
+		for idx := len(components) - 1; idx >= 0; idx-- {
+			stopComponent(mainLogger, components[idx])
+			monitor.componentStopped(components[idx].Name())
 		}
 	}(components)
 
-	ticker := time.NewTicker(30 * time.Second)
+	ticker := time.NewTicker(healthCheckInterval)
+	defer ticker.Stop()
 
 	for {
 		// This is just to finish program earlier when any of the component failed
-		ticker.Reset(90 * time.Second)
+		ticker.Reset(healthCheckInterval)
 		select {
 		case <-ticker.C:
+		case err := <-startErrors:
+			mainLogger.Error("Component failed to start", zap.Error(err))
+			return err
 		case <-ctx.Done():
 			return nil
 		}
@@ -63,6 +81,7 @@ func Run(ctx context.Context, pathManager networkutils.PathManager, mainLogger *
 		mainLogger.Info("Running health check")
 		for _, component := range components {
 			healthy, err := component.Healthy()
+			monitor.componentHealthChecked(component.Name(), healthy, err)
 			if !healthy {
 				allComponentsHealthy = false
 				mainLogger.Error(fmt.Sprintf("The %s component is unhealthy", component.Name()), zap.Error(err))
@@ -77,3 +96,30 @@ func Run(ctx context.Context, pathManager networkutils.PathManager, mainLogger *
 		}
 	}
 }
+
+// stopComponent stops the component and waits for it at most the stop timeout. The component that
+// does not respect the stop context is left behind, so it cannot block stopping the rest of components.
+func stopComponent(mainLogger *zap.Logger, component Component) {
+	stopTimeout := baseStopTimeout
+	if gracefulStopper, ok := component.(GracefulStopper); ok {
+		stopTimeout += gracefulStopper.StopTimeout()
+	}
+
+	stopCtx, cancel := context.WithTimeout(context.Background(), stopTimeout)
+	defer cancel()
+
+	mainLogger.Sugar().Infof("Stopping the %s component", component.Name())
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- component.Stop(stopCtx)
+	}()
+
+	select {
+	case err := <-stopped:
+		if err != nil {
+			mainLogger.Error(fmt.Sprintf("Failed to stop the %s component", component.Name()), zap.Error(err))
+		}
+	case <-stopCtx.Done():
+		mainLogger.Error(fmt.Sprintf("The %s component did not stop in %s", component.Name(), stopTimeout))
+	}
+}
@@ -0,0 +1,278 @@
+package components
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vegaprotocol/snapshot-testing/networkutils"
+	"go.uber.org/zap"
+)
+
+// callLog records calls of the fake components in the order they happened.
+type callLog struct {
+	mut   sync.Mutex
+	calls []string
+}
+
+func (cl *callLog) add(call string) {
+	cl.mut.Lock()
+	defer cl.mut.Unlock()
+
+	cl.calls = append(cl.calls, call)
+}
+
+func (cl *callLog) list() []string {
+	cl.mut.Lock()
+	defer cl.mut.Unlock()
+
+	return append([]string{}, cl.calls...)
+}
+
+// fakeComponent counts ticks in the Start function, so the controller and the monitor read the state
+// written by the running component.
+type fakeComponent struct {
+	name string
+	log  *callLog
+
+	startErr   error
+	cleanupErr error
+	// Number of successful health checks before the component becomes unhealthy, 0 means always healthy
+	unhealthyAfter int
+	// Stop blocks until the channel is closed and ignores the stop context
+	hangOnStop chan struct{}
+
+	mut    sync.Mutex
+	ticks  int
+	checks int
+}
+
+func (fc *fakeComponent) Name() string {
+	return fc.name
+}
+
+func (fc *fakeComponent) Start(ctx context.Context) error {
+	fc.log.add("start:" + fc.name)
+	if fc.startErr != nil {
+		return fc.startErr
+	}
+
+	ticker := time.NewTicker(time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			fc.mut.Lock()
+			fc.ticks++
+			fc.mut.Unlock()
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (fc *fakeComponent) Stop(ctx context.Context) error {
+	fc.log.add("stop:" + fc.name)
+	if fc.hangOnStop != nil {
+		<-fc.hangOnStop
+	}
+
+	return nil
+}
+
+func (fc *fakeComponent) Healthy() (bool, error) {
+	fc.mut.Lock()
+	defer fc.mut.Unlock()
+
+	fc.checks++
+	if fc.unhealthyAfter > 0 && fc.checks > fc.unhealthyAfter {
+		return false, fmt.Errorf("%s failed after %d ticks", fc.name, fc.ticks)
+	}
+
+	return true, nil
+}
+
+func (fc *fakeComponent) Cleanup(ctx context.Context) error {
+	fc.log.add("cleanup:" + fc.name)
+
+	return fc.cleanupErr
+}
+
+func (fc *fakeComponent) Result(results *Results) {
+	fc.mut.Lock()
+	ticks := fc.ticks
+	fc.mut.Unlock()
+
+	if results.Exec == nil {
+		results.Exec = map[string]*ExecResults{}
+	}
+	results.Exec[fc.name] = &ExecResults{ExitCode: &ticks}
+}
+
+// shortenControllerIntervals makes the controller check health and give up on stopping components quickly.
+func shortenControllerIntervals(t *testing.T) {
+	healthCheck, stopTimeout := healthCheckInterval, baseStopTimeout
+	healthCheckInterval = 10 * time.Millisecond
+	baseStopTimeout = 100 * time.Millisecond
+	t.Cleanup(func() {
+		healthCheckInterval, baseStopTimeout = healthCheck, stopTimeout
+	})
+}
+
+// readConcurrently reads the monitor the same way the monitoring servers do, until the returned function is called.
+func readConcurrently(monitor *Monitor) func() {
+	done := make(chan struct{})
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			_ = monitor.Status()
+			_ = monitor.PartialResults()
+		}
+	}()
+
+	return func() {
+		close(done)
+		wg.Wait()
+	}
+}
+
+func runComponents(ctx context.Context, components []Component, monitor *Monitor) error {
+	return Run(ctx, networkutils.PathManager{}, zap.NewNop(), components, monitor)
+}
+
+func TestRunStopsComponentsInReverseOrder(t *testing.T) {
+	shortenControllerIntervals(t)
+
+	log := &callLog{}
+	first := &fakeComponent{name: "first", log: log}
+	second := &fakeComponent{name: "second", log: log}
+	components := []Component{first, second}
+	monitor := NewMonitor()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	stopReading := readConcurrently(monitor)
+	err := runComponents(ctx, components, monitor)
+	stopReading()
+
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	calls := log.list()
+	if !slices.Equal(calls[:2], []string{"cleanup:first", "cleanup:second"}) {
+		t.Errorf("components must be cleaned up in order before start, got %v", calls)
+	}
+	if !slices.Equal(calls[len(calls)-2:], []string{"stop:second", "stop:first"}) {
+		t.Errorf("components must be stopped in reverse order, got %v", calls)
+	}
+
+	status := monitor.Status()
+	for _, component := range components {
+		if health := status.Components[component.Name()]; health.Status != ComponentHealthStopped {
+			t.Errorf("expected the %s component to be %s in the monitor, got %s", component.Name(), ComponentHealthStopped, health.Status)
+		}
+	}
+
+	results := NewResults(time.Now())
+	for _, component := range components {
+		component.Result(results)
+	}
+	if ticks := *results.Exec["first"].ExitCode; ticks == 0 {
+		t.Errorf("expected the first component to run, got %d ticks", ticks)
+	}
+}
+
+func TestRunReturnsWhenComponentIsUnhealthy(t *testing.T) {
+	shortenControllerIntervals(t)
+
+	log := &callLog{}
+	healthy := &fakeComponent{name: "healthy", log: log}
+	failing := &fakeComponent{name: "failing", log: log, unhealthyAfter: 3}
+	monitor := NewMonitor()
+
+	stopReading := readConcurrently(monitor)
+	err := runComponents(context.Background(), []Component{healthy, failing}, monitor)
+	stopReading()
+
+	if !errors.Is(err, ComponentFailureErr) {
+		t.Fatalf("expected %v, got %v", ComponentFailureErr, err)
+	}
+	if calls := log.list(); !slices.Contains(calls, "stop:healthy") || !slices.Contains(calls, "stop:failing") {
+		t.Errorf("all components must be stopped, got %v", calls)
+	}
+}
+
+func TestRunReturnsStartError(t *testing.T) {
+	shortenControllerIntervals(t)
+
+	log := &callLog{}
+	startErr := errors.New("port already in use")
+	running := &fakeComponent{name: "running", log: log}
+	broken := &fakeComponent{name: "broken", log: log, startErr: startErr}
+
+	err := runComponents(context.Background(), []Component{running, broken}, nil)
+	if !errors.Is(err, startErr) {
+		t.Fatalf("expected the start error, got %v", err)
+	}
+	if calls := log.list(); !slices.Contains(calls, "stop:running") {
+		t.Errorf("running components must be stopped after the start error, got %v", calls)
+	}
+}
+
+func TestRunDoesNotStartComponentsWhenCleanupFailed(t *testing.T) {
+	log := &callLog{}
+	broken := &fakeComponent{name: "broken", log: log, cleanupErr: errors.New("permission denied")}
+	next := &fakeComponent{name: "next", log: log}
+
+	err := runComponents(context.Background(), []Component{broken, next}, nil)
+	if err == nil {
+		t.Fatal("expected the cleanup error")
+	}
+	if calls := log.list(); !slices.Equal(calls, []string{"cleanup:broken"}) {
+		t.Errorf("expected only the failed cleanup, got %v", calls)
+	}
+}
+
+func TestRunDoesNotWaitForHangingComponent(t *testing.T) {
+	shortenControllerIntervals(t)
+
+	log := &callLog{}
+	release := make(chan struct{})
+	defer close(release)
+	first := &fakeComponent{name: "first", log: log}
+	hanging := &fakeComponent{name: "hanging", log: log, hangOnStop: release}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	finished := make(chan error)
+	go func() {
+		finished <- runComponents(ctx, []Component{first, hanging}, NewMonitor())
+	}()
+
+	select {
+	case err := <-finished:
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("controller is blocked by the component that does not stop")
+	}
+
+	if calls := log.list(); !slices.Contains(calls, "stop:first") {
+		t.Errorf("components must be stopped after the hanging one, got %v", calls)
+	}
+}
@@ -0,0 +1,266 @@
+package components
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/vegaprotocol/snapshot-testing/config"
+	"github.com/vegaprotocol/snapshot-testing/logging"
+	"github.com/vegaprotocol/snapshot-testing/networkutils"
+	"go.uber.org/zap"
+)
+
+const (
+	DefaultExecStopTimeout = 10 * time.Second
+	execHealthCheckTimeout = 10 * time.Second
+)
+
+// execState describes the external process. It is always accessed through the execComponent.mut lock.
+type execState struct {
+	started  bool
+	finished bool
+	stopping bool
+
+	exitCode int
+	signal   string
+	exitTime time.Time
+}
+
+type execComponent struct {
+	conf config.ExecComponent
+
+	mainLogger   *zap.Logger
+	stdoutLogger *zap.Logger
+	stderrLogger *zap.Logger
+
+	httpClient *http.Client
+
+	// done is closed when the process exits
+	done chan struct{}
+
+	mut   sync.Mutex
+	cmd   *exec.Cmd
+	state execState
+}
+
+// NewExec creates component that runs an arbitrary external command for the time of the test.
+func NewExec(
+	conf config.ExecComponent,
+	mainLogger *zap.Logger,
+	stdoutLogger *zap.Logger,
+	stderrLogger *zap.Logger,
+) (Component, error) {
+	if err := conf.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid exec component config: %w", err)
+	}
+
+	if conf.StopTimeout == 0 {
+		conf.StopTimeout = DefaultExecStopTimeout
+	}
+
+	return &execComponent{
+		conf:         conf,
+		mainLogger:   mainLogger,
+		stdoutLogger: stdoutLogger,
+		stderrLogger: stderrLogger,
+		httpClient:   networkutils.DefaultRESTClient(),
+		done:         make(chan struct{}),
+	}, nil
+}
+
+func (e *execComponent) Name() string {
+	return e.conf.Name
+}
+
+func (e *execComponent) snapshot() execState {
+	e.mut.Lock()
+	defer e.mut.Unlock()
+
+	return e.state
+}
+
+func (e *execComponent) Result(results *Results) {
+	state := e.snapshot()
+
+	res := &ExecResults{}
+	if state.finished {
+		exitCode := state.exitCode
+		res.ExitCode = &exitCode
+		res.ExitSignal = state.signal
+		res.ExitedAt = optionalTime(state.exitTime)
+	}
+
+	if results.Exec == nil {
+		results.Exec = map[string]*ExecResults{}
+	}
+	results.Exec[e.conf.Name] = res
+}
+
+// StopTimeout implements GracefulStopper.
+func (e *execComponent) StopTimeout() time.Duration {
+	return e.conf.StopTimeout
+}
+
+// Healthy implements Component.
+func (e *execComponent) Healthy() (bool, error) {
+	state := e.snapshot()
+
+	// Still not started
+	if !state.started {
+		return true, nil
+	}
+
+	if state.finished {
+		return false, fmt.Errorf("the %s command exited with code %d at %s", e.conf.Name, state.exitCode, state.exitTime.String())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), execHealthCheckTimeout)
+	defer cancel()
+
+	if len(e.conf.HealthCommand) > 0 {
+		healthCmd := exec.CommandContext(ctx, e.conf.HealthCommand[0], e.conf.HealthCommand[1:]...)
+		healthCmd.Dir = e.conf.WorkDir
+		healthCmd.Env = e.environment()
+		if output, err := healthCmd.CombinedOutput(); err != nil {
+			return false, fmt.Errorf("health command failed(output: %s): %w", output, err)
+		}
+
+		return true, nil
+	}
+
+	if len(e.conf.HealthURL) > 0 {
+		request, err := http.NewRequestWithContext(ctx, http.MethodGet, e.conf.HealthURL, nil)
+		if err != nil {
+			return false, fmt.Errorf("failed to create health request: %w", err)
+		}
+
+		resp, err := e.httpClient.Do(request)
+		if err != nil {
+			return false, fmt.Errorf("failed to send health request: %w", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return false, fmt.Errorf("invalid health response code from %s: got %d", e.conf.HealthURL, resp.StatusCode)
+		}
+	}
+
+	return true, nil
+}
+
+func (e *execComponent) environment() []string {
+	env := os.Environ()
+	for k, v := range e.conf.Env {
+		env = append(env, fmt.Sprintf("%s=%s", k, v))
+	}
+
+	return env
+}
+
+// Start implements Component.
+func (e *execComponent) Start(ctx context.Context) error {
+	if e.conf.StartDelay > 0 {
+		e.mainLogger.Sugar().Infof("Waiting %s before starting the %s command", e.conf.StartDelay, e.conf.Name)
+		select {
+		case <-time.After(e.conf.StartDelay):
+		case <-ctx.Done():
+			return nil
+		}
+	}
+
+	// Same as for the vegavisor, the process runs in its own group, so Stop can terminate all
+	// of its children as well.
+	cmd := exec.Command(e.conf.Command, e.conf.Args...)
+	cmd.Dir = e.conf.WorkDir
+	cmd.Env = e.environment()
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to get stdout pipe: %w", err)
+	}
+
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return fmt.Errorf("failed to get stderr pipe: %w", err)
+	}
+
+	e.mut.Lock()
+	if e.state.stopping {
+		e.mut.Unlock()
+		return nil
+	}
+	e.mainLogger.Sugar().Infof("Starting the %s command: %s %v", e.conf.Name, e.conf.Command, e.conf.Args)
+	if err := cmd.Start(); err != nil {
+		e.mut.Unlock()
+		return fmt.Errorf("failed to start the %s command: %w", e.conf.Name, err)
+	}
+	e.cmd = cmd
+	e.state.started = true
+	e.mut.Unlock()
+
+	streamsWg := sync.WaitGroup{}
+	streamsWg.Add(2)
+	go func(stream io.Reader) {
+		defer streamsWg.Done()
+		if err := logging.StreamLogs(stream, e.stdoutLogger, nil, nil); err != nil && !e.snapshot().stopping {
+			e.mainLogger.Error(fmt.Sprintf("failed to stream %s stdout", e.conf.Name), zap.Error(err))
+		}
+	}(stdout)
+
+	go func(stream io.Reader) {
+		defer streamsWg.Done()
+		if err := logging.StreamLogs(stream, e.stderrLogger, nil, nil); err != nil && !e.snapshot().stopping {
+			e.mainLogger.Error(fmt.Sprintf("failed to stream %s stderr", e.conf.Name), zap.Error(err))
+		}
+	}(stderr)
+
+	// Pipes are closed by Wait, so all the logs must be read before
+	streamsWg.Wait()
+	err = cmd.Wait()
+
+	e.mut.Lock()
+	e.state.finished = true
+	e.state.exitTime = time.Now()
+	e.state.exitCode, e.state.signal = exitDetails(cmd.ProcessState)
+	state := e.state
+	e.mut.Unlock()
+	close(e.done)
+
+	if !state.stopping {
+		e.mainLogger.Error(
+			fmt.Sprintf("the %s command finished before the end of the test", e.conf.Name),
+			zap.Int("exit-code", state.exitCode),
+			zap.Error(err),
+		)
+	}
+
+	return nil
+}
+
+// Stop implements Component.
+func (e *execComponent) Stop(ctx context.Context) error {
+	e.mut.Lock()
+	e.state.stopping = true
+	cmd := e.cmd
+	finished := e.state.finished
+	e.mut.Unlock()
+
+	if cmd == nil || finished {
+		return nil
+	}
+
+	return stopProcessGroup(ctx, e.mainLogger, e.conf.Name, cmd.Process.Pid, e.done, e.conf.StopTimeout)
+}
+
+// Cleanup implements Component.
+func (e *execComponent) Cleanup(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,145 @@
+package components
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/vegaprotocol/snapshot-testing/config"
+	"go.uber.org/zap"
+)
+
+func newTestExec(t *testing.T, conf config.ExecComponent) *execComponent {
+	t.Helper()
+
+	if conf.Name == "" {
+		conf.Name = "test-command"
+	}
+	component, err := NewExec(conf, zap.NewNop(), zap.NewNop(), zap.NewNop())
+	if err != nil {
+		t.Fatalf("failed to create exec component: %v", err)
+	}
+
+	return component.(*execComponent)
+}
+
+// startExec runs the Start function in the background and returns the channel with its result.
+func startExec(ctx context.Context, e *execComponent) <-chan error {
+	finished := make(chan error, 1)
+	go func() {
+		finished <- e.Start(ctx)
+	}()
+
+	return finished
+}
+
+func waitForStart(t *testing.T, finished <-chan error) error {
+	t.Helper()
+
+	select {
+	case err := <-finished:
+		return err
+	case <-time.After(5 * time.Second):
+		t.Fatal("the Start function did not return")
+		return nil
+	}
+}
+
+func TestExecStartDelay(t *testing.T) {
+	e := newTestExec(t, config.ExecComponent{
+		Command:    "true",
+		StartDelay: time.Hour,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if err := waitForStart(t, startExec(ctx, e)); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if healthy, err := e.Healthy(); !healthy {
+		t.Errorf("the command waiting for the start must be healthy, got %v", err)
+	}
+
+	results := NewResults(time.Now())
+	e.Result(results)
+	if exitCode := results.Exec[e.Name()].ExitCode; exitCode != nil {
+		t.Errorf("the command must not start before the delay, got exit code %d", *exitCode)
+	}
+}
+
+func TestExecEarlyExit(t *testing.T) {
+	e := newTestExec(t, config.ExecComponent{
+		Command: "sh",
+		Args:    []string{"-c", "echo failing; exit 3"},
+	})
+
+	if err := waitForStart(t, startExec(context.Background(), e)); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if healthy, _ := e.Healthy(); healthy {
+		t.Error("the command that exited must be unhealthy")
+	}
+
+	results := NewResults(time.Now())
+	e.Result(results)
+	res := results.Exec[e.Name()]
+	if res.ExitCode == nil || *res.ExitCode != 3 {
+		t.Errorf("expected exit code 3, got %v", res.ExitCode)
+	}
+	if res.ExitSignal != "" || res.ExitedAt == nil {
+		t.Errorf("expected exit time without signal, got %+v", res)
+	}
+}
+
+func TestExecStop(t *testing.T) {
+	testCases := []struct {
+		name           string
+		script         string
+		expectedSignal string
+	}{
+		{name: "terminated", script: "sleep 10", expectedSignal: "terminated"},
+		{name: "killed after the stop timeout", script: "trap '' TERM; sleep 10", expectedSignal: "killed"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := newTestExec(t, config.ExecComponent{
+				Command:     "sh",
+				Args:        []string{"-c", tc.script},
+				StopTimeout: 100 * time.Millisecond,
+			})
+
+			finished := startExec(context.Background(), e)
+			// Wait until the process is running, so the Stop has something to stop
+			deadline := time.Now().Add(5 * time.Second)
+			for !e.snapshot().started && time.Now().Before(deadline) {
+				time.Sleep(10 * time.Millisecond)
+			}
+			// Give the shell time to set up the trap
+			time.Sleep(100 * time.Millisecond)
+
+			if healthy, err := e.Healthy(); !healthy {
+				t.Fatalf("the running command must be healthy, got %v", err)
+			}
+
+			stopCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := e.Stop(stopCtx); err != nil {
+				t.Fatalf("expected no stop error, got %v", err)
+			}
+
+			if err := waitForStart(t, finished); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			results := NewResults(time.Now())
+			e.Result(results)
+			if signal := results.Exec[e.Name()].ExitSignal; signal != tc.expectedSignal {
+				t.Errorf("expected the %s signal, got %q", tc.expectedSignal, signal)
+			}
+		})
+	}
+}
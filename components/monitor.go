@@ -0,0 +1,323 @@
+package components
+
+import (
+	"sync"
+	"time"
+)
+
+// WatchdogProbe is the result of a single watchdog probe passed to the ProbeListener.
+type WatchdogProbe struct {
+	Metrics ProbeMetrics
+	State   NodeState
+	// Number of snapshots listed by the local data-node, -1 before the first successful query
+	LocalSnapshots int
+}
+
+// ProbeListener is implemented by components that follow the watchdog probes.
+type ProbeListener interface {
+	WatchdogProbe(probe WatchdogProbe)
+}
+
+type ComponentHealthStatus string
+
+const (
+	ComponentHealthUnknown   ComponentHealthStatus = "UNKNOWN"
+	ComponentHealthHealthy   ComponentHealthStatus = "HEALTHY"
+	ComponentHealthUnhealthy ComponentHealthStatus = "UNHEALTHY"
+	ComponentHealthStopped   ComponentHealthStatus = "STOPPED"
+)
+
+type ComponentHealth struct {
+	Status    ComponentHealthStatus `json:"status"`
+	Error     string                `json:"error"`
+	CheckedAt *time.Time            `json:"checked_at"`
+}
+
+// MonitorStatus is the copy of the live state of the run.
+type MonitorStatus struct {
+	StartedAt      time.Time                  `json:"started_at"`
+	Phase          string                     `json:"phase"`
+	PhaseType      string                     `json:"phase_type"`
+	PhaseStartedAt time.Time                  `json:"phase_started_at"`
+	Components     map[string]ComponentHealth `json:"components"`
+	// Null before the first watchdog probe
+	LastProbe   *ProbeMetrics              `json:"last_probe"`
+	NodeState   NodeState                  `json:"node_state"`
+	Probes      uint64                     `json:"probes"`
+	Snapshots   int                        `json:"local_snapshots"`
+	VisorStarts uint64                     `json:"visor_starts"`
+	VisorExits  map[VisorExitReason]uint64 `json:"visor_exits"`
+}
+
+// monitorEventsLimit is the number of the latest watchdog events kept by the monitor.
+const monitorEventsLimit = 1000
+
+// monitorSubscriberBuffer is the number of events buffered for the subscriber. Events are dropped when
+// the subscriber does not keep up.
+const monitorSubscriberBuffer = 100
+
+// Monitor tracks the live state of the run. It is fed by the controller, the watchdog and the vegavisor,
+// and read by the monitoring servers. All functions are safe to call on the nil monitor.
+type Monitor struct {
+	// mut protects all of the fields below
+	mut        sync.Mutex
+	status     MonitorStatus
+	components []Component
+	// The latest events of the watchdog timeline of all run phases
+	events      []EventResults
+	subscribers map[chan EventResults]struct{}
+	// Notified about the node state changes of all run phases
+	stateListeners []StateListener
+}
+
+func NewMonitor() *Monitor {
+	return &Monitor{
+		status: MonitorStatus{
+			StartedAt:  time.Now(),
+			Components: map[string]ComponentHealth{},
+			NodeState:  NodeNotStarted,
+			Snapshots:  -1,
+			VisorExits: map[VisorExitReason]uint64{},
+		},
+		subscribers: map[chan EventResults]struct{}{},
+	}
+}
+
+// SetPhase sets the current phase of the test.
+func (m *Monitor) SetPhase(name, phaseType string) {
+	if m == nil {
+		return
+	}
+
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	m.status.Phase = name
+	m.status.PhaseType = phaseType
+	m.status.PhaseStartedAt = time.Now()
+}
+
+// componentsStarted resets health of the components started by the controller.
+func (m *Monitor) componentsStarted(components []Component) {
+	if m == nil {
+		return
+	}
+
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	m.components = components
+	m.status.Components = map[string]ComponentHealth{}
+	for _, component := range components {
+		m.status.Components[component.Name()] = ComponentHealth{Status: ComponentHealthUnknown}
+	}
+}
+
+func (m *Monitor) componentHealthChecked(name string, healthy bool, err error) {
+	if m == nil {
+		return
+	}
+
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	now := time.Now()
+	health := ComponentHealth{
+		Status:    ComponentHealthHealthy,
+		CheckedAt: &now,
+	}
+	if !healthy {
+		health.Status = ComponentHealthUnhealthy
+		if err != nil {
+			health.Error = err.Error()
+		}
+	}
+	m.status.Components[name] = health
+}
+
+func (m *Monitor) componentStopped(name string) {
+	if m == nil {
+		return
+	}
+
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	health := m.status.Components[name]
+	health.Status = ComponentHealthStopped
+	m.status.Components[name] = health
+}
+
+// WatchdogProbe implements ProbeListener.
+func (m *Monitor) WatchdogProbe(probe WatchdogProbe) {
+	if m == nil {
+		return
+	}
+
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	metrics := probe.Metrics
+	m.status.LastProbe = &metrics
+	m.status.NodeState = probe.State
+	m.status.Probes++
+	if probe.LocalSnapshots >= 0 {
+		m.status.Snapshots = probe.LocalSnapshots
+	}
+}
+
+// AddStateListener registers the listener notified about the node state changes tracked by the watchdog
+// of any run phase.
+func (m *Monitor) AddStateListener(listener StateListener) {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	m.stateListeners = append(m.stateListeners, listener)
+}
+
+// NodeStateChanged implements StateListener.
+func (m *Monitor) NodeStateChanged(change NodeStateChange) {
+	if m == nil {
+		return
+	}
+
+	m.mut.Lock()
+	m.status.NodeState = change.State
+	listeners := m.stateListeners
+	m.mut.Unlock()
+
+	for _, listener := range listeners {
+		listener.NodeStateChanged(change)
+	}
+}
+
+// WatchdogEvent implements EventListener.
+func (m *Monitor) WatchdogEvent(event EventResults) {
+	if m == nil {
+		return
+	}
+
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	// The watchdog compresses repeated events, so the latest event may be just updated
+	if last := len(m.events) - 1; last >= 0 && m.events[last].Type == event.Type && m.events[last].Time.Equal(event.Time) {
+		m.events[last] = event
+	} else {
+		m.events = append(m.events, event)
+		if len(m.events) > monitorEventsLimit {
+			m.events = m.events[len(m.events)-monitorEventsLimit:]
+		}
+	}
+
+	for subscriber := range m.subscribers {
+		select {
+		case subscriber <- event:
+		default:
+		}
+	}
+}
+
+// SubscribeEvents returns the events received so far and the channel with the next events. The
+// returned function must be called when the subscriber is not interested in events anymore.
+func (m *Monitor) SubscribeEvents() ([]EventResults, <-chan EventResults, func()) {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	events := make(chan EventResults, monitorSubscriberBuffer)
+	m.subscribers[events] = struct{}{}
+
+	unsubscribe := func() {
+		m.mut.Lock()
+		defer m.mut.Unlock()
+
+		delete(m.subscribers, events)
+	}
+
+	return append([]EventResults{}, m.events...), events, unsubscribe
+}
+
+// PartialResults collects results of the running components. Nil is returned before the components
+// are started.
+func (m *Monitor) PartialResults() *Results {
+	m.mut.Lock()
+	components := m.components
+	startedAt := m.status.PhaseStartedAt
+	m.mut.Unlock()
+
+	if len(components) == 0 {
+		return nil
+	}
+
+	results := NewResults(startedAt)
+	for _, component := range components {
+		component.Result(results)
+	}
+
+	return results
+}
+
+// VisorStarted implements VisorListener.
+func (m *Monitor) VisorStarted() {
+	if m == nil {
+		return
+	}
+
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	m.status.VisorStarts++
+}
+
+// VisorExited implements VisorListener.
+func (m *Monitor) VisorExited(reason VisorExitReason) {
+	if m == nil {
+		return
+	}
+
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	m.status.VisorExits[reason]++
+}
+
+// Status returns copy of the live state of the run.
+func (m *Monitor) Status() MonitorStatus {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	status := m.status
+	status.Components = map[string]ComponentHealth{}
+	for name, health := range m.status.Components {
+		status.Components[name] = health
+	}
+	status.VisorExits = map[VisorExitReason]uint64{}
+	for reason, count := range m.status.VisorExits {
+		status.VisorExits[reason] = count
+	}
+	if m.status.LastProbe != nil {
+		lastProbe := *m.status.LastProbe
+		status.LastProbe = &lastProbe
+	}
+
+	return status
+}
+
+// connectMonitor makes the monitor follow the vegavisor and the watchdog.
+func connectMonitor(components []Component, monitor *Monitor) {
+	if monitor == nil {
+		return
+	}
+
+	for _, component := range components {
+		switch c := component.(type) {
+		case *visor:
+			c.AddListener(monitor)
+		case *watchdog:
+			c.AddProbeListener(monitor)
+			c.AddEventListener(monitor)
+			c.AddStateListener(monitor)
+		}
+	}
+	monitor.componentsStarted(components)
+}
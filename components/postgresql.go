@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"sync"
+	"time"
 
 	"github.com/vegaprotocol/snapshot-testing/clients/docker"
 	"github.com/vegaprotocol/snapshot-testing/config"
@@ -13,36 +15,56 @@ import (
 )
 
 type postgresql struct {
-	mainLogger    *zap.Logger
-	stdoutLogger  *zap.Logger
-	stderrLogger  *zap.Logger
-	containerName string
-	credentials   config.PostgreSQLCreds
+	mainLogger   *zap.Logger
+	stdoutLogger *zap.Logger
+	stderrLogger *zap.Logger
+	credentials  config.PostgreSQLCreds
+	// keepDatabase tells to start the container left by the previous run phase instead of the new one
+	keepDatabase bool
+
+	// containerNameMut protects the containerName set by Start and read by Healthy
+	containerNameMut sync.Mutex
+	containerName    string
 
 	dockerClient *docker.Client
 }
 
-func NewPostgresql(dockerClient *docker.Client, credentials config.PostgreSQLCreds, mainLogger *zap.Logger, stdoutLogger *zap.Logger, stderrLogger *zap.Logger) (Component, error) {
+// postgresqlStopTimeout is the time the postgresql gets to shut down cleanly before docker kills it
+const postgresqlStopTimeout = 30 * time.Second
+
+// NewPostgresql creates the component running the data-node database in the docker container. The container
+// is stopped at the end of the test and removed by the Cleanup before the next one, unless keepDatabase is set.
+// Then the next test starts the same container, so the data-node continues with the database it left.
+func NewPostgresql(
+	dockerClient *docker.Client,
+	credentials config.PostgreSQLCreds,
+	keepDatabase bool,
+	mainLogger *zap.Logger,
+	stdoutLogger *zap.Logger,
+	stderrLogger *zap.Logger,
+) (Component, error) {
 	return &postgresql{
 		mainLogger:   mainLogger,
 		stdoutLogger: stdoutLogger,
 		stderrLogger: stderrLogger,
 		dockerClient: dockerClient,
 		credentials:  credentials,
+		keepDatabase: keepDatabase,
 	}, nil
 }
 
 func (p *postgresql) Name() string {
-	return "postgresql"
+	return ComponentNamePostgresql
 }
 
 // Healthy implements Component.
 func (p *postgresql) Healthy() (bool, error) {
-	if p.containerName == "" {
+	containerName := p.getContainerName()
+	if containerName == "" {
 		return false, fmt.Errorf("the postgresql has not been started")
 	}
 
-	running, err := p.dockerClient.ContainerRunning(context.Background(), p.containerName)
+	running, err := p.dockerClient.ContainerRunning(context.Background(), containerName)
 	if err != nil {
 		return false, fmt.Errorf("failed to check if container is running: %w", err)
 	}
@@ -58,17 +80,21 @@ func (p *postgresql) Start(ctx context.Context) error {
 	container.Environment["POSTGRES_PASSWORD"] = p.credentials.Pass
 	container.Ports[p.credentials.Port] = p.credentials.Port
 
-	err := p.dockerClient.RunContainer(ctx, container)
+	startedAt := time.Now()
+	err := p.startContainer(ctx, container)
+	p.containerNameMut.Lock()
 	p.containerName = container.Name
+	p.containerNameMut.Unlock()
 	if err != nil {
 		return fmt.Errorf("failed to start postgresql component: %w", err)
 	}
 
-	stdout, err := p.dockerClient.Stdout(ctx, p.containerName, true)
+	// Logs of the reused container from the previous test are already written
+	stdout, err := p.dockerClient.Stdout(ctx, container.Name, true, startedAt)
 	if err != nil {
 		return fmt.Errorf("failed to get stdout stream for postgresql: %w", err)
 	}
-	stderr, err := p.dockerClient.Stderr(ctx, p.containerName, true)
+	stderr, err := p.dockerClient.Stderr(ctx, container.Name, true, startedAt)
 	if err != nil {
 		return fmt.Errorf("failed to get stderr stream for postgresql: %w", err)
 	}
@@ -76,16 +102,16 @@ func (p *postgresql) Start(ctx context.Context) error {
 	defer stderr.Close()
 
 	go func(stream io.Reader) {
-		// We do not care for finding panics in psql, so last argument is nil
-		if err := logging.StreamLogs(stream, p.stdoutLogger, nil); err != nil && !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		// We do not care for finding panics in psql, so extra info and tail are nil
+		if err := logging.StreamLogs(stream, p.stdoutLogger, nil, nil); err != nil && !errors.Is(ctx.Err(), context.DeadlineExceeded) {
 			p.mainLogger.Error("failed to stream postgresql stdout", zap.Error(err))
 		}
 	}(stdout)
 
 	go func(stream io.Reader) {
-		// We do not care for finding panics in psql, so last argument is nil
-		if err := logging.StreamLogs(stream, p.stdoutLogger, nil); err != nil && !errors.Is(ctx.Err(), context.DeadlineExceeded) {
-			p.mainLogger.Error("failed to stream postgresql stdout", zap.Error(err))
+		// We do not care for finding panics in psql, so extra info and tail are nil
+		if err := logging.StreamLogs(stream, p.stderrLogger, nil, nil); err != nil && !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			p.mainLogger.Error("failed to stream postgresql stderr", zap.Error(err))
 		}
 	}(stderr)
 
@@ -94,7 +120,40 @@ func (p *postgresql) Start(ctx context.Context) error {
 	return nil
 }
 
-// Stop implements Component.
+// startContainer starts the container left by the previous test when the database is kept, otherwise
+// it creates the new one.
+func (p *postgresql) startContainer(ctx context.Context, container config.ContainerConfig) error {
+	if p.keepDatabase {
+		containerExist, err := p.dockerClient.ContainerExist(ctx, container.Name)
+		if err != nil {
+			return fmt.Errorf("failed to check if docker container exists: %w", err)
+		}
+
+		if containerExist {
+			p.mainLogger.Info("Starting the postgresql container with the database of the previous test")
+			return p.dockerClient.StartExistingContainer(ctx, container.Name)
+		}
+
+		p.mainLogger.Info("There is no postgresql container of the previous test, starting with the empty database")
+	}
+
+	return p.dockerClient.RunContainer(ctx, container)
+}
+
+func (p *postgresql) getContainerName() string {
+	p.containerNameMut.Lock()
+	defer p.containerNameMut.Unlock()
+
+	return p.containerName
+}
+
+// StopTimeout implements GracefulStopper.
+func (p *postgresql) StopTimeout() time.Duration {
+	return postgresqlStopTimeout
+}
+
+// Stop implements Component. The container is only stopped, so the next test can continue with the
+// same database. It is removed by the Cleanup.
 func (p *postgresql) Stop(ctx context.Context) error {
 	containerExist, err := p.dockerClient.ContainerExist(ctx, config.PostgresqlConfig.Name)
 	if err != nil {
@@ -102,20 +161,33 @@ func (p *postgresql) Stop(ctx context.Context) error {
 	}
 
 	if containerExist {
-		err := p.dockerClient.ContainerRemoveForce(ctx, config.PostgresqlConfig.Name)
-		if err != nil {
-			return fmt.Errorf("failed to remove existing container: %w", err)
+		if err := p.dockerClient.ContainerStop(ctx, config.PostgresqlConfig.Name, postgresqlStopTimeout); err != nil {
+			return fmt.Errorf("failed to stop container: %w", err)
 		}
 	}
 
 	return nil
 }
 
-func (p *postgresql) Result() ComponentResults {
-	return ComponentResults{}
-}
+func (p *postgresql) Result(results *Results) {}
 
-// Stop implements Component.
+// Cleanup implements Component. It removes the container with its volumes left by the previous test,
+// unless the database is kept.
 func (p *postgresql) Cleanup(ctx context.Context) error {
-	return p.Stop(ctx)
+	if p.keepDatabase {
+		return nil
+	}
+
+	containerExist, err := p.dockerClient.ContainerExist(ctx, config.PostgresqlConfig.Name)
+	if err != nil {
+		return fmt.Errorf("failed to check if docker container exists: %w", err)
+	}
+
+	if containerExist {
+		if err := p.dockerClient.ContainerRemoveForce(ctx, config.PostgresqlConfig.Name); err != nil {
+			return fmt.Errorf("failed to remove existing container: %w", err)
+		}
+	}
+
+	return nil
 }
@@ -0,0 +1,134 @@
+package components
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// stopProcessGroup sends SIGTERM to the process group started with the Setpgid flag and waits
+// gracePeriod for the process to exit. When the process is still running after that time, or
+// the context is done, the whole group is killed.
+func stopProcessGroup(
+	ctx context.Context,
+	logger *zap.Logger,
+	name string,
+	pid int,
+	exited <-chan struct{},
+	gracePeriod time.Duration,
+) error {
+	// Negative pid means the whole process group
+	pgid := -pid
+	logger.Sugar().Infof("Sending SIGTERM to the %s, waiting %s for it to exit", name, gracePeriod)
+	if err := syscall.Kill(pgid, syscall.SIGTERM); err != nil && !errors.Is(err, syscall.ESRCH) {
+		return fmt.Errorf("failed to send SIGTERM to the %s: %w", name, err)
+	}
+
+	timer := time.NewTimer(gracePeriod)
+	defer timer.Stop()
+
+	select {
+	case <-exited:
+		logger.Sugar().Infof("The %s stopped gracefully", name)
+		return nil
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+
+	logger.Sugar().Infof("The %s did not stop in time, sending SIGKILL", name)
+	if err := syscall.Kill(pgid, syscall.SIGKILL); err != nil && !errors.Is(err, syscall.ESRCH) {
+		return fmt.Errorf("failed to send SIGKILL to the %s: %w", name, err)
+	}
+
+	select {
+	case <-exited:
+	case <-time.After(5 * time.Second):
+		return fmt.Errorf("%s did not exit after SIGKILL", name)
+	}
+
+	return nil
+}
+
+// exitDetails returns the exit code and the name of the signal that terminated the process.
+// The signal is empty when the process exited on its own.
+func exitDetails(state *os.ProcessState) (int, string) {
+	if state == nil {
+		return -1, ""
+	}
+
+	if status, ok := state.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return state.ExitCode(), status.Signal().String()
+	}
+
+	return state.ExitCode(), ""
+}
+
+// oomKillCount returns how many processes the OOM killer killed in the cgroup of this program. Child
+// processes stay in the same cgroup, so the counter includes them. The second value is false when the
+// counter is not available, e.g. the system does not use cgroups.
+func oomKillCount() (uint64, bool) {
+	cgroups, err := os.ReadFile("/proc/self/cgroup")
+	if err != nil {
+		return 0, false
+	}
+
+	for _, line := range strings.Split(strings.TrimSpace(string(cgroups)), "\n") {
+		// Line format is hierarchy-ID:controllers:path
+		parts := strings.SplitN(line, ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+
+		var eventsFile string
+		switch {
+		case parts[0] == "0" && parts[1] == "":
+			// cgroup v2
+			eventsFile = filepath.Join("/sys/fs/cgroup", parts[2], "memory.events")
+		case strings.Contains(","+parts[1]+",", ",memory,"):
+			// cgroup v1
+			eventsFile = filepath.Join("/sys/fs/cgroup/memory", parts[2], "memory.oom_control")
+		default:
+			continue
+		}
+
+		if count, ok := readOOMKillCounter(eventsFile); ok {
+			return count, true
+		}
+	}
+
+	return 0, false
+}
+
+// readOOMKillCounter reads the oom_kill counter from the cgroup memory events file.
+func readOOMKillCounter(eventsFile string) (uint64, bool) {
+	file, err := os.Open(eventsFile)
+	if err != nil {
+		return 0, false
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 || fields[0] != "oom_kill" {
+			continue
+		}
+
+		count, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			return 0, false
+		}
+		return count, true
+	}
+
+	return 0, false
+}
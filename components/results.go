@@ -0,0 +1,307 @@
+package components
+
+import (
+	"time"
+
+	"github.com/vegaprotocol/snapshot-testing/networkutils"
+)
+
+// ResultsSchemaVersion is increased on every incompatible change of the results structure. The JSON
+// Schema of the results is published in the schema directory.
+const ResultsSchemaVersion = 1
+
+// Results of the snapshot-testing run. Every component adds its own section in the Result function.
+// Timestamps are RFC3339, durations are in seconds and optional values are null.
+type Results struct {
+	SchemaVersion int `json:"schema_version"`
+
+	// Overall status of the test, empty when the watchdog did not run
+	Status            HealthyStatus `json:"status,omitempty"`
+	ReasonCode        ReasonCode    `json:"reason_code,omitempty"`
+	Reason            string        `json:"reason"`
+	ShouldSkipFailure bool          `json:"should_skip_failure"`
+	// The test was stopped with SIGINT or SIGTERM before the end
+	Interrupted bool `json:"interrupted"`
+
+	StartedAt       time.Time `json:"started_at"`
+	FinishedAt      time.Time `json:"finished_at"`
+	DurationSeconds float64   `json:"duration_seconds"`
+
+	Config      *RunConfigResults       `json:"config,omitempty"`
+	Setup       *SetupResults           `json:"setup,omitempty"`
+	Snapshots   *SnapshotsResults       `json:"snapshots,omitempty"`
+	Watchdog    *WatchdogResults        `json:"watchdog,omitempty"`
+	Visor       *VisorResults           `json:"visor,omitempty"`
+	Consistency *ConsistencyResults     `json:"consistency,omitempty"`
+	SmokeTests  *SmokeTestsResults      `json:"api_smoke_tests,omitempty"`
+	Exec        map[string]*ExecResults `json:"exec,omitempty"`
+}
+
+func NewResults(startedAt time.Time) *Results {
+	return &Results{
+		SchemaVersion: ResultsSchemaVersion,
+		StartedAt:     startedAt,
+	}
+}
+
+// Finish sets the end time of the test.
+func (r *Results) Finish(finishedAt time.Time) {
+	r.FinishedAt = finishedAt
+	r.DurationSeconds = finishedAt.Sub(r.StartedAt).Seconds()
+}
+
+// SetStatus sets the overall status of the test.
+func (r *Results) SetStatus(status HealthyStatus, reasonCode ReasonCode, reason string) {
+	r.Status = status
+	r.ReasonCode = reasonCode
+	r.Reason = reason
+}
+
+type RunConfigResults struct {
+	Environment string `json:"environment"`
+	// Empty when the config for the environment was used
+	ConfigPath      string   `json:"config_path"`
+	DurationSeconds float64  `json:"duration_seconds"`
+	Components      []string `json:"components"`
+}
+
+type SetupResults struct {
+	ChainID           string `json:"chain_id"`
+	AppVersion        string `json:"app_version"`
+	NetworkHeadHeight uint64 `json:"network_head_height"`
+	// Null when the restart snapshot was not found
+	RestartSnapshotHeight *uint64 `json:"restart_snapshot_height"`
+	// Sum of the steps durations
+	DurationSeconds float64 `json:"duration_seconds"`
+	// Name of the step that took the most time
+	SlowestStep string `json:"slowest_step"`
+	// ID of the trace with the setup steps, empty when tracing is disabled
+	TraceID string             `json:"trace_id"`
+	Steps   []SetupStepResults `json:"steps"`
+}
+
+type SetupStepResults struct {
+	Name            string    `json:"name"`
+	StartedAt       time.Time `json:"started_at"`
+	DurationSeconds float64   `json:"duration_seconds"`
+	Passed          bool      `json:"passed"`
+	Error           string    `json:"error"`
+}
+
+// NewSetupResults converts report of the local node setup into the results section.
+func NewSetupResults(report networkutils.SetupReport) *SetupResults {
+	res := &SetupResults{
+		ChainID:           report.ChainID,
+		AppVersion:        report.AppVersion,
+		NetworkHeadHeight: report.NetworkHeadHeight,
+		TraceID:           report.TraceID,
+		Steps:             []SetupStepResults{},
+	}
+
+	if report.RestartSnapshot != nil {
+		height := report.RestartSnapshot.BlockHeight
+		res.RestartSnapshotHeight = &height
+	}
+
+	slowest := time.Duration(0)
+	for _, step := range report.Steps {
+		res.DurationSeconds += step.Duration.Seconds()
+		if step.Duration > slowest {
+			slowest = step.Duration
+			res.SlowestStep = step.Name
+		}
+
+		errMsg := ""
+		if step.Err != nil {
+			errMsg = step.Err.Error()
+		}
+
+		res.Steps = append(res.Steps, SetupStepResults{
+			Name:            step.Name,
+			StartedAt:       step.StartedAt,
+			DurationSeconds: step.Duration.Seconds(),
+			Passed:          step.Err == nil,
+			Error:           errMsg,
+		})
+	}
+
+	return res
+}
+
+type SnapshotsResults struct {
+	// The first and the latest snapshot available in the local node
+	Min int64 `json:"min"`
+	Max int64 `json:"max"`
+	// Heights of all snapshots available in the local node
+	Heights []int64 `json:"heights"`
+}
+
+// NewSnapshotsResults creates the snapshots section from the sorted snapshot heights.
+func NewSnapshotsResults(heights []int64) *SnapshotsResults {
+	res := &SnapshotsResults{
+		Heights: append([]int64{}, heights...),
+	}
+
+	if len(heights) > 0 {
+		res.Min = heights[0]
+		res.Max = heights[len(heights)-1]
+	}
+
+	return res
+}
+
+type WatchdogResults struct {
+	State      NodeState  `json:"state"`
+	StateSince *time.Time `json:"state_since"`
+
+	StartedAt              *time.Time `json:"started_at"`
+	FirstSeenAt            *time.Time `json:"first_seen_at"`
+	CaughtUpAt             *time.Time `json:"caught_up_at"`
+	CatchUpDurationSeconds *float64   `json:"catch_up_duration_seconds"`
+	LastLagAt              *time.Time `json:"last_lag_at"`
+	LastHealthyAt          *time.Time `json:"last_healthy_at"`
+	LastStallAt            *time.Time `json:"last_stall_at"`
+	LastVegaTimeLagAt      *time.Time `json:"last_vega_time_lag_at"`
+	NetworkHaltedAt        *time.Time `json:"network_halted_at"`
+
+	LastKnownNodeHeight    uint64 `json:"last_known_node_height"`
+	NetworkLastKnownHeight uint64 `json:"network_last_known_height"`
+	LagEpisodes            int    `json:"lag_episodes"`
+
+	CatchUpProgress    CatchUpProgressResults `json:"catch_up_progress"`
+	Metrics            MetricsSummaryResults  `json:"metrics"`
+	PassCriteriaStatus PassCriteriaStatus     `json:"pass_criteria_status"`
+	PassCriteria       []CriterionResults     `json:"pass_criteria"`
+	Events             []EventResults         `json:"events"`
+	EventsDropped      uint64                 `json:"events_dropped"`
+	Config             WatchdogConfigResults  `json:"config"`
+}
+
+type CatchUpProgressResults struct {
+	WindowSeconds            float64 `json:"window_seconds"`
+	ReplayBlocksPerSecond    float64 `json:"replay_blocks_per_second"`
+	MaxReplayBlocksPerSecond float64 `json:"max_replay_blocks_per_second"`
+	NetworkBlocksPerSecond   float64 `json:"network_blocks_per_second"`
+	// False before the first full window
+	Measured bool `json:"measured"`
+	// Null when not measured or when the node does not get closer to the network
+	EstimatedSecondsToCatchUp *float64 `json:"estimated_seconds_to_catch_up"`
+}
+
+type MetricsSummaryResults struct {
+	Probes              uint64  `json:"probes"`
+	MaxCoreLag          uint64  `json:"max_core_lag"`
+	MaxDataNodeLag      uint64  `json:"max_data_node_lag"`
+	MeanBlocksPerSecond float64 `json:"mean_blocks_per_second"`
+	LaggingSeconds      float64 `json:"lagging_seconds"`
+	MetricsFile         string  `json:"metrics_file"`
+}
+
+type CriterionResults struct {
+	Name      string `json:"name"`
+	Threshold string `json:"threshold"`
+	Actual    string `json:"actual"`
+	Passed    bool   `json:"passed"`
+}
+
+type EventResults struct {
+	Time                time.Time `json:"time"`
+	LastTime            time.Time `json:"last_time"`
+	Count               uint64    `json:"count"`
+	Type                EventType `json:"type"`
+	Message             string    `json:"message"`
+	LocalCoreHeight     uint64    `json:"local_core_height"`
+	LocalDataNodeHeight uint64    `json:"local_data_node_height"`
+	NetworkHeight       uint64    `json:"network_height"`
+}
+
+type WatchdogConfigResults struct {
+	LocalRESTURL          string  `json:"local_rest_url"`
+	LocalCoreURL          string  `json:"local_core_url"`
+	IntervalSeconds       float64 `json:"interval_seconds"`
+	CoreLag               uint64  `json:"core_lag"`
+	DataNodeLag           uint64  `json:"data_node_lag"`
+	StallWindowSeconds    float64 `json:"stall_window_seconds"`
+	VegaTimeLagSeconds    float64 `json:"vega_time_lag_seconds"`
+	SpeedWindowSeconds    float64 `json:"speed_window_seconds"`
+	CatchUpTimeoutSeconds float64 `json:"catch_up_timeout_seconds"`
+	EventsLimit           int     `json:"events_limit"`
+
+	// Pass criteria, the max_lag_episodes is null when it is not checked
+	MinHealthyDurationSeconds float64 `json:"min_healthy_duration_seconds"`
+	MaxCatchUpDurationSeconds float64 `json:"max_catch_up_duration_seconds"`
+	MaxLagEpisodes            *int    `json:"max_lag_episodes"`
+	MaxLaggingTimeSeconds     float64 `json:"max_lagging_time_seconds"`
+}
+
+type VisorResults struct {
+	ExitReason VisorExitReason `json:"exit_reason"`
+	// Null when the process did not exit, -1 when it was terminated by a signal
+	ExitCode   *int       `json:"exit_code"`
+	ExitSignal string     `json:"exit_signal"`
+	ExitedAt   *time.Time `json:"exited_at"`
+
+	StderrTail    string `json:"stderr_tail"`
+	ExtraLogLines string `json:"extra_log_lines"`
+}
+
+type ExecResults struct {
+	// Null when the process did not exit, -1 when it was terminated by a signal
+	ExitCode   *int       `json:"exit_code"`
+	ExitSignal string     `json:"exit_signal"`
+	ExitedAt   *time.Time `json:"exited_at"`
+}
+
+type ConsistencyResults struct {
+	Status            ConsistencyStatus `json:"status"`
+	Checks            uint64            `json:"checks"`
+	LastMatchedHeight uint64            `json:"last_matched_height"`
+	// Null when the local state did not diverge
+	FirstDivergentHeight *uint64                      `json:"first_divergent_height"`
+	Mismatches           []ConsistencyMismatchResults `json:"mismatches"`
+	Config               ConsistencyConfigResults     `json:"config"`
+}
+
+type ConsistencyMismatchResults struct {
+	Time        time.Time    `json:"time"`
+	Kind        MismatchKind `json:"kind"`
+	Height      uint64       `json:"height"`
+	Remote      string       `json:"remote"`
+	LocalValue  string       `json:"local_value"`
+	RemoteValue string       `json:"remote_value"`
+}
+
+type ConsistencyConfigResults struct {
+	LocalRPCURL       string   `json:"local_rpc_url"`
+	LocalRESTURL      string   `json:"local_rest_url"`
+	IntervalSeconds   float64  `json:"interval_seconds"`
+	Peers             int      `json:"peers"`
+	DataNodeResources []string `json:"data_node_resources"`
+}
+
+type SmokeTestsResults struct {
+	Status     SmokeTestsStatus        `json:"status"`
+	ExecutedAt *time.Time              `json:"executed_at"`
+	Remote     string                  `json:"remote"`
+	Queries    []SmokeTestQueryResults `json:"queries"`
+}
+
+type SmokeTestQueryResults struct {
+	Name             string `json:"name"`
+	Path             string `json:"path"`
+	Passed           bool   `json:"passed"`
+	LocalStatusCode  int    `json:"local_status_code"`
+	RemoteStatusCode int    `json:"remote_status_code"`
+	// Values of the fields that differ on the local and the remote node
+	MismatchedFields map[string]string `json:"mismatched_fields"`
+	Error            string            `json:"error"`
+}
+
+// optionalTime returns nil for the zero time, so it is reported as null.
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+
+	return &t
+}
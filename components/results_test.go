@@ -0,0 +1,71 @@
+package components
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+)
+
+// schemaConfigProperties returns the properties of the config field of the given definition in the results schema.
+func schemaConfigProperties(t *testing.T, definition string) map[string]any {
+	t.Helper()
+
+	content, err := os.ReadFile("../schema/results.schema.json")
+	if err != nil {
+		t.Fatalf("failed to read results schema: %v", err)
+	}
+
+	var schema struct {
+		Defs map[string]struct {
+			Properties map[string]struct {
+				Properties map[string]any `json:"properties"`
+			} `json:"properties"`
+		} `json:"$defs"`
+	}
+	if err := json.Unmarshal(content, &schema); err != nil {
+		t.Fatalf("failed to unmarshal results schema: %v", err)
+	}
+
+	properties := schema.Defs[definition].Properties["config"].Properties
+	if len(properties) == 0 {
+		t.Fatalf("schema does not define properties of the %s.config", definition)
+	}
+
+	return properties
+}
+
+func TestResultsConfigMatchesSchema(t *testing.T) {
+	testCases := []struct {
+		definition string
+		config     any
+	}{
+		{definition: "watchdog", config: WatchdogConfigResults{}},
+		{definition: "consistency", config: ConsistencyConfigResults{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.definition, func(t *testing.T) {
+			content, err := json.Marshal(tc.config)
+			if err != nil {
+				t.Fatalf("failed to marshal config: %v", err)
+			}
+
+			var fields map[string]any
+			if err := json.Unmarshal(content, &fields); err != nil {
+				t.Fatalf("failed to unmarshal config: %v", err)
+			}
+
+			properties := schemaConfigProperties(t, tc.definition)
+			for field := range fields {
+				if _, ok := properties[field]; !ok {
+					t.Errorf("the %s field is missing in the %s.config schema", field, tc.definition)
+				}
+			}
+			for property := range properties {
+				if _, ok := fields[property]; !ok {
+					t.Errorf("the %s.config schema defines unknown %s field", tc.definition, property)
+				}
+			}
+		})
+	}
+}
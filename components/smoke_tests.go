@@ -0,0 +1,256 @@
+package components
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/vegaprotocol/snapshot-testing/config"
+	"github.com/vegaprotocol/snapshot-testing/networkutils"
+	"go.uber.org/zap"
+)
+
+type SmokeTestsStatus string
+
+const (
+	SmokeTestsNotRun SmokeTestsStatus = "NOT_RUN"
+	SmokeTestsPassed SmokeTestsStatus = "PASSED"
+	SmokeTestsFailed SmokeTestsStatus = "FAILED"
+)
+
+type smokeTestResult struct {
+	name             string
+	path             string
+	localStatusCode  int
+	remoteStatusCode int
+	// Fields with different values on the local and the remote node
+	mismatchedFields map[string]string
+	err              error
+}
+
+func (str smokeTestResult) passed() bool {
+	return str.err == nil && str.localStatusCode == str.remoteStatusCode && len(str.mismatchedFields) == 0
+}
+
+func (str smokeTestResult) toResults() SmokeTestQueryResults {
+	errMsg := ""
+	if str.err != nil {
+		errMsg = str.err.Error()
+	}
+
+	return SmokeTestQueryResults{
+		Name:             str.name,
+		Path:             str.path,
+		Passed:           str.passed(),
+		LocalStatusCode:  str.localStatusCode,
+		RemoteStatusCode: str.remoteStatusCode,
+		MismatchedFields: str.mismatchedFields,
+		Error:            errMsg,
+	}
+}
+
+type smokeTests struct {
+	logger        *zap.Logger
+	conf          config.SmokeTests
+	dataNodesREST []string
+
+	// mut protects all of the fields below
+	mut       sync.Mutex
+	stop      context.CancelFunc
+	executed  time.Time
+	remoteURL string
+	results   []smokeTestResult
+}
+
+func NewSmokeTests(dataNodesREST []string, conf config.SmokeTests, mainLogger *zap.Logger) (Component, error) {
+	if len(dataNodesREST) < 1 {
+		return nil, fmt.Errorf("at least one rest endpoint is required")
+	}
+
+	return &smokeTests{
+		logger:        mainLogger,
+		conf:          conf.Merge(config.DefaultSmokeTests),
+		dataNodesREST: dataNodesREST,
+	}, nil
+}
+
+func (st *smokeTests) Name() string {
+	return ComponentNameSmokeTests
+}
+
+// Start waits until the local node caught the network up, then runs all of the queries once.
+func (st *smokeTests) Start(ctx context.Context) error {
+	restClient := networkutils.DefaultRESTClient()
+
+	testsCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	st.mut.Lock()
+	st.stop = cancel
+	st.mut.Unlock()
+
+	ticker := time.NewTicker(st.conf.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-testsCtx.Done():
+			return nil
+		}
+
+		remoteURL, caughtUp := st.caughtUp(testsCtx, restClient)
+		if !caughtUp {
+			continue
+		}
+
+		st.logger.Sugar().Infof("Local node caught the network up, running data-node API smoke tests against %s", remoteURL)
+		results := []smokeTestResult{}
+		for _, query := range st.conf.Queries {
+			result := st.runQuery(testsCtx, restClient, remoteURL, query)
+			if result.passed() {
+				st.logger.Sugar().Infof("Smoke test %s passed", query.Name)
+			} else {
+				st.logger.Sugar().Errorf("Smoke test %s failed: %+v", query.Name, result.toResults())
+			}
+			results = append(results, result)
+		}
+
+		st.mut.Lock()
+		st.executed = time.Now()
+		st.remoteURL = remoteURL
+		st.results = results
+		st.mut.Unlock()
+
+		return nil
+	}
+}
+
+// caughtUp returns the remote data-node, healthy within the max lag and not behind the local data-node,
+// when the local node caught the network up.
+func (st *smokeTests) caughtUp(ctx context.Context, httpClient *http.Client) (string, bool) {
+	localStatistics, err := networkutils.GetStatistics(ctx, httpClient, st.conf.LocalRESTURL)
+	if err != nil {
+		return "", false
+	}
+
+	if localStatistics.BlockHeight > localStatistics.DataNodeHeight &&
+		localStatistics.BlockHeight-localStatistics.DataNodeHeight > st.conf.MaxLag {
+		return "", false
+	}
+
+	for _, restURL := range st.dataNodesREST {
+		remoteStatistics, err := networkutils.GetStatistics(ctx, httpClient, restURL)
+		if err != nil {
+			continue
+		}
+
+		// Remote data-node behind the local one does not know all of the data the local node returns
+		if remoteStatistics.DataNodeHeight < localStatistics.DataNodeHeight {
+			st.logger.Sugar().Debugf("The %s data-node cannot be used for smoke tests: it is behind the local data-node", restURL)
+			continue
+		}
+		if err := networkutils.CheckStatisticsHeights(remoteStatistics, localStatistics.BlockHeight, st.conf.MaxLag, st.conf.MaxLag); err != nil {
+			st.logger.Sugar().Debugf("The %s data-node cannot be used for smoke tests: %s", restURL, err.Error())
+			continue
+		}
+
+		if remoteStatistics.BlockHeight > localStatistics.BlockHeight &&
+			remoteStatistics.BlockHeight-localStatistics.BlockHeight > st.conf.MaxLag {
+			// The local node is behind this remote node, so it is behind the network
+			return "", false
+		}
+
+		return restURL, true
+	}
+
+	return "", false
+}
+
+func (st *smokeTests) runQuery(ctx context.Context, httpClient *http.Client, remoteURL string, query config.SmokeTestQuery) smokeTestResult {
+	result := smokeTestResult{
+		name:             query.Name,
+		path:             query.Path,
+		mismatchedFields: map[string]string{},
+	}
+
+	localStatusCode, localBody, err := networkutils.GetRESTResource(ctx, httpClient, st.conf.LocalRESTURL, query.Path)
+	result.localStatusCode = localStatusCode
+	if err != nil {
+		result.err = fmt.Errorf("failed to query local node: %w", err)
+		return result
+	}
+
+	remoteStatusCode, remoteBody, err := networkutils.GetRESTResource(ctx, httpClient, remoteURL, query.Path)
+	result.remoteStatusCode = remoteStatusCode
+	if err != nil {
+		result.err = fmt.Errorf("failed to query remote node: %w", err)
+		return result
+	}
+
+	for _, field := range query.Fields {
+		localValue, localErr := networkutils.GetJSONField(localBody, field)
+		remoteValue, remoteErr := networkutils.GetJSONField(remoteBody, field)
+
+		switch {
+		case localErr != nil && remoteErr != nil:
+			// Field missing on both nodes is not a difference
+		case localErr != nil:
+			result.mismatchedFields[field] = fmt.Sprintf("local: %s, remote: %v", localErr.Error(), remoteValue)
+		case remoteErr != nil:
+			result.mismatchedFields[field] = fmt.Sprintf("local: %v, remote: %s", localValue, remoteErr.Error())
+		case !reflect.DeepEqual(localValue, remoteValue):
+			result.mismatchedFields[field] = fmt.Sprintf("local: %v, remote: %v", localValue, remoteValue)
+		}
+	}
+
+	return result
+}
+
+func (st *smokeTests) Stop(ctx context.Context) error {
+	st.mut.Lock()
+	stop := st.stop
+	st.mut.Unlock()
+
+	if stop != nil {
+		stop()
+	}
+
+	return nil
+}
+
+// Healthy implements Component. Failed queries are reported in the results only.
+func (st *smokeTests) Healthy() (bool, error) {
+	return true, nil
+}
+
+func (st *smokeTests) Cleanup(ctx context.Context) error {
+	return nil
+}
+
+func (st *smokeTests) Result(results *Results) {
+	st.mut.Lock()
+	defer st.mut.Unlock()
+
+	status := SmokeTestsNotRun
+	if len(st.results) > 0 {
+		status = SmokeTestsPassed
+	}
+
+	queries := []SmokeTestQueryResults{}
+	for _, result := range st.results {
+		if !result.passed() {
+			status = SmokeTestsFailed
+		}
+		queries = append(queries, result.toResults())
+	}
+
+	results.SmokeTests = &SmokeTestsResults{
+		Status:     status,
+		ExecutedAt: optionalTime(st.executed),
+		Remote:     st.remoteURL,
+		Queries:    queries,
+	}
+}
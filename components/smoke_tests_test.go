@@ -0,0 +1,108 @@
+package components
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/vegaprotocol/snapshot-testing/config"
+	"github.com/vegaprotocol/snapshot-testing/networkutils"
+)
+
+// newFakeStatisticsServer serves the statistics of the node with the given core and data-node heights.
+func newFakeStatisticsServer(t *testing.T, coreHeight, dataNodeHeight uint64) string {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/statistics" {
+			http.NotFound(w, r)
+			return
+		}
+
+		now := time.Now().UTC().Format(time.RFC3339Nano)
+		w.Header().Set("x-block-height", fmt.Sprint(dataNodeHeight))
+		fmt.Fprintf(w, `{"statistics": {"blockHeight": "%d", "currentTime": "%s", "vegaTime": "%s"}}`, coreHeight, now, now)
+	}))
+	t.Cleanup(server.Close)
+
+	return server.URL
+}
+
+func TestSmokeTestsCaughtUp(t *testing.T) {
+	type heights struct {
+		core     uint64
+		dataNode uint64
+	}
+
+	testCases := []struct {
+		name              string
+		local             heights
+		remotes           []heights
+		expectedCaughtUp  bool
+		expectedRemoteIdx int
+	}{
+		{
+			name:             "remote at the local height",
+			local:            heights{core: 1000, dataNode: 1000},
+			remotes:          []heights{{core: 1000, dataNode: 1000}},
+			expectedCaughtUp: true,
+		},
+		{
+			name:    "local data-node lags behind the local core",
+			local:   heights{core: 1000, dataNode: 800},
+			remotes: []heights{{core: 1000, dataNode: 1000}},
+		},
+		{
+			name:    "local node behind the remote",
+			local:   heights{core: 1000, dataNode: 1000},
+			remotes: []heights{{core: 1200, dataNode: 1200}},
+		},
+		{
+			name:    "remote behind the local node",
+			local:   heights{core: 1000, dataNode: 1000},
+			remotes: []heights{{core: 990, dataNode: 990}},
+		},
+		{
+			name:              "remote with lagging data-node is skipped",
+			local:             heights{core: 1000, dataNode: 1000},
+			remotes:           []heights{{core: 1050, dataNode: 1000}, {core: 1005, dataNode: 1005}},
+			expectedCaughtUp:  true,
+			expectedRemoteIdx: 1,
+		},
+		{
+			name:              "remote behind the local node is skipped",
+			local:             heights{core: 1000, dataNode: 1000},
+			remotes:           []heights{{core: 990, dataNode: 990}, {core: 1010, dataNode: 1010}},
+			expectedCaughtUp:  true,
+			expectedRemoteIdx: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			remotes := []string{}
+			for _, remote := range tc.remotes {
+				remotes = append(remotes, newFakeStatisticsServer(t, remote.core, remote.dataNode))
+			}
+
+			component, err := NewSmokeTests(remotes, config.SmokeTests{
+				LocalRESTURL: newFakeStatisticsServer(t, tc.local.core, tc.local.dataNode),
+				MaxLag:       20,
+			}, zap.NewNop())
+			if err != nil {
+				t.Fatalf("failed to create smoke tests: %v", err)
+			}
+
+			remoteURL, caughtUp := component.(*smokeTests).caughtUp(context.Background(), networkutils.DefaultRESTClient())
+			if caughtUp != tc.expectedCaughtUp {
+				t.Fatalf("expected caught up %t, got %t", tc.expectedCaughtUp, caughtUp)
+			}
+			if caughtUp && remoteURL != remotes[tc.expectedRemoteIdx] {
+				t.Errorf("expected the %s remote, got %s", remotes[tc.expectedRemoteIdx], remoteURL)
+			}
+		})
+	}
+}
@@ -2,71 +2,203 @@ package components
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"io"
 	"net"
 	"os/exec"
+	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/vegaprotocol/snapshot-testing/logging"
 	"go.uber.org/zap"
 )
 
-type visor struct {
+// VisorStderrTailLines is the number of the last vegavisor stderr lines reported in the results
+const VisorStderrTailLines = 50
+
+type VisorExitReason string
+
+const (
+	VisorNotExited            VisorExitReason = "NOT_EXITED"
+	VisorExitClean            VisorExitReason = "CLEAN"
+	VisorExitStoppedByTest    VisorExitReason = "STOPPED_BY_TEST"
+	VisorExitPanic            VisorExitReason = "PANIC"
+	VisorExitConsensusFailure VisorExitReason = "CONSENSUS_FAILURE"
+	VisorExitOOMKilled        VisorExitReason = "OOM_KILLED"
+	// VisorExitKilled means the process got SIGKILL from someone else than the test, e.g. the operator,
+	// the CI runner or the OOM killer when We could not confirm it in the cgroup memory events
+	VisorExitKilled VisorExitReason = "KILLED"
+	VisorExitError  VisorExitReason = "ERROR"
+)
+
+// EarlyTermination tells if the vegavisor died on its own before the test finished
+func (r VisorExitReason) EarlyTermination() bool {
+	return r != VisorNotExited && r != VisorExitStoppedByTest
+}
+
+// visorState describes the vegavisor process. It is written by the Start and Stop functions and
+// read by the controller, so it is always accessed through the visor.mut lock.
+type visorState struct {
 	started  bool
 	finished bool
+	stopping bool
 
+	exitCode   int
+	signal     string
+	exitTime   time.Time
+	exitReason VisorExitReason
+}
+
+type visor struct {
 	mainLogger   *zap.Logger
 	stdoutLogger *zap.Logger
 	stderrLogger *zap.Logger
-	commandStop  context.CancelFunc
 
-	extraLogs logging.ExtraInfo
+	extraLogs  logging.ExtraInfo
+	stderrTail *logging.LogTail
 
 	vegavisorBinary string
 	vegavisorHome   string
+
+	// stopTimeout is the time We give the vegavisor to exit after SIGTERM before
+	// the whole process group is killed
+	stopTimeout time.Duration
+
+	// done is closed when the vegavisor process exits
+	done chan struct{}
+
+	mut       sync.Mutex
+	cmd       *exec.Cmd
+	state     visorState
+	listeners []VisorListener
 }
 
 func NewVisor(
 	vegavisorBinary string,
 	vegavisorHome string,
+	stopTimeout time.Duration,
 	mainLogger *zap.Logger,
 	stdoutLogger *zap.Logger,
 	stderrLogger *zap.Logger,
 ) (Component, error) {
 	return &visor{
 		mainLogger:   mainLogger,
-		stdoutLogger: stderrLogger,
+		stdoutLogger: stdoutLogger,
 		stderrLogger: stderrLogger,
 
 		vegavisorBinary: vegavisorBinary,
 		vegavisorHome:   vegavisorHome,
+		stopTimeout:     stopTimeout,
 		extraLogs:       logging.NewExtraInfo(),
+		stderrTail:      logging.NewLogTail(VisorStderrTailLines),
+		done:            make(chan struct{}),
+		state: visorState{
+			exitReason: VisorNotExited,
+		},
 	}, nil
 }
 
 func (v *visor) Name() string {
-	return "vegavisor"
+	return ComponentNameVisor
 }
 
-const KeyVisorExtraLogLines = "visor-extra-log-lines"
+func (v *visor) snapshot() visorState {
+	v.mut.Lock()
+	defer v.mut.Unlock()
+
+	return v.state
+}
 
-func (v *visor) Result() ComponentResults {
-	return ComponentResults{
-		KeyVisorExtraLogLines: v.extraLogs.String(512),
+func (v *visor) Result(results *Results) {
+	state := v.snapshot()
+
+	res := &VisorResults{
+		ExitReason:    state.exitReason,
+		StderrTail:    v.stderrTail.String(),
+		ExtraLogLines: v.extraLogs.String(512),
+	}
+
+	if state.finished {
+		exitCode := state.exitCode
+		res.ExitCode = &exitCode
+		res.ExitSignal = state.signal
+		res.ExitedAt = optionalTime(state.exitTime)
 	}
+
+	results.Visor = res
+}
+
+// classifyExit tells why the vegavisor finished based on how the process exited and what it logged
+// just before. The oomKilled tells if the OOM killer killed a process in our cgroup while the vegavisor was running.
+func classifyExit(stoppedByTest bool, oomKilled bool, exitCode int, signal string, logLines []string) VisorExitReason {
+	if stoppedByTest {
+		return VisorExitStoppedByTest
+	}
+
+	for _, line := range logLines {
+		line = strings.ToLower(line)
+		if strings.Contains(line, "consensus failure") ||
+			strings.Contains(line, "wrong block.header.apphash") ||
+			strings.Contains(line, "wrong block.header.lastresultshash") {
+			return VisorExitConsensusFailure
+		}
+	}
+
+	for _, line := range logLines {
+		line = strings.ToLower(line)
+		if strings.Contains(line, "panic") || strings.Contains(line, "invalid memory") {
+			return VisorExitPanic
+		}
+	}
+
+	if oomKilled {
+		return VisorExitOOMKilled
+	}
+	for _, line := range logLines {
+		if strings.Contains(strings.ToLower(line), "out of memory") {
+			return VisorExitOOMKilled
+		}
+	}
+
+	// SIGKILL can come from the operator, the CI runner or the OOM killer We could not confirm
+	if signal == syscall.SIGKILL.String() {
+		return VisorExitKilled
+	}
+	for _, line := range logLines {
+		if strings.Contains(strings.ToLower(line), "signal: killed") {
+			return VisorExitKilled
+		}
+	}
+
+	if exitCode == 0 {
+		return VisorExitClean
+	}
+
+	return VisorExitError
+}
+
+// StopTimeout implements GracefulStopper.
+func (v *visor) StopTimeout() time.Duration {
+	return v.stopTimeout
 }
 
 // Healthy implements Component.
 func (v *visor) Healthy() (bool, error) {
+	state := v.snapshot()
+
 	// Still not started
-	if !v.started {
+	if !state.started {
 		return true, nil
 	}
 
 	// Program should not finish early
-	return !v.finished, nil
+	if state.finished {
+		return false, fmt.Errorf("vegavisor exited(%s) with code %d at %s", state.exitReason, state.exitCode, state.exitTime.String())
+	}
+
+	return true, nil
 }
 
 func (v *visor) waitForPostgreSQL(ctx context.Context) error {
@@ -98,18 +230,27 @@ func (v *visor) waitForPostgreSQL(ctx context.Context) error {
 
 // Start implements Component.
 func (v *visor) Start(ctx context.Context) error {
-	commandContext, cancel := context.WithCancel(ctx)
-	defer cancel()
-
-	postgreSQLWaitContext, psqlWaitCancel := context.WithTimeout(commandContext, 120*time.Second)
+	postgreSQLWaitContext, psqlWaitCancel := context.WithTimeout(ctx, 120*time.Second)
 	defer psqlWaitCancel()
 	if err := v.waitForPostgreSQL(postgreSQLWaitContext); err != nil {
 		return fmt.Errorf("postgreSQL did not start in 60 seconds: %w", err)
 	}
 
-	v.commandStop = cancel
+	select {
+	case <-time.After(30 * time.Second):
+	case <-ctx.Done():
+		// Test finished before We had a chance to start the node
+		return nil
+	}
+
+	return v.run(exec.Command(v.vegavisorBinary, []string{"run", "--home", v.vegavisorHome}...))
+}
 
-	cmd := exec.CommandContext(commandContext, v.vegavisorBinary, []string{"run", "--home", v.vegavisorHome}...)
+// run starts the vegavisor command and waits until it exits.
+func (v *visor) run(cmd *exec.Cmd) error {
+	// The vegavisor is not bound to the context. It spawns vega and data-node as child processes, so
+	// We run it in a separated process group and Stop sends signals to the whole group.
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
 
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
@@ -120,46 +261,126 @@ func (v *visor) Start(ctx context.Context) error {
 	if err != nil {
 		return fmt.Errorf("failed to get stderr pipe: %w", err)
 	}
-	go func(cmd *exec.Cmd) {
-		time.Sleep(30 * time.Second)
-		v.started = true
-		// We do not care about errors if test has finished(parent context expired)
-		if err := cmd.Run(); err != nil && !errors.Is(ctx.Err(), context.DeadlineExceeded) {
-			v.mainLogger.Error("failed to start vegavisor", zap.Error(err))
-		}
-		v.finished = true
-	}(cmd)
 
-	defer stdout.Close()
-	defer stderr.Close()
+	// The OOM killer is confirmed when the oom_kill counter of our cgroup increased while the vegavisor was running
+	oomKillsBefore, oomKillsKnown := oomKillCount()
 
+	v.mut.Lock()
+	if v.state.stopping {
+		v.mut.Unlock()
+		return nil
+	}
+	if err := cmd.Start(); err != nil {
+		v.mut.Unlock()
+		return fmt.Errorf("failed to start vegavisor: %w", err)
+	}
+	v.cmd = cmd
+	v.state.started = true
+	listeners := v.listeners
+	v.mut.Unlock()
+
+	for _, listener := range listeners {
+		listener.VisorStarted()
+	}
+
+	streamsWg := sync.WaitGroup{}
+	streamsWg.Add(2)
 	go func(stream io.Reader) {
-		if err := logging.StreamLogs(stream, v.stdoutLogger, &v.extraLogs); err != nil && !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		defer streamsWg.Done()
+		if err := logging.StreamLogs(stream, v.stdoutLogger, &v.extraLogs, nil); err != nil && !v.isStopping() {
 			v.mainLogger.Error("failed to stream visor stdout", zap.Error(err))
 		}
 	}(stdout)
 
 	go func(stream io.Reader) {
-		if err := logging.StreamLogs(stream, v.stdoutLogger, &v.extraLogs); err != nil && !errors.Is(ctx.Err(), context.DeadlineExceeded) {
-			v.mainLogger.Error("failed to stream visor stdout", zap.Error(err))
+		defer streamsWg.Done()
+		if err := logging.StreamLogs(stream, v.stderrLogger, &v.extraLogs, v.stderrTail); err != nil && !v.isStopping() {
+			v.mainLogger.Error("failed to stream visor stderr", zap.Error(err))
 		}
 	}(stderr)
 
-	<-commandContext.Done()
+	// Pipes are closed by Wait, so all the logs must be read before
+	streamsWg.Wait()
+	err = cmd.Wait()
+	oomKillsAfter, _ := oomKillCount()
+
+	v.mut.Lock()
+	v.state.finished = true
+	v.state.exitTime = time.Now()
+	v.state.exitCode, v.state.signal = exitDetails(cmd.ProcessState)
+	v.state.exitReason = classifyExit(
+		v.state.stopping,
+		oomKillsKnown && oomKillsAfter > oomKillsBefore,
+		v.state.exitCode,
+		v.state.signal,
+		append(v.extraLogs.Lines(), v.stderrTail.Lines()...),
+	)
+	state := v.state
+	v.mut.Unlock()
+	close(v.done)
+
+	for _, listener := range listeners {
+		listener.VisorExited(state.exitReason)
+	}
+
+	// We do not care about errors if We stopped the vegavisor
+	if !state.stopping {
+		v.mainLogger.Error(
+			fmt.Sprintf("vegavisor finished before the end of the test: %s", state.exitReason),
+			zap.Int("exit-code", state.exitCode),
+			zap.Error(err),
+		)
+	}
 
 	return nil
 }
 
-// Stop implements Component.
+// AddListener registers the listener notified when the vegavisor process starts and exits.
+func (v *visor) AddListener(listener VisorListener) {
+	v.mut.Lock()
+	defer v.mut.Unlock()
+
+	v.listeners = append(v.listeners, listener)
+}
+
+// connectVisorListeners registers all of the VisorListener components in the vegavisor component.
+func connectVisorListeners(components []Component) {
+	for _, component := range components {
+		visorComponent, ok := component.(*visor)
+		if !ok {
+			continue
+		}
+
+		for _, listenerComponent := range components {
+			if listener, ok := listenerComponent.(VisorListener); ok {
+				visorComponent.AddListener(listener)
+			}
+		}
+	}
+}
+
+func (v *visor) isStopping() bool {
+	return v.snapshot().stopping
+}
+
+// Stop implements Component. It sends SIGTERM to the vegavisor process group and waits
+// for the stopTimeout to let vega exit cleanly, then it kills the whole group.
 func (v *visor) Stop(ctx context.Context) error {
-	if v.started {
-		v.commandStop()
+	v.mut.Lock()
+	v.state.stopping = true
+	cmd := v.cmd
+	finished := v.state.finished
+	v.mut.Unlock()
+
+	if cmd == nil || finished {
+		return nil
 	}
 
-	return nil
+	return stopProcessGroup(ctx, v.mainLogger, "vegavisor", cmd.Process.Pid, v.done, v.stopTimeout)
 }
 
-// Stop implements Component.
+// Cleanup implements Component. The vegavisor process is owned by the Start function, so there is
+// nothing left to clean up before the test.
 func (v *visor) Cleanup(ctx context.Context) error {
-	return v.Stop(ctx)
+	return nil
 }
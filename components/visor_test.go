@@ -0,0 +1,157 @@
+package components
+
+import (
+	"context"
+	"os/exec"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// visorCalls records the vegavisor lifecycle seen by the listener.
+type visorCalls struct {
+	mut     sync.Mutex
+	started int
+	exits   []VisorExitReason
+}
+
+func (vc *visorCalls) VisorStarted() {
+	vc.mut.Lock()
+	defer vc.mut.Unlock()
+
+	vc.started++
+}
+
+func (vc *visorCalls) VisorExited(reason VisorExitReason) {
+	vc.mut.Lock()
+	defer vc.mut.Unlock()
+
+	vc.exits = append(vc.exits, reason)
+}
+
+func newTestVisor(t *testing.T) *visor {
+	component, err := NewVisor("", "", 100*time.Millisecond, zap.NewNop(), zap.NewNop(), zap.NewNop())
+	if err != nil {
+		t.Fatalf("failed to create visor: %v", err)
+	}
+
+	return component.(*visor)
+}
+
+// readVisorConcurrently reads the vegavisor state the same way the controller does, until the visor exits.
+func readVisorConcurrently(v *visor) *sync.WaitGroup {
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			_, _ = v.Healthy()
+			v.Result(NewResults(time.Now()))
+
+			select {
+			case <-v.done:
+				return
+			default:
+			}
+		}
+	}()
+
+	return wg
+}
+
+func TestVisorReportsEarlyExit(t *testing.T) {
+	v := newTestVisor(t)
+	listener := &visorCalls{}
+	v.AddListener(listener)
+
+	readers := readVisorConcurrently(v)
+	err := v.run(exec.Command("sh", "-c", "echo starting; sleep 0.1; echo 'panic: runtime error' >&2; exit 2"))
+	readers.Wait()
+
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	results := NewResults(time.Now())
+	v.Result(results)
+	if results.Visor.ExitReason != VisorExitPanic {
+		t.Errorf("expected exit reason %s, got %s", VisorExitPanic, results.Visor.ExitReason)
+	}
+	if results.Visor.ExitCode == nil || *results.Visor.ExitCode != 2 {
+		t.Errorf("expected exit code 2, got %v", results.Visor.ExitCode)
+	}
+	if healthy, _ := v.Healthy(); healthy {
+		t.Error("visor that exited early must be unhealthy")
+	}
+
+	listener.mut.Lock()
+	defer listener.mut.Unlock()
+	if listener.started != 1 || !slices.Equal(listener.exits, []VisorExitReason{VisorExitPanic}) {
+		t.Errorf("expected one start and the %s exit, got %d starts and %v exits", VisorExitPanic, listener.started, listener.exits)
+	}
+}
+
+func TestVisorStoppedByTest(t *testing.T) {
+	v := newTestVisor(t)
+
+	readers := readVisorConcurrently(v)
+	finished := make(chan error)
+	go func() {
+		finished <- v.run(exec.Command("sleep", "10"))
+	}()
+
+	for !v.snapshot().started {
+		time.Sleep(time.Millisecond)
+	}
+	if err := v.Stop(context.Background()); err != nil {
+		t.Fatalf("failed to stop visor: %v", err)
+	}
+
+	select {
+	case err := <-finished:
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("visor did not exit after stop")
+	}
+	readers.Wait()
+
+	if state := v.snapshot(); state.exitReason != VisorExitStoppedByTest {
+		t.Errorf("expected exit reason %s, got %s", VisorExitStoppedByTest, state.exitReason)
+	}
+}
+
+func TestClassifyExit(t *testing.T) {
+	testCases := []struct {
+		name          string
+		stoppedByTest bool
+		oomKilled     bool
+		exitCode      int
+		signal        string
+		logLines      []string
+		expected      VisorExitReason
+	}{
+		{name: "stopped by test", stoppedByTest: true, exitCode: -1, signal: "killed", expected: VisorExitStoppedByTest},
+		{name: "clean exit", expected: VisorExitClean},
+		{name: "error", exitCode: 1, expected: VisorExitError},
+		{name: "panic", exitCode: 2, logLines: []string{"panic: runtime error: invalid memory address"}, expected: VisorExitPanic},
+		{name: "consensus failure", exitCode: 1, logLines: []string{"CONSENSUS FAILURE!!!"}, expected: VisorExitConsensusFailure},
+		{name: "confirmed oom kill", exitCode: -1, signal: "killed", oomKilled: true, expected: VisorExitOOMKilled},
+		{name: "out of memory logged", exitCode: 2, logLines: []string{"fatal error: runtime: out of memory"}, expected: VisorExitOOMKilled},
+		{name: "killed by someone else", exitCode: -1, signal: "killed", expected: VisorExitKilled},
+		{name: "child killed", exitCode: 1, logLines: []string{"vega process exited: signal: killed"}, expected: VisorExitKilled},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			reason := classifyExit(tc.stoppedByTest, tc.oomKilled, tc.exitCode, tc.signal, tc.logLines)
+			if reason != tc.expected {
+				t.Errorf("expected %s, got %s", tc.expected, reason)
+			}
+		})
+	}
+}
@@ -3,8 +3,12 @@ package components
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"os"
+	"sync"
 	"time"
 
+	"github.com/vegaprotocol/snapshot-testing/config"
 	"github.com/vegaprotocol/snapshot-testing/networkutils"
 	"go.uber.org/zap"
 )
@@ -15,155 +19,205 @@ const (
 	Healthy      HealthyStatus = "HEALTHY"
 	MaybeHealthy HealthyStatus = "MAYBE"
 	Unhealthy    HealthyStatus = "UNHEALTHY"
+	// NetworkHalted means the remote network stopped producing blocks, so the local node could not be tested
+	NetworkHalted HealthyStatus = "NETWORK_HALTED"
+	// StateDiverged means the local node state differs from the rest of the network
+	StateDiverged HealthyStatus = "STATE_DIVERGED"
 )
 
-type event struct {
-	time  time.Time
-	event string
-}
-
 type localNodeStatus struct {
 	started                time.Time // We started the watchdog thread
 	firstSeen              time.Time // We got first response from the /statistics for local node
 	catchUp                time.Time // Node catch rest of the network up
-	blockProductionStopped time.Time // Node did not produce blocks
+	blockProductionStopped time.Time // Node did not produce blocks for the stall window
+	vegaTimeLagging        time.Time // Node vega time was too far behind the network
 
-	lastHeight uint64
+	lastHeight         uint64
+	lastHeightIncrease time.Time     // When the lastHeight increased for the last time
+	stalledFor         time.Duration // How long the height did not increase when the node was stalled last time
+	vegaTimeLag        time.Duration // The last vega time lag above the threshold
 
 	lagging time.Time // When node started lagging
 	healthy time.Time // Last healthy event
 
-	events []event
+	networkHeight         uint64    // The highest block reported by the network
+	networkHeightIncrease time.Time // When the networkHeight increased for the last time
+	networkHalted         time.Time // Network did not produce blocks for the stall window
+	networkHaltedHeight   uint64    // The network height when the network halted for the last time
+	networkResumed        time.Time // Network produced a block after the last halt
+
+	progress       catchUpProgress
+	slowSince      time.Time     // Estimated time to catch up exceeds the time left since this probe
+	catchUpTooSlow time.Time     // Estimated time to catch up exceeded the time left for the catch-up
+	slowETA        time.Duration // Estimated time to catch up when the node was considered too slow
+	slowTimeLeft   time.Duration // Time left for the catch-up when the node was considered too slow
+
+	lagEpisodes     int           // How many times the node started lagging after the catch-up
+	laggingDuration time.Duration // Total time of the finished lag episodes
+
+	state       NodeState
+	stateReason ReasonCode // Why the node is in the current state, e.g. the lagging node can be stuck in the past
+	stateSince  time.Time
+	crashReason VisorExitReason
+	// Notified about every state change
+	stateListeners []StateListener
+
+	events eventTimeline
 }
 
-func (lns localNodeStatus) healthyStatus() HealthyStatus {
-	// Node stopped producing blocks at some point
-	if lns.blockProductionStopped.After(lns.healthy) {
-		return Unhealthy
-	}
-
-	// Node was up to date and did not lagging on the end
-	if !lns.catchUp.IsZero() && lns.healthy.After(lns.lagging) {
-		return Healthy
-	}
-
-	// Node caught rest of the network up at some point but then started lagging...
-	if !lns.catchUp.IsZero() && !lns.healthy.After(lns.lagging) {
-		return MaybeHealthy
-	}
-
-	return Unhealthy
-}
-
-func (lns localNodeStatus) unhealthyReason() string {
-	if !lns.catchUp.IsZero() && !lns.healthy.After(lns.blockProductionStopped) {
-		return fmt.Sprintf("Node stopped producing blocks at block %d", lns.lastHeight)
-	}
-
-	if !lns.catchUp.IsZero() && lns.healthy.After(lns.lagging) {
-		return ""
-	}
-
-	if !lns.catchUp.IsZero() && !lns.healthy.After(lns.lagging) {
-		return "Node caught up at some point but then started lagging"
-	}
-
-	if lns.catchUp.IsZero() {
-		return "Node never caught up rest of the network"
-	}
-
-	if lns.firstSeen.IsZero() {
-		return "Node never returned valid response for the /statistics endpoint"
-	}
-
-	if lns.blockProductionStopped.After(lns.healthy) {
-		return fmt.Sprintf("Node stopped producing blocks at block %d", lns.lastHeight)
-	}
-
-	return "Unknown reason?????"
-}
-
-const (
-	KeyNodeStatus                    = "status"
-	KeyUnhealthyReason               = "reason"
-	KeyStarted                       = "test-startup"
-	KeyFirstSeen                     = "node-startup"
-	KeyCatchUp                       = "node-catch-up"
-	KeyLastLag                       = "node-last-lag"
-	KeyLastHealthy                   = "node-last-healthy"
-	KeyCatchUpTime                   = "catchup-duration"
-	KeyNetworkStoppedProducingBlocks = "network-stopped-producing blocks"
-	KeyLastKnownNodeHeight           = "last-known-node-height"
-)
-
-// Prepare results that can be write into some file
-func (lns localNodeStatus) toMap() ComponentResults {
-	res := ComponentResults{
-		KeyNodeStatus:                    lns.healthyStatus(),
-		KeyUnhealthyReason:               lns.unhealthyReason(),
-		KeyStarted:                       lns.started.String(),
-		KeyFirstSeen:                     lns.firstSeen.String(),
-		KeyCatchUp:                       lns.catchUp.String(),
-		KeyLastLag:                       lns.lagging.String(),
-		KeyLastHealthy:                   lns.healthy.String(),
-		KeyLastKnownNodeHeight:           lns.lastHeight,
-		KeyNetworkStoppedProducingBlocks: lns.blockProductionStopped.String(),
-		KeyCatchUpTime:                   "N/A",
+// toResults prepares the watchdog section of the results
+func (lns localNodeStatus) toResults() *WatchdogResults {
+	res := &WatchdogResults{
+		State:                  lns.state,
+		StateSince:             optionalTime(lns.stateSince),
+		StartedAt:              optionalTime(lns.started),
+		FirstSeenAt:            optionalTime(lns.firstSeen),
+		CaughtUpAt:             optionalTime(lns.catchUp),
+		LastLagAt:              optionalTime(lns.lagging),
+		LastHealthyAt:          optionalTime(lns.healthy),
+		LastStallAt:            optionalTime(lns.blockProductionStopped),
+		LastVegaTimeLagAt:      optionalTime(lns.vegaTimeLagging),
+		NetworkHaltedAt:        optionalTime(lns.networkHalted),
+		LastKnownNodeHeight:    lns.lastHeight,
+		NetworkLastKnownHeight: lns.networkHeight,
+		LagEpisodes:            lns.lagEpisodes,
+		CatchUpProgress:        lns.progress.toResults(),
+		Events:                 lns.events.toResults(),
+		EventsDropped:          lns.events.dropped,
 	}
 
 	if !lns.catchUp.IsZero() {
-		res[KeyCatchUpTime] = lns.catchUp.Sub(lns.started).String()
+		catchUpDuration := lns.catchUp.Sub(lns.started).Seconds()
+		res.CatchUpDurationSeconds = &catchUpDuration
 	}
 
 	return res
 }
 
-func (lns *localNodeStatus) PushEvent(str string) {
-	if len(str) < 1 {
+func (lns *localNodeStatus) PushEvent(kind EventType, message string, heights eventHeights, now time.Time) {
+	if len(message) < 1 {
 		return
 	}
 
-	lns.events = append(lns.events, event{
-		time:  time.Now(),
-		event: str,
-	})
+	lns.events.push(kind, message, heights, now)
+}
+
+// clone returns copy of the status that can be safely read while the watchdog is still running
+func (lns localNodeStatus) clone() localNodeStatus {
+	result := lns
+	result.events = lns.events.clone()
+
+	return result
 }
 
 type watchdog struct {
 	logger        *zap.Logger
 	restEndpoints []string
-
-	stop   context.CancelFunc
-	status localNodeStatus
-
+	conf          config.Watchdog
+	// Every probe is written as a JSON line to this file
+	metricsFile string
+
+	// mut protects all of the fields below. They are written by the Start goroutine and read by
+	// the controller through the Healthy and Result functions.
+	mut                sync.Mutex
+	stop               context.CancelFunc
+	deadline           time.Time // End of the test, zero when the test has no time limit
+	status             localNodeStatus
+	metrics            metricsSummary
 	lastReconciliation time.Time
+	probeListeners     []ProbeListener
+	// Number of snapshots listed by the local data-node, queried only when someone listens to the probes
+	localSnapshots        int
+	localSnapshotsQueried time.Time
 }
 
-func NewWatchdog(restEndpoints []string, mainLogger *zap.Logger) (Component, error) {
+// catchUpTooSlowWindows is the number of speed windows the node must be too slow for, before the test fails early.
+const catchUpTooSlowWindows = 2
+
+// localSnapshotsInterval is how often the watchdog counts snapshots of the local node for the probe listeners.
+const localSnapshotsInterval = time.Minute
+
+func NewWatchdog(restEndpoints []string, conf config.Watchdog, metricsFile string, mainLogger *zap.Logger) (Component, error) {
 	if len(restEndpoints) < 1 {
 		return nil, fmt.Errorf("at least one rest endpoint is required")
 	}
 
+	conf = conf.Merge(config.DefaultWatchdog)
+	if err := conf.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid watchdog config: %w", err)
+	}
+
 	return &watchdog{
 		restEndpoints:      restEndpoints,
+		conf:               conf,
+		metricsFile:        metricsFile,
 		logger:             mainLogger,
 		lastReconciliation: time.Now(),
+		localSnapshots:     -1,
+		status: localNodeStatus{
+			progress:    catchUpProgress{window: conf.SpeedWindow},
+			state:       NodeNotStarted,
+			stateReason: ReasonNone,
+			events:      eventTimeline{limit: conf.EventsLimit},
+		},
 	}, nil
 }
 
 func (w *watchdog) Name() string {
-	return "watchdog"
+	return ComponentNameWatchdog
+}
+
+func (w *watchdog) Result(results *Results) {
+	status := w.statusSnapshot()
+	results.SetStatus(status.verdict())
+
+	res := status.toResults()
+	w.mut.Lock()
+	res.Metrics = w.metrics.toResults(w.metricsFile)
+	w.mut.Unlock()
+
+	res.Config = WatchdogConfigResults{
+		LocalRESTURL:              w.conf.LocalRESTURL,
+		LocalCoreURL:              w.conf.LocalCoreURL,
+		IntervalSeconds:           w.conf.Interval.Seconds(),
+		CoreLag:                   *w.conf.CoreLag,
+		DataNodeLag:               *w.conf.DataNodeLag,
+		StallWindowSeconds:        w.conf.StallWindow.Seconds(),
+		VegaTimeLagSeconds:        w.conf.VegaTimeLag.Seconds(),
+		SpeedWindowSeconds:        w.conf.SpeedWindow.Seconds(),
+		CatchUpTimeoutSeconds:     w.conf.CatchUpTimeout.Seconds(),
+		EventsLimit:               w.conf.EventsLimit,
+		MinHealthyDurationSeconds: w.conf.PassCriteria.MinHealthyDuration.Seconds(),
+		MaxCatchUpDurationSeconds: w.conf.PassCriteria.MaxCatchUpDuration.Seconds(),
+		MaxLagEpisodes:            w.conf.PassCriteria.MaxLagEpisodes,
+		MaxLaggingTimeSeconds:     w.conf.PassCriteria.MaxLaggingTime.Seconds(),
+	}
+	results.Watchdog = res
+
+	applyPassCriteria(results, w.conf.PassCriteria, status)
 }
 
-func (w *watchdog) Result() ComponentResults {
-	return w.status.toMap()
+func (w *watchdog) statusSnapshot() localNodeStatus {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+
+	return w.status.clone()
 }
 
 // Healthy implements Component.
 func (w *watchdog) Healthy() (bool, error) {
+	w.mut.Lock()
 	lastReconciliationDiff := time.Since(w.lastReconciliation)
+	catchUpTooSlow := !w.status.catchUpTooSlow.IsZero()
+	w.mut.Unlock()
+
+	// There is no point to wait for the end of the test when the node is not going to catch up
+	if catchUpTooSlow {
+		return false, fmt.Errorf("local node is not going to catch the network up in time")
+	}
 
-	return lastReconciliationDiff < 30*time.Second, nil
+	// Watchdog is stuck when it missed few probes in a row
+	return lastReconciliationDiff < max(30*time.Second, 6*w.conf.Interval), nil
 }
 
 // Start start the watchdog components and keep eyes on the node. It is responsible to set
@@ -172,16 +226,29 @@ func (w *watchdog) Healthy() (bool, error) {
 func (w *watchdog) Start(ctx context.Context) error {
 	restClient := networkutils.DefaultRESTClient()
 
-	w.status.started = time.Now()
-
 	watcherCtx, cancel := context.WithCancel(ctx)
 	defer cancel()
+
+	w.mut.Lock()
+	w.status.started = time.Now()
 	w.stop = cancel
+	w.deadline, _ = ctx.Deadline()
+	w.mut.Unlock()
+
+	// Metrics are appended, so every run phase of the scenario stays in the file
+	metricsOut, err := os.OpenFile(w.metricsFile, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open watchdog metrics file: %w", err)
+	}
+	defer metricsOut.Close()
 
-	ticker := time.NewTicker(5 * time.Second)
+	ticker := time.NewTicker(w.conf.Interval)
 	for {
+		w.mut.Lock()
 		w.lastReconciliation = time.Now()
-		ticker.Reset(5 * time.Second)
+		w.mut.Unlock()
+
+		ticker.Reset(w.conf.Interval)
 		select {
 		case <-ticker.C:
 		// Someone finished execution
@@ -189,89 +256,318 @@ func (w *watchdog) Start(ctx context.Context) error {
 			return nil
 		}
 
-		networkStatistics, err := networkutils.GetLatestStatistics(restClient, w.restEndpoints)
+		networkStatistics, err := networkutils.GetLatestStatistics(watcherCtx, restClient, w.restEndpoints)
 		if err != nil {
 			w.logger.Sugar().Infof("Could not get valid response from any available REST endpoints: %v", w.restEndpoints)
 			continue
 		}
 
-		nodeStatistics, err := networkutils.GetLatestStatistics(restClient, []string{"http://localhost:3008"})
-		if err != nil {
-			w.status.PushEvent("Node unhealthy")
-			w.logger.Sugar().Info("Could not get valid response from local node(http://localhost:3008)")
-			continue
+		probeStart := time.Now()
+		nodeStatistics, nodeErr := networkutils.GetLatestStatistics(watcherCtx, restClient, []string{w.conf.LocalRESTURL})
+		dataNodeDown := false
+		if w.conf.LocalCoreURL != "" {
+			// Data-node REST reports core height only when data-node is up, so We ask core directly
+			coreStatistics, err := networkutils.GetLatestStatistics(watcherCtx, restClient, []string{w.conf.LocalCoreURL})
+			switch {
+			case err != nil:
+				nodeErr = fmt.Errorf("failed to get statistics from local core: %w", err)
+			case nodeErr != nil:
+				// Core is running without the data-node, so the data-node is reported as lagging behind core
+				w.logger.Sugar().Infof("Could not get valid response from local data-node(%s): %s", w.conf.LocalRESTURL, nodeErr.Error())
+				nodeStatistics, nodeErr = coreStatistics, nil
+				nodeStatistics.DataNodeHeight = 0
+				dataNodeDown = true
+			default:
+				nodeStatistics.BlockHeight = coreStatistics.BlockHeight
+			}
 		}
 
-		if w.status.firstSeen.IsZero() {
-			w.status.PushEvent("Node response from /statistics first seen")
-			w.status.firstSeen = time.Now()
+		probeLatency := time.Since(probeStart)
+
+		if nodeErr != nil {
+			nodeStatistics = nil
+		}
+		metrics := newProbeMetrics(probeStart, probeLatency, networkStatistics, nodeStatistics, dataNodeDown)
+		if err := writeProbeMetrics(metricsOut, metrics); err != nil {
+			w.logger.Error("Failed to record watchdog metrics", zap.Error(err))
 		}
 
-		if nodeStatistics.BlockHeight < networkStatistics.BlockHeight {
-			blocksDiff := networkStatistics.BlockHeight - nodeStatistics.BlockHeight
-			if blocksDiff > 500 {
-				msg := fmt.Sprintf(
-					"Core blocks lag too big: local core(%d) is %d blocks behind rest of the network(%d), 500 blocks allowed",
-					nodeStatistics.BlockHeight,
-					blocksDiff,
-					networkStatistics.BlockHeight,
-				)
-				w.status.PushEvent(msg)
-				w.logger.Info(msg)
-
-				w.status.lagging = time.Now()
-				continue
-			}
+		w.mut.Lock()
+		w.metrics.push(metrics, *w.conf.CoreLag, *w.conf.DataNodeLag)
+		w.reconcile(networkStatistics, nodeStatistics, nodeErr, time.Now())
+		listeners := w.probeListeners
+		w.mut.Unlock()
+
+		w.notifyProbeListeners(watcherCtx, restClient, listeners, metrics, nodeErr == nil)
+	}
+}
+
+// AddProbeListener registers the listener notified after every probe.
+func (w *watchdog) AddProbeListener(listener ProbeListener) {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+
+	w.probeListeners = append(w.probeListeners, listener)
+}
+
+// AddStateListener registers the listener notified when the node moves to the new state.
+func (w *watchdog) AddStateListener(listener StateListener) {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+
+	w.status.stateListeners = append(w.status.stateListeners, listener)
+}
+
+// AddEventListener registers the listener notified about every event in the watchdog timeline.
+func (w *watchdog) AddEventListener(listener EventListener) {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+
+	w.status.events.listeners = append(w.status.events.listeners, listener)
+}
+
+func (w *watchdog) notifyProbeListeners(ctx context.Context, httpClient *http.Client, listeners []ProbeListener, metrics ProbeMetrics, nodeUp bool) {
+	if len(listeners) == 0 {
+		return
+	}
+
+	w.mut.Lock()
+	countSnapshots := nodeUp && time.Since(w.localSnapshotsQueried) >= localSnapshotsInterval
+	if countSnapshots {
+		w.localSnapshotsQueried = time.Now()
+	}
+	w.mut.Unlock()
+
+	if countSnapshots {
+		snapshots, err := networkutils.GetSnapshots(ctx, httpClient, w.conf.LocalRESTURL)
+		if err != nil {
+			w.logger.Sugar().Infof("Could not count snapshots of the local node: %s", err.Error())
+		} else {
+			w.mut.Lock()
+			w.localSnapshots = len(snapshots)
+			w.mut.Unlock()
 		}
+	}
 
-		if nodeStatistics.DataNodeHeight < nodeStatistics.BlockHeight {
-			blocksDiff := nodeStatistics.BlockHeight - nodeStatistics.DataNodeHeight
-
-			if blocksDiff > 500 {
-				msg := fmt.Sprintf(
-					"Data node blocks lag too big: local data-node(%d) is %d blocks behind core(%d), 500 blocks allowed",
-					nodeStatistics.DataNodeHeight,
-					blocksDiff,
-					nodeStatistics.BlockHeight,
-				)
-				w.status.PushEvent(msg)
-				w.logger.Info(msg)
-
-				w.status.lagging = time.Now()
-				continue
-			}
+	w.mut.Lock()
+	probe := WatchdogProbe{
+		Metrics:        metrics,
+		State:          w.status.state,
+		LocalSnapshots: w.localSnapshots,
+	}
+	w.mut.Unlock()
+
+	for _, listener := range listeners {
+		listener.WatchdogProbe(probe)
+	}
+}
+
+// reconcile updates the status based on the single probe of the network and the local node taken
+// at the given time. Caller must hold the w.mut lock.
+func (w *watchdog) reconcile(networkStatistics, nodeStatistics *networkutils.Statistics, nodeErr error, now time.Time) {
+	heights := eventHeights{network: networkStatistics.BlockHeight}
+
+	// The network head is tracked separately, so We do not blame the local node when the whole network stopped
+	if w.status.networkHeightIncrease.IsZero() || networkStatistics.BlockHeight > w.status.networkHeight {
+		if w.status.networkHalted.After(w.status.networkHeightIncrease) {
+			w.status.networkResumed = now
 		}
+		w.status.networkHeight = max(w.status.networkHeight, networkStatistics.BlockHeight)
+		w.status.networkHeightIncrease = now
+	} else if haltedFor := now.Sub(w.status.networkHeightIncrease); haltedFor >= w.conf.StallWindow {
+		msg := fmt.Sprintf(
+			"Network did not produce any block for %s. Last known network block is %d",
+			haltedFor.String(),
+			w.status.networkHeight,
+		)
+		w.status.PushEvent(EventNetworkHalted, msg, heights, now)
+		w.logger.Info(msg)
+		w.status.networkHalted = now
+		w.status.networkHaltedHeight = w.status.networkHeight
+	}
+	networkIsHalted := w.status.networkHalted.After(w.status.networkHeightIncrease)
 
-		// Height increased after it was considered as healthy?
-		if w.status.lastHeight >= nodeStatistics.BlockHeight {
-			msg := fmt.Sprintf("Node did not produce any block since last check. Last known block is %d", nodeStatistics.BlockHeight)
-			w.status.PushEvent(msg)
-			w.logger.Info(msg)
-			w.status.blockProductionStopped = time.Now()
-			continue
+	if nodeErr != nil {
+		w.status.PushEvent(EventNodeUnavailable, "Node unhealthy", heights, now)
+		w.logger.Sugar().Infof("Could not get valid response from local node(%s): %s", w.conf.LocalRESTURL, nodeErr.Error())
+
+		// Node that stopped responding does not produce blocks as well
+		if stalledFor := now.Sub(w.status.lastHeightIncrease); !w.status.firstSeen.IsZero() && stalledFor >= w.conf.StallWindow {
+			w.status.blockProductionStopped = now
+			w.status.stalledFor = stalledFor
+			w.status.transition(NodeStalled, ReasonStalled, now)
 		}
+		return
+	}
+
+	heights.localCore = nodeStatistics.BlockHeight
+	heights.localDataNode = nodeStatistics.DataNodeHeight
+
+	if w.status.firstSeen.IsZero() {
+		w.status.PushEvent(EventFirstSeen, "Node response from /statistics first seen", heights, now)
+		w.status.firstSeen = now
+		w.status.lastHeightIncrease = now
+	}
+
+	w.status.progress.push(heightSample{
+		time:          now,
+		localHeight:   nodeStatistics.BlockHeight,
+		networkHeight: networkStatistics.BlockHeight,
+	})
 
+	// Block times vary, so the node is stalled only when its height did not increase for the whole window.
+	// It is checked also when the node is lagging, because node can get stuck during the replay as well.
+	if nodeStatistics.BlockHeight > w.status.lastHeight {
 		w.status.lastHeight = nodeStatistics.BlockHeight
+		w.status.lastHeightIncrease = now
+	} else if networkIsHalted && nodeStatistics.BlockHeight >= w.status.networkHeight {
+		// Node is at the network head, it cannot produce blocks until the network is back
+		w.status.lastHeightIncrease = now
+		return
+	} else if stalledFor := now.Sub(w.status.lastHeightIncrease); stalledFor >= w.conf.StallWindow {
+		msg := fmt.Sprintf(
+			"Node did not produce any block for %s. Last known block is %d",
+			stalledFor.String(),
+			nodeStatistics.BlockHeight,
+		)
+		w.status.PushEvent(EventStalled, msg, heights, now)
+		w.logger.Info(msg)
+		w.status.blockProductionStopped = now
+		w.status.stalledFor = stalledFor
+		w.status.transition(NodeStalled, ReasonStalled, now)
+		return
+	}
 
-		w.status.healthy = time.Now()
-		if w.status.catchUp.IsZero() {
-			msg := fmt.Sprintf("Node caught rest of the network up at block %d", nodeStatistics.BlockHeight)
-			w.status.catchUp = time.Now()
-			w.status.PushEvent(msg)
+	if nodeStatistics.BlockHeight < networkStatistics.BlockHeight {
+		blocksDiff := networkStatistics.BlockHeight - nodeStatistics.BlockHeight
+		if blocksDiff > *w.conf.CoreLag {
+			msg := fmt.Sprintf(
+				"Core blocks lag too big: local core(%d) is %d blocks behind rest of the network(%d), %d blocks allowed. Replay speed is %.2f blocks/s, estimated time to catch up is %s",
+				nodeStatistics.BlockHeight,
+				blocksDiff,
+				networkStatistics.BlockHeight,
+				*w.conf.CoreLag,
+				w.status.progress.localSpeed,
+				w.status.progress.etaString(),
+			)
+			w.status.PushEvent(EventCoreLag, msg, heights, now)
 			w.logger.Info(msg)
-		} else {
-			msg := fmt.Sprintf("Local node is healthy, block is %d", nodeStatistics.BlockHeight)
-			w.status.PushEvent(msg)
+
+			w.status.lagging = now
+			w.status.lag(ReasonLagging, now)
+			if w.status.catchUp.IsZero() {
+				w.checkCatchUpSpeed(heights, now)
+			}
+			return
+		}
+	}
+
+	if nodeStatistics.DataNodeHeight < nodeStatistics.BlockHeight {
+		blocksDiff := nodeStatistics.BlockHeight - nodeStatistics.DataNodeHeight
+
+		if blocksDiff > *w.conf.DataNodeLag {
+			msg := fmt.Sprintf(
+				"Data node blocks lag too big: local data-node(%d) is %d blocks behind core(%d), %d blocks allowed",
+				nodeStatistics.DataNodeHeight,
+				blocksDiff,
+				nodeStatistics.BlockHeight,
+				*w.conf.DataNodeLag,
+			)
+			w.status.PushEvent(EventDataNodeLag, msg, heights, now)
 			w.logger.Info(msg)
+
+			w.status.lagging = now
+			w.status.lag(ReasonLagging, now)
+			return
+		}
+	}
+
+	// Node is progressing, but its vega time is far behind the network
+	if vegaTimeLag := networkStatistics.VegaTime.Sub(nodeStatistics.VegaTime); vegaTimeLag > w.conf.VegaTimeLag {
+		msg := fmt.Sprintf(
+			"Vega time lag too big: local node time(%s) is %s behind rest of the network(%s), %s allowed",
+			nodeStatistics.VegaTime.String(),
+			vegaTimeLag.String(),
+			networkStatistics.VegaTime.String(),
+			w.conf.VegaTimeLag.String(),
+		)
+		w.status.PushEvent(EventVegaTimeLag, msg, heights, now)
+		w.logger.Info(msg)
+		w.status.vegaTimeLagging = now
+		w.status.vegaTimeLag = vegaTimeLag
+		w.status.lag(ReasonVegaTimeLag, now)
+		return
+	}
+
+	w.status.healthy = now
+	w.status.transition(NodeCaughtUp, ReasonNone, now)
+	if w.status.catchUp.IsZero() {
+		msg := fmt.Sprintf("Node caught rest of the network up at block %d", nodeStatistics.BlockHeight)
+		w.status.catchUp = now
+		w.status.PushEvent(EventCaughtUp, msg, heights, now)
+		w.logger.Info(msg)
+	} else {
+		msg := fmt.Sprintf("Local node is healthy, block is %d", nodeStatistics.BlockHeight)
+		w.status.PushEvent(EventHealthy, msg, heights, now)
+		w.logger.Info(msg)
+	}
+}
+
+// checkCatchUpSpeed marks the catch-up as too slow when the node is not going to catch the network up
+// before the end of the test or the catch-up timeout. A single window can be slow, e.g. when the node
+// replays blocks with many transactions, so the estimate must exceed the time left for the whole
+// catchUpTooSlowWindows speed windows. Caller must hold the w.mut lock.
+func (w *watchdog) checkCatchUpSpeed(heights eventHeights, now time.Time) {
+	if !w.status.catchUpTooSlow.IsZero() || !w.status.progress.measured {
+		return
+	}
+
+	catchUpDeadline := w.deadline
+	if w.conf.CatchUpTimeout > 0 {
+		timeoutDeadline := w.status.started.Add(w.conf.CatchUpTimeout)
+		if catchUpDeadline.IsZero() || timeoutDeadline.Before(catchUpDeadline) {
+			catchUpDeadline = timeoutDeadline
 		}
+	}
+
+	if catchUpDeadline.IsZero() {
+		return
+	}
+
+	timeLeft := catchUpDeadline.Sub(now)
+	if w.status.progress.eta <= timeLeft {
+		w.status.slowSince = time.Time{}
+		return
+	}
 
+	if w.status.slowSince.IsZero() {
+		w.status.slowSince = now
 	}
+	if now.Sub(w.status.slowSince) < catchUpTooSlowWindows*w.conf.SpeedWindow && timeLeft > 0 {
+		return
+	}
+
+	msg := fmt.Sprintf(
+		"Node catches the network up too slow: replay speed is %.2f blocks/s, estimated time to catch up is %s, only %s left",
+		w.status.progress.localSpeed,
+		w.status.progress.etaString(),
+		timeLeft.Round(time.Second).String(),
+	)
+	w.status.PushEvent(EventCatchUpTooSlow, msg, heights, now)
+	w.logger.Error(msg)
+	w.status.catchUpTooSlow = now
+	w.status.slowETA = w.status.progress.eta
+	w.status.slowTimeLeft = timeLeft
 }
 
 // Stop implements Component.
 func (w *watchdog) Stop(ctx context.Context) error {
-	if w.stop != nil {
-		w.stop()
+	w.mut.Lock()
+	stop := w.stop
+	w.mut.Unlock()
+
+	if stop != nil {
+		stop()
 	}
 
 	return nil
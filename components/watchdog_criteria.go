@@ -0,0 +1,138 @@
+package components
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/vegaprotocol/snapshot-testing/config"
+)
+
+type PassCriteriaStatus string
+
+const (
+	PassCriteriaNotConfigured PassCriteriaStatus = "NOT_CONFIGURED"
+	PassCriteriaPassed        PassCriteriaStatus = "PASSED"
+	PassCriteriaFailed        PassCriteriaStatus = "FAILED"
+)
+
+type criterionResult struct {
+	name      string
+	threshold string
+	actual    string
+	passed    bool
+}
+
+func (cr criterionResult) toResults() CriterionResults {
+	return CriterionResults{
+		Name:      cr.name,
+		Threshold: cr.threshold,
+		Actual:    cr.actual,
+		Passed:    cr.passed,
+	}
+}
+
+// healthyStreak returns for how long the node has been continuously healthy at the end of the test.
+func (lns localNodeStatus) healthyStreak(now time.Time) time.Duration {
+	if lns.state != NodeCaughtUp {
+		return 0
+	}
+
+	return now.Sub(lns.stateSince)
+}
+
+// laggingTime returns total time the node was lagging after it caught the network up.
+func (lns localNodeStatus) laggingTime(now time.Time) time.Duration {
+	if lns.state != NodeLagging {
+		return lns.laggingDuration
+	}
+
+	return lns.laggingDuration + now.Sub(lns.stateSince)
+}
+
+// evaluatePassCriteria checks all of the configured pass criteria. Criteria with zero or nil threshold are skipped.
+func evaluatePassCriteria(criteria config.PassCriteria, lns localNodeStatus, now time.Time) (PassCriteriaStatus, []criterionResult) {
+	results := []criterionResult{}
+
+	if criteria.MinHealthyDuration > 0 {
+		streak := lns.healthyStreak(now)
+		results = append(results, criterionResult{
+			name:      "min-healthy-duration",
+			threshold: criteria.MinHealthyDuration.String(),
+			actual:    streak.Round(time.Second).String(),
+			passed:    streak >= criteria.MinHealthyDuration,
+		})
+	}
+
+	if criteria.MaxCatchUpDuration > 0 {
+		result := criterionResult{
+			name:      "max-catch-up-duration",
+			threshold: criteria.MaxCatchUpDuration.String(),
+			actual:    "N/A",
+		}
+		if !lns.catchUp.IsZero() {
+			catchUpDuration := lns.catchUp.Sub(lns.started)
+			result.actual = catchUpDuration.Round(time.Second).String()
+			result.passed = catchUpDuration <= criteria.MaxCatchUpDuration
+		}
+		results = append(results, result)
+	}
+
+	if criteria.MaxLagEpisodes != nil {
+		results = append(results, criterionResult{
+			name:      "max-lag-episodes",
+			threshold: fmt.Sprint(*criteria.MaxLagEpisodes),
+			actual:    fmt.Sprint(lns.lagEpisodes),
+			passed:    lns.lagEpisodes <= *criteria.MaxLagEpisodes,
+		})
+	}
+
+	if criteria.MaxLaggingTime > 0 {
+		laggingTime := lns.laggingTime(now)
+		results = append(results, criterionResult{
+			name:      "max-lagging-time",
+			threshold: criteria.MaxLaggingTime.String(),
+			actual:    laggingTime.Round(time.Second).String(),
+			passed:    laggingTime <= criteria.MaxLaggingTime,
+		})
+	}
+
+	if len(results) == 0 {
+		return PassCriteriaNotConfigured, results
+	}
+
+	for _, result := range results {
+		if !result.passed {
+			return PassCriteriaFailed, results
+		}
+	}
+
+	return PassCriteriaPassed, results
+}
+
+// applyPassCriteria adds the pass criteria to the results. The healthy node fails when it did not meet them.
+func applyPassCriteria(results *Results, criteria config.PassCriteria, lns localNodeStatus) {
+	status, criteriaResults := evaluatePassCriteria(criteria, lns, time.Now())
+
+	criteriaList := []CriterionResults{}
+	failed := []string{}
+	for _, result := range criteriaResults {
+		criteriaList = append(criteriaList, result.toResults())
+		if !result.passed {
+			failed = append(failed, fmt.Sprintf("%s(%s, %s allowed)", result.name, result.actual, result.threshold))
+		}
+	}
+
+	results.Watchdog.PassCriteria = criteriaList
+	results.Watchdog.PassCriteriaStatus = status
+
+	if status != PassCriteriaFailed {
+		return
+	}
+
+	// Node failed for more important reason
+	if results.Status != Healthy && results.Status != MaybeHealthy {
+		return
+	}
+
+	results.SetStatus(Unhealthy, ReasonPassCriteriaFailed, fmt.Sprintf("Node did not meet the pass criteria: %v", failed))
+}
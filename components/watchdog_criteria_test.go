@@ -0,0 +1,51 @@
+package components
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vegaprotocol/snapshot-testing/config"
+)
+
+func TestEvaluatePassCriteriaMaxLagEpisodes(t *testing.T) {
+	zero, two := 0, 2
+
+	testCases := []struct {
+		name           string
+		maxLagEpisodes *int
+		lagEpisodes    int
+		expected       PassCriteriaStatus
+	}{
+		{name: "not checked", maxLagEpisodes: nil, lagEpisodes: 5, expected: PassCriteriaNotConfigured},
+		{name: "no lag allowed without lag", maxLagEpisodes: &zero, lagEpisodes: 0, expected: PassCriteriaPassed},
+		{name: "no lag allowed with lag", maxLagEpisodes: &zero, lagEpisodes: 1, expected: PassCriteriaFailed},
+		{name: "within the limit", maxLagEpisodes: &two, lagEpisodes: 2, expected: PassCriteriaPassed},
+		{name: "above the limit", maxLagEpisodes: &two, lagEpisodes: 3, expected: PassCriteriaFailed},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			criteria := config.PassCriteria{MaxLagEpisodes: tc.maxLagEpisodes}
+			lns := localNodeStatus{state: NodeCaughtUp, lagEpisodes: tc.lagEpisodes}
+
+			status, _ := evaluatePassCriteria(criteria, lns, time.Now())
+			if status != tc.expected {
+				t.Errorf("expected %s, got %s", tc.expected, status)
+			}
+		})
+	}
+}
+
+func TestPassCriteriaMergeKeepsExplicitZero(t *testing.T) {
+	zero, two := 0, 2
+
+	merged := config.PassCriteria{MaxLagEpisodes: &zero}.Merge(config.PassCriteria{MaxLagEpisodes: &two})
+	if merged.MaxLagEpisodes == nil || *merged.MaxLagEpisodes != 0 {
+		t.Errorf("expected explicit 0 to be kept, got %v", merged.MaxLagEpisodes)
+	}
+
+	merged = config.PassCriteria{}.Merge(config.PassCriteria{MaxLagEpisodes: &two})
+	if merged.MaxLagEpisodes == nil || *merged.MaxLagEpisodes != 2 {
+		t.Errorf("expected the other value for the empty criteria, got %v", merged.MaxLagEpisodes)
+	}
+}
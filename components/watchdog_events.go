@@ -0,0 +1,117 @@
+package components
+
+import "time"
+
+type EventType string
+
+const (
+	EventNodeStarting    EventType = "NODE_STARTING"
+	EventNodeCrashed     EventType = "NODE_CRASHED"
+	EventNodeUnavailable EventType = "NODE_UNAVAILABLE"
+	EventFirstSeen       EventType = "FIRST_SEEN"
+	EventCoreLag         EventType = "CORE_LAG"
+	EventDataNodeLag     EventType = "DATA_NODE_LAG"
+	EventStalled         EventType = "STALLED"
+	EventCatchUpTooSlow  EventType = "CATCHUP_TOO_SLOW"
+	EventVegaTimeLag     EventType = "VEGA_TIME_LAG"
+	EventNetworkHalted   EventType = "NETWORK_HALTED"
+	EventCaughtUp        EventType = "CAUGHT_UP"
+	EventHealthy         EventType = "HEALTHY"
+)
+
+// EventListener is implemented by components that follow the watchdog timeline. The listener gets
+// the new event or the updated latest one, when the same event happened again.
+type EventListener interface {
+	WatchdogEvent(event EventResults)
+}
+
+type eventHeights struct {
+	localCore     uint64
+	localDataNode uint64
+	network       uint64
+}
+
+// event is a single entry in the watchdog timeline. Consecutive events of the same type are
+// compressed into one entry, where count tells how many times it happened between time and
+// lastTime. Message and heights come from the latest occurrence.
+type event struct {
+	time     time.Time
+	lastTime time.Time
+	count    uint64
+
+	kind    EventType
+	message string
+	heights eventHeights
+}
+
+func (e event) toResults() EventResults {
+	return EventResults{
+		Time:                e.time,
+		LastTime:            e.lastTime,
+		Count:               e.count,
+		Type:                e.kind,
+		Message:             e.message,
+		LocalCoreHeight:     e.heights.localCore,
+		LocalDataNodeHeight: e.heights.localDataNode,
+		NetworkHeight:       e.heights.network,
+	}
+}
+
+// eventTimeline keeps the latest events up to the limit.
+type eventTimeline struct {
+	limit     int
+	dropped   uint64
+	events    []event
+	listeners []EventListener
+}
+
+// push adds the event that happened at the given time, usually the time of the probe.
+func (et *eventTimeline) push(kind EventType, message string, heights eventHeights, now time.Time) {
+	if last := len(et.events) - 1; last >= 0 && et.events[last].kind == kind {
+		et.events[last].lastTime = now
+		et.events[last].count++
+		et.events[last].message = message
+		et.events[last].heights = heights
+		et.notifyListeners(et.events[last])
+		return
+	}
+
+	et.events = append(et.events, event{
+		time:     now,
+		lastTime: now,
+		count:    1,
+		kind:     kind,
+		message:  message,
+		heights:  heights,
+	})
+
+	if et.limit > 0 && len(et.events) > et.limit {
+		dropped := len(et.events) - et.limit
+		et.events = et.events[dropped:]
+		et.dropped += uint64(dropped)
+	}
+
+	et.notifyListeners(et.events[len(et.events)-1])
+}
+
+func (et eventTimeline) notifyListeners(e event) {
+	for _, listener := range et.listeners {
+		listener.WatchdogEvent(e.toResults())
+	}
+}
+
+func (et eventTimeline) clone() eventTimeline {
+	result := et
+	result.events = append([]event{}, et.events...)
+
+	return result
+}
+
+func (et eventTimeline) toResults() []EventResults {
+	result := []EventResults{}
+	for _, e := range et.events {
+		result = append(result, e.toResults())
+	}
+
+	return result
+}
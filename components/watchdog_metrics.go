@@ -0,0 +1,173 @@
+package components
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/vegaprotocol/snapshot-testing/networkutils"
+)
+
+// ProbeMetrics is a single watchdog probe written as a line of the metrics file.
+type ProbeMetrics struct {
+	Time        time.Time `json:"time"`
+	LocalNodeUp bool      `json:"local_node_up"`
+	// Local core responded without the local data-node, so the data-node height and lag are not known
+	LocalDataNodeDown   bool   `json:"local_data_node_down"`
+	LocalCoreHeight     uint64 `json:"local_core_height"`
+	LocalDataNodeHeight uint64 `json:"local_data_node_height"`
+	NetworkHeight       uint64 `json:"network_height"`
+	CoreLag             uint64 `json:"core_lag"`
+	DataNodeLag         uint64 `json:"data_node_lag"`
+	// Wall clock minus the local node vega time
+	VegaTimeDriftSeconds float64 `json:"vega_time_drift_seconds"`
+	ProbeLatencySeconds  float64 `json:"probe_latency_seconds"`
+}
+
+// DataNodeLagKnown tells if the probe measured the local data-node lag.
+func (pm ProbeMetrics) DataNodeLagKnown() bool {
+	return pm.LocalNodeUp && !pm.LocalDataNodeDown
+}
+
+func newProbeMetrics(
+	probeTime time.Time,
+	latency time.Duration,
+	networkStatistics *networkutils.Statistics,
+	nodeStatistics *networkutils.Statistics,
+	dataNodeDown bool,
+) ProbeMetrics {
+	metrics := ProbeMetrics{
+		Time:                probeTime,
+		NetworkHeight:       networkStatistics.BlockHeight,
+		ProbeLatencySeconds: latency.Seconds(),
+	}
+
+	if nodeStatistics == nil {
+		return metrics
+	}
+
+	metrics.LocalNodeUp = true
+	metrics.LocalCoreHeight = nodeStatistics.BlockHeight
+	metrics.VegaTimeDriftSeconds = probeTime.Sub(nodeStatistics.VegaTime).Seconds()
+	if networkStatistics.BlockHeight > nodeStatistics.BlockHeight {
+		metrics.CoreLag = networkStatistics.BlockHeight - nodeStatistics.BlockHeight
+	}
+
+	if dataNodeDown {
+		metrics.LocalDataNodeDown = true
+		return metrics
+	}
+
+	metrics.LocalDataNodeHeight = nodeStatistics.DataNodeHeight
+	if nodeStatistics.BlockHeight > nodeStatistics.DataNodeHeight {
+		metrics.DataNodeLag = nodeStatistics.BlockHeight - nodeStatistics.DataNodeHeight
+	}
+
+	return metrics
+}
+
+// metricsSummary aggregates all of the probes recorded during the test.
+type metricsSummary struct {
+	probes         uint64
+	maxCoreLag     uint64
+	maxDataNodeLag uint64
+	lagging        time.Duration
+
+	firstUp *ProbeMetrics
+	lastUp  *ProbeMetrics
+	last    *ProbeMetrics
+}
+
+// push adds the probe to the summary. The time between probes is counted as lagging when
+// the previous probe exceeded any of the thresholds. Probes without the local data-node do not
+// count to the data-node lag.
+func (ms *metricsSummary) push(metrics ProbeMetrics, coreLagThreshold, dataNodeLagThreshold uint64) {
+	ms.probes++
+	ms.maxCoreLag = max(ms.maxCoreLag, metrics.CoreLag)
+	if metrics.DataNodeLagKnown() {
+		ms.maxDataNodeLag = max(ms.maxDataNodeLag, metrics.DataNodeLag)
+	}
+
+	if ms.last != nil && ms.last.LocalNodeUp &&
+		(ms.last.CoreLag > coreLagThreshold || ms.last.DataNodeLag > dataNodeLagThreshold) {
+		ms.lagging += metrics.Time.Sub(ms.last.Time)
+	}
+
+	if metrics.LocalNodeUp {
+		if ms.firstUp == nil {
+			ms.firstUp = &metrics
+		}
+		ms.lastUp = &metrics
+	}
+	ms.last = &metrics
+}
+
+// blocksPerSecond returns mean speed of the local core between the first and the last probe
+// where the local node responded.
+func (ms metricsSummary) blocksPerSecond() float64 {
+	if ms.firstUp == nil || ms.lastUp == nil || ms.lastUp.LocalCoreHeight < ms.firstUp.LocalCoreHeight {
+		return 0
+	}
+
+	elapsed := ms.lastUp.Time.Sub(ms.firstUp.Time).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+
+	return float64(ms.lastUp.LocalCoreHeight-ms.firstUp.LocalCoreHeight) / elapsed
+}
+
+func (ms metricsSummary) toResults(metricsFile string) MetricsSummaryResults {
+	return MetricsSummaryResults{
+		Probes:              ms.probes,
+		MaxCoreLag:          ms.maxCoreLag,
+		MaxDataNodeLag:      ms.maxDataNodeLag,
+		MeanBlocksPerSecond: ms.blocksPerSecond(),
+		LaggingSeconds:      ms.lagging.Seconds(),
+		MetricsFile:         metricsFile,
+	}
+}
+
+func writeProbeMetrics(out io.Writer, metrics ProbeMetrics) error {
+	line, err := json.Marshal(metrics)
+	if err != nil {
+		return fmt.Errorf("failed to marshal probe metrics: %w", err)
+	}
+
+	if _, err := out.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write probe metrics: %w", err)
+	}
+
+	return nil
+}
+
+// ReadProbeMetrics reads all of the probes from the metrics file written by the watchdog.
+func ReadProbeMetrics(metricsFile string) ([]ProbeMetrics, error) {
+	metricsIn, err := os.Open(metricsFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open metrics file: %w", err)
+	}
+	defer metricsIn.Close()
+
+	metrics := []ProbeMetrics{}
+	scanner := bufio.NewScanner(metricsIn)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		probe := ProbeMetrics{}
+		if err := json.Unmarshal(scanner.Bytes(), &probe); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal probe metrics: %w", err)
+		}
+		metrics = append(metrics, probe)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read metrics file: %w", err)
+	}
+
+	return metrics, nil
+}
@@ -0,0 +1,116 @@
+package components
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/vegaprotocol/snapshot-testing/config"
+	"github.com/vegaprotocol/snapshot-testing/networkutils"
+)
+
+func TestProbeMetricsWithDataNodeDown(t *testing.T) {
+	probeTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	network := &networkutils.Statistics{BlockHeight: 1000}
+
+	testCases := []struct {
+		name                string
+		node                *networkutils.Statistics
+		dataNodeDown        bool
+		expectedLagKnown    bool
+		expectedDataNodeLag uint64
+		expectedCoreLag     uint64
+	}{
+		{
+			name:                "data-node up",
+			node:                &networkutils.Statistics{BlockHeight: 990, DataNodeHeight: 980, VegaTime: probeTime},
+			expectedLagKnown:    true,
+			expectedDataNodeLag: 10,
+			expectedCoreLag:     10,
+		},
+		{
+			name:             "data-node down",
+			node:             &networkutils.Statistics{BlockHeight: 990, VegaTime: probeTime},
+			dataNodeDown:     true,
+			expectedLagKnown: false,
+			expectedCoreLag:  10,
+		},
+		{
+			name:             "node down",
+			expectedLagKnown: false,
+			expectedCoreLag:  0,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			metrics := newProbeMetrics(probeTime, time.Millisecond, network, tc.node, tc.dataNodeDown)
+
+			if metrics.DataNodeLagKnown() != tc.expectedLagKnown {
+				t.Errorf("expected data-node lag known %t, got %t", tc.expectedLagKnown, metrics.DataNodeLagKnown())
+			}
+			if metrics.DataNodeLag != tc.expectedDataNodeLag {
+				t.Errorf("expected data-node lag %d, got %d", tc.expectedDataNodeLag, metrics.DataNodeLag)
+			}
+			if metrics.CoreLag != tc.expectedCoreLag {
+				t.Errorf("expected core lag %d, got %d", tc.expectedCoreLag, metrics.CoreLag)
+			}
+		})
+	}
+}
+
+func TestMetricsSummarySkipsDataNodeDown(t *testing.T) {
+	probeTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	network := &networkutils.Statistics{BlockHeight: 1000}
+
+	summary := metricsSummary{}
+	summary.push(newProbeMetrics(probeTime, 0, network, &networkutils.Statistics{BlockHeight: 1000, DataNodeHeight: 995}, false), 10, 10)
+	summary.push(newProbeMetrics(probeTime.Add(time.Second), 0, network, &networkutils.Statistics{BlockHeight: 1000}, true), 10, 10)
+	summary.push(newProbeMetrics(probeTime.Add(2*time.Second), 0, network, &networkutils.Statistics{BlockHeight: 1000, DataNodeHeight: 998}, false), 10, 10)
+
+	results := summary.toResults("")
+	if results.MaxDataNodeLag != 5 {
+		t.Errorf("expected the max data-node lag from the probes with the data-node up, got %d", results.MaxDataNodeLag)
+	}
+	if results.LaggingSeconds != 0 {
+		t.Errorf("expected no lagging time, got %f", results.LaggingSeconds)
+	}
+}
+
+func TestWatchdogAppendsMetricsOfEveryRun(t *testing.T) {
+	server := newFakeNodeServer(t)
+	metricsFile := filepath.Join(t.TempDir(), "watchdog-metrics.jsonl")
+
+	probes := uint64(0)
+	for run := 0; run < 2; run++ {
+		watchdog, err := NewWatchdog([]string{server.URL}, config.Watchdog{
+			LocalRESTURL: server.URL,
+			Interval:     10 * time.Millisecond,
+		}, metricsFile, zap.NewNop())
+		if err != nil {
+			t.Fatalf("failed to create watchdog: %v", err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		err = watchdog.Start(ctx)
+		cancel()
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		results := NewResults(time.Now())
+		watchdog.Result(results)
+		probes += results.Watchdog.Metrics.Probes
+	}
+
+	metrics, err := ReadProbeMetrics(metricsFile)
+	if err != nil {
+		t.Fatalf("failed to read metrics: %v", err)
+	}
+	if uint64(len(metrics)) != probes {
+		t.Errorf("expected %d probes of both runs in the metrics file, got %d", probes, len(metrics))
+	}
+}
@@ -0,0 +1,84 @@
+package components
+
+import (
+	"math"
+	"time"
+)
+
+type heightSample struct {
+	time          time.Time
+	localHeight   uint64
+	networkHeight uint64
+}
+
+// catchUpProgress measures the replay speed of the local node over the moving window and estimates
+// when the node catches the network up.
+type catchUpProgress struct {
+	window  time.Duration
+	samples []heightSample
+
+	// Speeds measured over the last full window
+	localSpeed    float64
+	networkSpeed  float64
+	maxLocalSpeed float64
+	// Estimated time to catch up, math.MaxInt64 when the node does not get closer to the network
+	eta      time.Duration
+	measured bool
+}
+
+func (cup *catchUpProgress) push(sample heightSample) {
+	cup.samples = append(cup.samples, sample)
+
+	// Keep one sample older than the window, so the samples always cover the whole window
+	for len(cup.samples) > 2 && sample.time.Sub(cup.samples[1].time) >= cup.window {
+		cup.samples = cup.samples[1:]
+	}
+
+	first := cup.samples[0]
+	elapsed := sample.time.Sub(first.time)
+	if elapsed < cup.window || sample.localHeight < first.localHeight || sample.networkHeight < first.networkHeight {
+		return
+	}
+
+	cup.measured = true
+	cup.localSpeed = float64(sample.localHeight-first.localHeight) / elapsed.Seconds()
+	cup.networkSpeed = float64(sample.networkHeight-first.networkHeight) / elapsed.Seconds()
+	cup.maxLocalSpeed = max(cup.maxLocalSpeed, cup.localSpeed)
+
+	cup.eta = time.Duration(math.MaxInt64)
+	if sample.localHeight >= sample.networkHeight {
+		cup.eta = 0
+	} else if closingSpeed := cup.localSpeed - cup.networkSpeed; closingSpeed > 0 {
+		lag := float64(sample.networkHeight - sample.localHeight)
+		cup.eta = time.Duration(lag / closingSpeed * float64(time.Second))
+	}
+}
+
+func (cup catchUpProgress) etaString() string {
+	if !cup.measured {
+		return "N/A"
+	}
+
+	if cup.eta == time.Duration(math.MaxInt64) {
+		return "never"
+	}
+
+	return cup.eta.Round(time.Second).String()
+}
+
+func (cup catchUpProgress) toResults() CatchUpProgressResults {
+	res := CatchUpProgressResults{
+		WindowSeconds:            cup.window.Seconds(),
+		ReplayBlocksPerSecond:    cup.localSpeed,
+		MaxReplayBlocksPerSecond: cup.maxLocalSpeed,
+		NetworkBlocksPerSecond:   cup.networkSpeed,
+		Measured:                 cup.measured,
+	}
+
+	if cup.measured && cup.eta != time.Duration(math.MaxInt64) {
+		eta := cup.eta.Seconds()
+		res.EstimatedSecondsToCatchUp = &eta
+	}
+
+	return res
+}
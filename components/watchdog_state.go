@@ -0,0 +1,226 @@
+package components
+
+import (
+	"fmt"
+	"time"
+)
+
+// NodeState is the state of the local node tracked by the watchdog. The state is changed by the
+// watchdog probes and the vegavisor events:
+//
+//	NOT_STARTED -> STARTING  vegavisor started
+//	* -> CATCHING_UP         lag above the thresholds before the node caught up
+//	* -> CAUGHT_UP           lag within the thresholds
+//	* -> LAGGING             lag above the thresholds after the node caught up
+//	* -> STALLED             height did not increase for the stall window
+//	* -> CRASHED             vegavisor exited before the end of the test, the state is final
+type NodeState string
+
+const (
+	NodeNotStarted NodeState = "NOT_STARTED"
+	NodeStarting   NodeState = "STARTING"
+	NodeCatchingUp NodeState = "CATCHING_UP"
+	NodeCaughtUp   NodeState = "CAUGHT_UP"
+	NodeLagging    NodeState = "LAGGING"
+	NodeStalled    NodeState = "STALLED"
+	NodeCrashed    NodeState = "CRASHED"
+)
+
+// ReasonCode explains the final status of the test.
+type ReasonCode string
+
+const (
+	ReasonNone               ReasonCode = "NONE"
+	ReasonSetupFailed        ReasonCode = "SETUP_FAILED"
+	ReasonNeverResponded     ReasonCode = "NEVER_RESPONDED"
+	ReasonNeverCaughtUp      ReasonCode = "NEVER_CAUGHT_UP"
+	ReasonCatchUpTooSlow     ReasonCode = "CATCHUP_TOO_SLOW"
+	ReasonLagging            ReasonCode = "LAGGING_AFTER_CATCH_UP"
+	ReasonVegaTimeLag        ReasonCode = "VEGA_TIME_LAG"
+	ReasonStalled            ReasonCode = "STALLED"
+	ReasonCrashed            ReasonCode = "CRASHED"
+	ReasonNetworkHalted      ReasonCode = "NETWORK_HALTED"
+	ReasonStateDiverged      ReasonCode = "STATE_DIVERGED"
+	ReasonSmokeTestsFailed   ReasonCode = "API_SMOKE_TESTS_FAILED"
+	ReasonPassCriteriaFailed ReasonCode = "PASS_CRITERIA_FAILED"
+)
+
+// NodeStateChange is passed to the StateListener when the node moves to the new state.
+type NodeStateChange struct {
+	Time          time.Time
+	PreviousState NodeState
+	State         NodeState
+	Reason        ReasonCode
+	// The last known heights of the local core and the network
+	LocalCoreHeight uint64
+	NetworkHeight   uint64
+}
+
+// StateListener is implemented by components that follow the node state tracked by the watchdog.
+// Listeners are called with the watchdog lock held, so they must not block.
+type StateListener interface {
+	NodeStateChanged(change NodeStateChange)
+}
+
+// transition moves the node to the new state. The crashed node does not change its state anymore.
+func (lns *localNodeStatus) transition(state NodeState, reason ReasonCode, now time.Time) {
+	if lns.state == NodeCrashed || (lns.state == state && lns.stateReason == reason) {
+		return
+	}
+
+	if lns.state == NodeLagging {
+		lns.laggingDuration += now.Sub(lns.stateSince)
+	}
+	if state == NodeLagging && lns.state != NodeLagging {
+		lns.lagEpisodes++
+	}
+
+	change := NodeStateChange{
+		Time:            now,
+		PreviousState:   lns.state,
+		State:           state,
+		Reason:          reason,
+		LocalCoreHeight: lns.lastHeight,
+		NetworkHeight:   lns.networkHeight,
+	}
+
+	lns.state = state
+	lns.stateReason = reason
+	lns.stateSince = now
+
+	// Only the state changes are interesting for listeners, not the reason updates
+	if change.PreviousState != change.State {
+		for _, listener := range lns.stateListeners {
+			listener.NodeStateChanged(change)
+		}
+	}
+}
+
+// lag moves the node to the lagging state, or keeps it catching up when it never caught the network up.
+func (lns *localNodeStatus) lag(reason ReasonCode, now time.Time) {
+	if lns.catchUp.IsZero() {
+		lns.transition(NodeCatchingUp, ReasonNone, now)
+		return
+	}
+
+	lns.transition(NodeLagging, reason, now)
+}
+
+// verdict returns the final status of the test with the reason, based on the last node state.
+func (lns localNodeStatus) verdict() (HealthyStatus, ReasonCode, string) {
+	status, code, reason := lns.nodeVerdict()
+
+	if status == Healthy || lns.networkHalted.IsZero() {
+		return status, code, reason
+	}
+
+	// Local node could not be healthy because the whole network stopped, it is not a failure of the node
+	if lns.networkHalted.After(lns.networkHeightIncrease) {
+		return NetworkHalted, ReasonNetworkHalted, fmt.Sprintf(
+			"Network halted: network height did not increase from block %d since %s",
+			lns.networkHeight,
+			lns.networkHeightIncrease.String(),
+		)
+	}
+
+	// Network recovered, so only the failure that started before the network resumed is caused by the halt
+	if !lns.stateSince.After(lns.networkResumed) {
+		return NetworkHalted, ReasonNetworkHalted, fmt.Sprintf(
+			"Network halted: network height did not increase from block %d until %s",
+			lns.networkHaltedHeight,
+			lns.networkResumed.String(),
+		)
+	}
+
+	return status, code, reason
+}
+
+func (lns localNodeStatus) nodeVerdict() (HealthyStatus, ReasonCode, string) {
+	switch lns.state {
+	case NodeCaughtUp:
+		return Healthy, ReasonNone, ""
+
+	case NodeLagging:
+		if lns.stateReason == ReasonVegaTimeLag {
+			return Unhealthy, ReasonVegaTimeLag, fmt.Sprintf(
+				"Node stuck in the past: vega time is %s behind the network at block %d",
+				lns.vegaTimeLag.String(),
+				lns.lastHeight,
+			)
+		}
+		return MaybeHealthy, ReasonLagging, "Node caught up at some point but then started lagging"
+
+	case NodeStalled:
+		return Unhealthy, ReasonStalled, fmt.Sprintf(
+			"Node stalled: height did not increase from block %d for %s",
+			lns.lastHeight,
+			lns.stalledFor.String(),
+		)
+
+	case NodeCrashed:
+		return Unhealthy, ReasonCrashed, fmt.Sprintf(
+			"Node crashed: vegavisor exited(%s) at %s",
+			lns.crashReason,
+			lns.stateSince.String(),
+		)
+
+	case NodeCatchingUp:
+		if !lns.catchUpTooSlow.IsZero() {
+			return Unhealthy, ReasonCatchUpTooSlow, fmt.Sprintf(
+				"Node catches the network up too slow: estimated time to catch up was %s, only %s left",
+				lns.slowETA.Round(time.Second).String(),
+				lns.slowTimeLeft.Round(time.Second).String(),
+			)
+		}
+		return Unhealthy, ReasonNeverCaughtUp, "Node never caught up rest of the network"
+
+	default:
+		// NodeNotStarted and NodeStarting
+		return Unhealthy, ReasonNeverResponded, "Node never returned valid response for the /statistics endpoint"
+	}
+}
+
+// VisorListener is implemented by components that follow the vegavisor process lifecycle.
+type VisorListener interface {
+	VisorStarted()
+	VisorExited(reason VisorExitReason)
+}
+
+// VisorStarted implements VisorListener.
+func (w *watchdog) VisorStarted() {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+
+	w.visorStarted(time.Now())
+}
+
+// visorStarted moves the node to the starting state. Caller must hold the w.mut lock.
+func (w *watchdog) visorStarted(now time.Time) {
+	if w.status.state != NodeNotStarted {
+		return
+	}
+
+	w.status.PushEvent(EventNodeStarting, "Vegavisor process started", eventHeights{}, now)
+	w.status.transition(NodeStarting, ReasonNone, now)
+}
+
+// VisorExited implements VisorListener.
+func (w *watchdog) VisorExited(reason VisorExitReason) {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+
+	w.visorExited(reason, time.Now())
+}
+
+// visorExited marks the node crashed when vegavisor exited on its own. Caller must hold the w.mut lock.
+func (w *watchdog) visorExited(reason VisorExitReason, now time.Time) {
+	if !reason.EarlyTermination() {
+		return
+	}
+
+	msg := fmt.Sprintf("Vegavisor process exited before the end of the test: %s", reason)
+	w.status.PushEvent(EventNodeCrashed, msg, eventHeights{localCore: w.status.lastHeight, network: w.status.networkHeight}, now)
+	w.logger.Info(msg)
+	w.status.crashReason = reason
+	w.status.transition(NodeCrashed, ReasonCrashed, now)
+}
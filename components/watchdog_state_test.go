@@ -0,0 +1,414 @@
+package components
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/vegaprotocol/snapshot-testing/config"
+	"github.com/vegaprotocol/snapshot-testing/networkutils"
+	"go.uber.org/zap"
+)
+
+const testProbeInterval = 5 * time.Second
+
+// watchdogStep is a single recorded probe or vegavisor event replayed by the watchdog.
+type watchdogStep struct {
+	// Vegavisor events are replayed instead of the probe when set
+	visorStarted bool
+	visorExited  VisorExitReason
+
+	network  uint64
+	core     uint64
+	dataNode uint64
+	// Local node did not respond to the probe
+	nodeDown    bool
+	vegaTimeLag time.Duration
+}
+
+func visorStartedStep() watchdogStep {
+	return watchdogStep{visorStarted: true}
+}
+
+func visorExitedStep(reason VisorExitReason) watchdogStep {
+	return watchdogStep{visorExited: reason}
+}
+
+func probeStep(network, core, dataNode uint64) watchdogStep {
+	return watchdogStep{network: network, core: core, dataNode: dataNode}
+}
+
+func nodeDownStep(network uint64) watchdogStep {
+	return watchdogStep{network: network, nodeDown: true}
+}
+
+// repeatSteps returns count probes where every height increases by the given number of blocks per probe.
+func repeatSteps(count int, from watchdogStep, networkBlocks, nodeBlocks uint64) []watchdogStep {
+	steps := []watchdogStep{}
+	for i := 0; i < count; i++ {
+		steps = append(steps, from)
+		from.network += networkBlocks
+		from.core += nodeBlocks
+		from.dataNode += nodeBlocks
+	}
+
+	return steps
+}
+
+func testWatchdogConfig() config.Watchdog {
+	coreLag, dataNodeLag := uint64(10), uint64(10)
+
+	return config.Watchdog{
+		Interval:    testProbeInterval,
+		CoreLag:     &coreLag,
+		DataNodeLag: &dataNodeLag,
+		StallWindow: time.Minute,
+		VegaTimeLag: time.Minute,
+		SpeedWindow: time.Minute,
+	}
+}
+
+// replayWatchdog replays the steps with synthetic timestamps, one probe interval apart. The test ends
+// after the testDuration, zero means no time limit.
+func replayWatchdog(t *testing.T, conf config.Watchdog, testDuration time.Duration, steps []watchdogStep) *watchdog {
+	t.Helper()
+
+	component, err := NewWatchdog([]string{"http://remote:3008"}, conf, "", zap.NewNop())
+	if err != nil {
+		t.Fatalf("failed to create watchdog: %v", err)
+	}
+	w := component.(*watchdog)
+
+	started := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	w.status.started = started
+	if testDuration > 0 {
+		w.deadline = started.Add(testDuration)
+	}
+
+	now := started
+	for _, step := range steps {
+		now = now.Add(testProbeInterval)
+		vegaTime := started.Add(time.Hour).Add(now.Sub(started))
+
+		w.mut.Lock()
+		switch {
+		case step.visorStarted:
+			w.visorStarted(now)
+		case step.visorExited != "":
+			w.visorExited(step.visorExited, now)
+		default:
+			networkStatistics := &networkutils.Statistics{
+				BlockHeight:    step.network,
+				DataNodeHeight: step.network,
+				VegaTime:       vegaTime,
+			}
+
+			var nodeStatistics *networkutils.Statistics
+			var nodeErr error
+			if step.nodeDown {
+				nodeErr = errors.New("connection refused")
+			} else {
+				nodeStatistics = &networkutils.Statistics{
+					BlockHeight:    step.core,
+					DataNodeHeight: step.dataNode,
+					VegaTime:       vegaTime.Add(-step.vegaTimeLag),
+				}
+			}
+
+			w.reconcile(networkStatistics, nodeStatistics, nodeErr, now)
+		}
+		w.mut.Unlock()
+	}
+
+	return w
+}
+
+func concatSteps(parts ...[]watchdogStep) []watchdogStep {
+	steps := []watchdogStep{}
+	for _, part := range parts {
+		steps = append(steps, part...)
+	}
+
+	return steps
+}
+
+func TestWatchdogStateReplay(t *testing.T) {
+	// Node replays 100 blocks per probe, the network produces 5 blocks per probe
+	catchingUp := repeatSteps(5, probeStep(1000, 100, 100), 5, 100)
+	// Node follows the network within the thresholds
+	caughtUp := repeatSteps(5, probeStep(2000, 1998, 1998), 5, 5)
+	// Core falls 50 blocks behind the network
+	lagging := repeatSteps(3, probeStep(2100, 2050, 2050), 5, 5)
+	healthyAgain := repeatSteps(3, probeStep(2200, 2200, 2200), 5, 5)
+
+	testCases := []struct {
+		name         string
+		conf         func(conf *config.Watchdog)
+		testDuration time.Duration
+		steps        []watchdogStep
+
+		expectedState       NodeState
+		expectedStatus      HealthyStatus
+		expectedReason      ReasonCode
+		expectedLagEpisodes int
+	}{
+		{
+			name:           "node never responded",
+			steps:          []watchdogStep{visorStartedStep(), nodeDownStep(1000), nodeDownStep(1005)},
+			expectedState:  NodeStarting,
+			expectedStatus: Unhealthy,
+			expectedReason: ReasonNeverResponded,
+		},
+		{
+			name:           "catching up",
+			steps:          concatSteps([]watchdogStep{visorStartedStep()}, catchingUp),
+			expectedState:  NodeCatchingUp,
+			expectedStatus: Unhealthy,
+			expectedReason: ReasonNeverCaughtUp,
+		},
+		{
+			name:           "caught up",
+			steps:          concatSteps([]watchdogStep{visorStartedStep()}, catchingUp, caughtUp),
+			expectedState:  NodeCaughtUp,
+			expectedStatus: Healthy,
+			expectedReason: ReasonNone,
+		},
+		{
+			name: "data-node lags behind core before the catch-up",
+			steps: concatSteps(
+				[]watchdogStep{visorStartedStep()},
+				repeatSteps(3, probeStep(2000, 1998, 1500), 5, 5),
+			),
+			expectedState:  NodeCatchingUp,
+			expectedStatus: Unhealthy,
+			expectedReason: ReasonNeverCaughtUp,
+		},
+		{
+			name: "stalled after the catch-up",
+			steps: concatSteps(
+				caughtUp,
+				// Height does not increase for more than the stall window, so the node lags before it stalls
+				repeatSteps(13, probeStep(2025, 2023, 2023), 5, 0),
+			),
+			expectedState:       NodeStalled,
+			expectedStatus:      Unhealthy,
+			expectedReason:      ReasonStalled,
+			expectedLagEpisodes: 1,
+		},
+		{
+			name: "stalled when the node stopped responding",
+			steps: concatSteps(
+				caughtUp,
+				repeatSteps(13, nodeDownStep(2025), 5, 0),
+			),
+			expectedState:  NodeStalled,
+			expectedStatus: Unhealthy,
+			expectedReason: ReasonStalled,
+		},
+		{
+			name: "network halted",
+			steps: concatSteps(
+				catchingUp,
+				// Neither the network nor the node produce blocks
+				repeatSteps(13, probeStep(1025, 500, 500), 0, 0),
+			),
+			expectedState:  NodeStalled,
+			expectedStatus: NetworkHalted,
+			expectedReason: ReasonNetworkHalted,
+		},
+		{
+			name: "node stalled during the network halt did not recover",
+			steps: concatSteps(
+				catchingUp,
+				repeatSteps(13, probeStep(1025, 500, 500), 0, 0),
+				// Network resumed, but the node is still stuck
+				repeatSteps(3, probeStep(1030, 500, 500), 5, 0),
+			),
+			expectedState:  NodeStalled,
+			expectedStatus: NetworkHalted,
+			expectedReason: ReasonNetworkHalted,
+		},
+		{
+			name: "node stalled after the network recovered from the halt",
+			steps: concatSteps(
+				caughtUp,
+				// Neither the network nor the node produce blocks
+				repeatSteps(13, probeStep(2025, 2023, 2023), 0, 0),
+				// Network and the node recovered
+				repeatSteps(3, probeStep(2030, 2028, 2028), 5, 5),
+				// Only the local node stalled
+				repeatSteps(13, probeStep(2045, 2043, 2043), 5, 0),
+			),
+			expectedState:       NodeStalled,
+			expectedStatus:      Unhealthy,
+			expectedReason:      ReasonStalled,
+			expectedLagEpisodes: 1,
+		},
+		{
+			name: "crashed",
+			steps: concatSteps(
+				[]watchdogStep{visorStartedStep()},
+				caughtUp,
+				[]watchdogStep{visorExitedStep(VisorExitPanic)},
+				// Node does not recover from the crash even when the probes look healthy
+				caughtUp,
+			),
+			expectedState:  NodeCrashed,
+			expectedStatus: Unhealthy,
+			expectedReason: ReasonCrashed,
+		},
+		{
+			name: "stopped by the test is not a crash",
+			steps: concatSteps(
+				caughtUp,
+				[]watchdogStep{visorExitedStep(VisorExitStoppedByTest)},
+			),
+			expectedState:  NodeCaughtUp,
+			expectedStatus: Healthy,
+			expectedReason: ReasonNone,
+		},
+		{
+			name:                "lagging after the catch-up",
+			steps:               concatSteps(catchingUp, caughtUp, lagging),
+			expectedState:       NodeLagging,
+			expectedStatus:      MaybeHealthy,
+			expectedReason:      ReasonLagging,
+			expectedLagEpisodes: 1,
+		},
+		{
+			name:                "recovered from the lag episodes",
+			steps:               concatSteps(caughtUp, lagging, healthyAgain, lagging, healthyAgain),
+			expectedState:       NodeCaughtUp,
+			expectedStatus:      Healthy,
+			expectedReason:      ReasonNone,
+			expectedLagEpisodes: 2,
+		},
+		{
+			name: "vega time lag after the catch-up",
+			steps: concatSteps(
+				caughtUp,
+				[]watchdogStep{{network: 2030, core: 2030, dataNode: 2030, vegaTimeLag: 10 * time.Minute}},
+			),
+			expectedState:       NodeLagging,
+			expectedStatus:      Unhealthy,
+			expectedReason:      ReasonVegaTimeLag,
+			expectedLagEpisodes: 1,
+		},
+		{
+			name:         "catch-up too slow for the test duration",
+			testDuration: 10 * time.Minute,
+			// Node replays as fast as the network produces blocks, so it never catches up
+			steps:          repeatSteps(40, probeStep(1000, 100, 100), 5, 5),
+			expectedState:  NodeCatchingUp,
+			expectedStatus: Unhealthy,
+			expectedReason: ReasonCatchUpTooSlow,
+		},
+		{
+			name: "catch-up too slow for the catch-up timeout",
+			conf: func(conf *config.Watchdog) {
+				conf.CatchUpTimeout = 5 * time.Minute
+			},
+			// Node closes 10 blocks per probe, it needs 7.5m to catch the 900 blocks up
+			steps:          repeatSteps(40, probeStep(1000, 100, 100), 5, 15),
+			expectedState:  NodeCatchingUp,
+			expectedStatus: Unhealthy,
+			expectedReason: ReasonCatchUpTooSlow,
+		},
+		{
+			name:         "single slow window does not fail the catch-up",
+			testDuration: 10 * time.Minute,
+			steps: concatSteps(
+				// The first window is too slow, then the node replays 100 blocks per probe
+				repeatSteps(14, probeStep(1000, 100, 100), 5, 5),
+				repeatSteps(10, probeStep(1070, 170, 170), 5, 105),
+			),
+			expectedState:  NodeCaughtUp,
+			expectedStatus: Healthy,
+			expectedReason: ReasonNone,
+		},
+		{
+			name:         "catch-up fast enough",
+			testDuration: time.Hour,
+			// Node closes 10 blocks per probe, it needs 7.5m to catch the 900 blocks up
+			steps:          repeatSteps(20, probeStep(1000, 100, 100), 5, 15),
+			expectedState:  NodeCatchingUp,
+			expectedStatus: Unhealthy,
+			expectedReason: ReasonNeverCaughtUp,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			conf := testWatchdogConfig()
+			if tc.conf != nil {
+				tc.conf(&conf)
+			}
+
+			w := replayWatchdog(t, conf, tc.testDuration, tc.steps)
+			status := w.statusSnapshot()
+
+			if status.state != tc.expectedState {
+				t.Errorf("expected state %s, got %s", tc.expectedState, status.state)
+			}
+
+			healthyStatus, reason, message := status.verdict()
+			if healthyStatus != tc.expectedStatus || reason != tc.expectedReason {
+				t.Errorf("expected %s(%s), got %s(%s): %s", tc.expectedStatus, tc.expectedReason, healthyStatus, reason, message)
+			}
+
+			if status.lagEpisodes != tc.expectedLagEpisodes {
+				t.Errorf("expected %d lag episodes, got %d", tc.expectedLagEpisodes, status.lagEpisodes)
+			}
+		})
+	}
+}
+
+func TestWatchdogLaggingTime(t *testing.T) {
+	steps := concatSteps(
+		repeatSteps(5, probeStep(2000, 1998, 1998), 5, 5),
+		// Two lag episodes, 3 probes each
+		repeatSteps(3, probeStep(2100, 2050, 2050), 5, 5),
+		repeatSteps(2, probeStep(2200, 2200, 2200), 5, 5),
+		repeatSteps(3, probeStep(2300, 2250, 2250), 5, 5),
+		repeatSteps(2, probeStep(2400, 2400, 2400), 5, 5),
+	)
+
+	w := replayWatchdog(t, testWatchdogConfig(), 0, steps)
+	status := w.statusSnapshot()
+
+	// Each episode lasts from the first lagging probe to the first healthy probe
+	if expected := 6 * testProbeInterval; status.laggingDuration != expected {
+		t.Errorf("expected lagging duration %s, got %s", expected, status.laggingDuration)
+	}
+}
+
+func TestWatchdogEventTimesFollowProbes(t *testing.T) {
+	steps := concatSteps(
+		[]watchdogStep{visorStartedStep()},
+		repeatSteps(3, probeStep(2000, 1900, 1900), 5, 10),
+		repeatSteps(2, probeStep(2015, 2015, 2015), 5, 5),
+	)
+
+	w := replayWatchdog(t, testWatchdogConfig(), 0, steps)
+	events := w.statusSnapshot().events.toResults()
+
+	started := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	expected := map[EventType][2]time.Time{
+		EventNodeStarting: {started.Add(testProbeInterval), started.Add(testProbeInterval)},
+		EventCoreLag:      {started.Add(2 * testProbeInterval), started.Add(4 * testProbeInterval)},
+		EventCaughtUp:     {started.Add(5 * testProbeInterval), started.Add(5 * testProbeInterval)},
+	}
+	for _, event := range events {
+		times, ok := expected[event.Type]
+		if !ok {
+			continue
+		}
+		delete(expected, event.Type)
+		if !event.Time.Equal(times[0]) || !event.LastTime.Equal(times[1]) {
+			t.Errorf("expected the %s event from %s to %s, got %s to %s", event.Type, times[0], times[1], event.Time, event.LastTime)
+		}
+	}
+	for eventType := range expected {
+		t.Errorf("expected the %s event, got %+v", eventType, events)
+	}
+}
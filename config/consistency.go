@@ -0,0 +1,51 @@
+package config
+
+import "time"
+
+// Consistency describes how the local chain state is compared with the rest of the network.
+type Consistency struct {
+	// Tendermint RPC of the local node
+	LocalRPCURL string `toml:"local_rpc_url"`
+	// Data-node REST API of the local node
+	LocalRESTURL string `toml:"local_rest_url"`
+
+	Interval time.Duration `toml:"interval"`
+	// Max number of healthy RPC peers the local block and app hashes are compared with
+	Peers int `toml:"peers"`
+	// Data-node REST resources compared with the remote data-node. Local node must not return any
+	// item missing on the remote node
+	DataNodeResources []string `toml:"data_node_resources"`
+}
+
+var DefaultConsistency = Consistency{
+	LocalRPCURL:  "http://localhost:26657",
+	LocalRESTURL: "http://localhost:3008",
+	Interval:     5 * time.Minute,
+	Peers:        3,
+	DataNodeResources: []string{
+		"/api/v2/markets",
+		"/api/v2/assets",
+		"/api/v2/network/parameters",
+	},
+}
+
+// Merge returns copy of the config where empty values are replaced with values from the other config.
+func (c Consistency) Merge(other Consistency) Consistency {
+	if c.LocalRPCURL == "" {
+		c.LocalRPCURL = other.LocalRPCURL
+	}
+	if c.LocalRESTURL == "" {
+		c.LocalRESTURL = other.LocalRESTURL
+	}
+	if c.Interval == 0 {
+		c.Interval = other.Interval
+	}
+	if c.Peers == 0 {
+		c.Peers = other.Peers
+	}
+	if len(c.DataNodeResources) == 0 {
+		c.DataNodeResources = other.DataNodeResources
+	}
+
+	return c
+}
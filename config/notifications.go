@@ -0,0 +1,110 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+type NotificationEvent string
+
+const (
+	// NotificationRunFailed is sent when the test finished with other status than HEALTHY, unless the failure
+	// should be skipped, e.g. because the whole network halted
+	NotificationRunFailed NotificationEvent = "run_failed"
+	// NotificationRunFinished is sent when the test finished with any status
+	NotificationRunFinished NotificationEvent = "run_finished"
+	// NotificationStateChanged is sent when the watchdog moves the local node to the new state
+	NotificationStateChanged NotificationEvent = "state_changed"
+)
+
+var notificationEvents = []NotificationEvent{NotificationRunFailed, NotificationRunFinished, NotificationStateChanged}
+
+// Notifications describes where the test outcome is sent.
+type Notifications struct {
+	Webhooks []Webhook `toml:"webhooks"`
+}
+
+// Webhook sends notifications as HTTP POST requests.
+type Webhook struct {
+	// Name is used in logs only, the URL is used when empty
+	Name    string            `toml:"name"`
+	URL     string            `toml:"url"`
+	Headers map[string]string `toml:"headers"`
+	// Go template of the request body executed with the notification. The notification is sent as JSON when empty
+	Body string `toml:"body"`
+
+	Events []NotificationEvent `toml:"events"`
+	// Node states sent with the state_changed event, all states when empty, e.g. ["CAUGHT_UP", "CRASHED"]
+	States []string `toml:"states"`
+
+	Timeout time.Duration `toml:"timeout"`
+	// Number of retries after the failed request. Zero disables retries, so nil means not set
+	Retries       *int          `toml:"retries"`
+	RetryInterval time.Duration `toml:"retry_interval"`
+}
+
+var DefaultWebhook = Webhook{
+	Headers:       map[string]string{"Content-Type": "application/json"},
+	Events:        []NotificationEvent{NotificationRunFailed},
+	Timeout:       10 * time.Second,
+	Retries:       valuePointer(3),
+	RetryInterval: 5 * time.Second,
+}
+
+// Merge returns copy of the config where empty values are replaced with values from the other config.
+// Headers are merged, headers of this config take precedence.
+func (w Webhook) Merge(other Webhook) Webhook {
+	if w.Name == "" {
+		w.Name = other.Name
+	}
+	if w.URL == "" {
+		w.URL = other.URL
+	}
+	headers := map[string]string{}
+	for name, value := range other.Headers {
+		headers[name] = value
+	}
+	for name, value := range w.Headers {
+		headers[name] = value
+	}
+	w.Headers = headers
+	if w.Body == "" {
+		w.Body = other.Body
+	}
+	if len(w.Events) == 0 {
+		w.Events = other.Events
+	}
+	if len(w.States) == 0 {
+		w.States = other.States
+	}
+	if w.Timeout == 0 {
+		w.Timeout = other.Timeout
+	}
+	if w.Retries == nil {
+		w.Retries = other.Retries
+	}
+	if w.RetryInterval == 0 {
+		w.RetryInterval = other.RetryInterval
+	}
+
+	return w
+}
+
+func (w Webhook) Validate() error {
+	if len(w.URL) == 0 {
+		return fmt.Errorf("empty url for the webhook")
+	}
+
+	if w.Retries != nil && *w.Retries < 0 {
+		return fmt.Errorf("retries cannot be negative for the %s webhook, got %d", w.URL, *w.Retries)
+	}
+
+	for _, event := range w.Events {
+		if !slices.Contains(notificationEvents, event) {
+			return fmt.Errorf("unknown event %q for the %s webhook, available events are: %v", event, w.URL, notificationEvents)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,178 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml"
+	"gopkg.in/yaml.v3"
+)
+
+type PhaseType string
+
+const (
+	// PhasePrepare downloads binaries and initializes the local node from the remote snapshot
+	PhasePrepare PhaseType = "prepare"
+	// PhaseRun starts given components and runs them for the phase duration. Components are
+	// stopped at the end of the phase.
+	PhaseRun PhaseType = "run"
+	// PhaseRestartFromLocalSnapshot configures the local node to start from the latest snapshot
+	// it produced in the previous run phases
+	PhaseRestartFromLocalSnapshot PhaseType = "restart-from-local-snapshot"
+	// PhaseAssert checks results of the previous phases
+	PhaseAssert PhaseType = "assert"
+)
+
+type Scenario struct {
+	Name   string          `toml:"name"`
+	Phases []ScenarioPhase `toml:"phases"`
+}
+
+type ScenarioPhase struct {
+	Name string    `toml:"name"`
+	Type PhaseType `toml:"type"`
+
+	// Used by the run phase only
+	Duration time.Duration `toml:"duration"`
+	// Names of the components used by the run phase. All of them are used when the list is empty
+	Components []string `toml:"components"`
+	// Options of the components used by the run phase
+	Options ComponentOptions `toml:"options"`
+
+	// Used by the assert phase only
+	Assertions []ScenarioAssertion `toml:"assertions"`
+}
+
+// ComponentOptions override the options of the built-in components for the single run phase. Empty
+// values are taken from the flags and the network config.
+type ComponentOptions struct {
+	Postgresql  PostgresqlOptions `toml:"postgresql"`
+	Vegavisor   VegavisorOptions  `toml:"vegavisor"`
+	Watchdog    Watchdog          `toml:"watchdog"`
+	Consistency Consistency       `toml:"consistency"`
+	SmokeTests  SmokeTests        `toml:"api-smoke-tests"`
+}
+
+type PostgresqlOptions struct {
+	// Keep the database of the previous run phase, so the data-node can continue from the local snapshot. By
+	// default the database is kept by all run phases except the first one after the prepare phase
+	KeepDatabase *bool `toml:"keep_database"`
+}
+
+type VegavisorOptions struct {
+	// Time given to the vegavisor to exit after SIGTERM before it is killed
+	StopTimeout time.Duration `toml:"stop_timeout"`
+}
+
+// ScenarioAssertion checks the value produced by the given phase. The key is a dot separated path in the phase results.
+type ScenarioAssertion struct {
+	Phase  string `toml:"phase"`
+	Key    string `toml:"key"`
+	Equals string `toml:"equals"`
+}
+
+func LoadScenario(path string) (*Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scenario file: %w", err)
+	}
+
+	scenario := &Scenario{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		if err := unmarshalYAML(data, scenario); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal scenario: %w", err)
+		}
+	default:
+		if err := toml.Unmarshal(data, scenario); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal scenario: %w", err)
+		}
+	}
+
+	if err := scenario.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid scenario: %w", err)
+	}
+
+	return scenario, nil
+}
+
+// unmarshalYAML decodes the YAML document with the same keys as the TOML one. The document is converted
+// to the TOML tree, so We do not need the yaml tags next to the toml ones in every config structure.
+func unmarshalYAML(data []byte, v interface{}) error {
+	document := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return fmt.Errorf("failed to parse yaml: %w", err)
+	}
+
+	tree, err := toml.TreeFromMap(document)
+	if err != nil {
+		return fmt.Errorf("failed to convert yaml: %w", err)
+	}
+
+	return tree.Unmarshal(v)
+}
+
+func (s Scenario) Validate() error {
+	if len(s.Phases) == 0 {
+		return fmt.Errorf("no phases")
+	}
+
+	phaseNames := map[string]struct{}{}
+	for idx, phase := range s.Phases {
+		if len(phase.Name) == 0 {
+			return fmt.Errorf("empty name for phase %d", idx+1)
+		}
+
+		if _, exists := phaseNames[phase.Name]; exists {
+			return fmt.Errorf("duplicated phase name: %s", phase.Name)
+		}
+
+		switch phase.Type {
+		case PhasePrepare, PhaseRestartFromLocalSnapshot:
+		case PhaseRun:
+			if phase.Duration <= 0 {
+				return fmt.Errorf("missing duration for the %s phase", phase.Name)
+			}
+
+			if err := phase.Options.Validate(); err != nil {
+				return fmt.Errorf("invalid options of the %s phase: %w", phase.Name, err)
+			}
+		case PhaseAssert:
+			if len(phase.Assertions) == 0 {
+				return fmt.Errorf("no assertions for the %s phase", phase.Name)
+			}
+
+			for _, assertion := range phase.Assertions {
+				// Assertions can only refer to the phases executed before
+				if _, exists := phaseNames[assertion.Phase]; !exists {
+					return fmt.Errorf("the %s phase asserts results of unknown or later phase: %s", phase.Name, assertion.Phase)
+				}
+			}
+		default:
+			return fmt.Errorf("unknown type of the %s phase: %s", phase.Name, phase.Type)
+		}
+
+		phaseNames[phase.Name] = struct{}{}
+	}
+
+	return nil
+}
+
+func (co ComponentOptions) Validate() error {
+	if co.Vegavisor.StopTimeout < 0 {
+		return fmt.Errorf("negative vegavisor stop_timeout")
+	}
+
+	if err := co.Watchdog.Merge(DefaultWatchdog).Validate(); err != nil {
+		return fmt.Errorf("invalid watchdog options: %w", err)
+	}
+
+	if err := co.SmokeTests.Validate(); err != nil {
+		return fmt.Errorf("invalid api-smoke-tests options: %w", err)
+	}
+
+	return nil
+}
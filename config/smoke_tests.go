@@ -0,0 +1,78 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// SmokeTests describes the data-node REST queries executed once the local node caught the network up.
+type SmokeTests struct {
+	// Data-node REST API of the local node
+	LocalRESTURL string `toml:"local_rest_url"`
+	// How often the local node is checked if it caught the network up
+	Interval time.Duration `toml:"interval"`
+	// Max number of blocks the local core can be behind the network, and the local data-node behind
+	// the local core, to consider the node caught up
+	MaxLag uint64 `toml:"max_lag"`
+
+	Queries []SmokeTestQuery `toml:"queries"`
+}
+
+// SmokeTestQuery is a single REST query sent to the local and the remote data-node. The query passes
+// when both nodes return the same status code and the same values of the selected fields.
+type SmokeTestQuery struct {
+	Name string `toml:"name"`
+	Path string `toml:"path"`
+	// Dot separated paths of the JSON fields, e.g. "networkParameters.edges.0.node.key"
+	Fields []string `toml:"fields"`
+}
+
+var DefaultSmokeTests = SmokeTests{
+	LocalRESTURL: "http://localhost:3008",
+	Interval:     10 * time.Second,
+	MaxLag:       500,
+	Queries: []SmokeTestQuery{
+		{Name: "markets", Path: "/api/v2/markets"},
+		{Name: "assets", Path: "/api/v2/assets"},
+		{Name: "network-parameters", Path: "/api/v2/network/parameters"},
+		{Name: "snapshots", Path: "/api/v2/snapshots"},
+	},
+}
+
+// Merge returns copy of the config where empty values are replaced with values from the other config.
+func (st SmokeTests) Merge(other SmokeTests) SmokeTests {
+	if st.LocalRESTURL == "" {
+		st.LocalRESTURL = other.LocalRESTURL
+	}
+	if st.Interval == 0 {
+		st.Interval = other.Interval
+	}
+	if st.MaxLag == 0 {
+		st.MaxLag = other.MaxLag
+	}
+	if len(st.Queries) == 0 {
+		st.Queries = other.Queries
+	}
+
+	return st
+}
+
+func (st SmokeTests) Validate() error {
+	names := map[string]struct{}{}
+	for idx, query := range st.Queries {
+		if len(query.Name) == 0 {
+			return fmt.Errorf("empty name for smoke test query %d", idx+1)
+		}
+
+		if len(query.Path) == 0 {
+			return fmt.Errorf("empty path for the %s smoke test query", query.Name)
+		}
+
+		if _, exists := names[query.Name]; exists {
+			return fmt.Errorf("duplicated smoke test query name: %s", query.Name)
+		}
+		names[query.Name] = struct{}{}
+	}
+
+	return nil
+}
@@ -1,6 +1,12 @@
 package config
 
-import "fmt"
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"time"
+)
 
 type PostgreSQLCreds struct {
 	Host   string
@@ -34,6 +40,92 @@ type Network struct {
 	RPCPeers       []EndpointWithREST `toml:"rpc_peers"`
 	Seeds          []string           `toml:"seeds"`
 	BootstrapPeers []EndpointWithREST `toml:"bootstrap_peers"`
+
+	// Extra processes started next to the local node for the time of the test
+	ExecComponents []ExecComponent `toml:"exec_components"`
+
+	// Empty values are replaced with the DefaultWatchdog values
+	Watchdog Watchdog `toml:"watchdog"`
+
+	// Empty values are replaced with the DefaultConsistency values
+	Consistency Consistency `toml:"consistency"`
+
+	// Empty values are replaced with the DefaultSmokeTests values
+	SmokeTests SmokeTests `toml:"smoke_tests"`
+
+	// Webhooks notified about the test outcome
+	Notifications Notifications `toml:"notifications"`
+}
+
+// Names of the built-in components. Exec components cannot use them, so the names stay unique in
+// the results, the status and the metrics.
+const (
+	ComponentNamePostgresql  = "postgresql"
+	ComponentNameVisor       = "vegavisor"
+	ComponentNameWatchdog    = "watchdog"
+	ComponentNameConsistency = "consistency"
+	ComponentNameSmokeTests  = "api-smoke-tests"
+)
+
+var BuiltInComponentNames = []string{
+	ComponentNamePostgresql,
+	ComponentNameVisor,
+	ComponentNameWatchdog,
+	ComponentNameConsistency,
+	ComponentNameSmokeTests,
+}
+
+// ExecComponent describes an external command started as a test component, e.g. a trading bot
+// or a load generator.
+type ExecComponent struct {
+	Name    string            `toml:"name"`
+	Command string            `toml:"command"`
+	Args    []string          `toml:"args"`
+	Env     map[string]string `toml:"env"`
+	WorkDir string            `toml:"work_dir"`
+
+	// The command is started after this delay, e.g. to give the local node time to start
+	StartDelay time.Duration `toml:"start_delay"`
+	// Time given to the command to exit after SIGTERM before it is killed
+	StopTimeout time.Duration `toml:"stop_timeout"`
+
+	// The component is healthy when the health command returns 0 or the health URL responds with 2xx.
+	// When none of them is set, the component is healthy as long as the command is running.
+	HealthCommand []string `toml:"health_command"`
+	HealthURL     string   `toml:"health_url"`
+
+	// Log files are relative to the logs directory, by default <name>-stdout.log and <name>-stderr.log
+	StdoutLogFile string `toml:"stdout_log_file"`
+	StderrLogFile string `toml:"stderr_log_file"`
+}
+
+// execComponentNamePattern allows only names that are safe to use in the log file names
+var execComponentNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+func (ec ExecComponent) Validate() error {
+	if len(ec.Name) == 0 {
+		return fmt.Errorf("empty name")
+	}
+
+	if !execComponentNamePattern.MatchString(ec.Name) {
+		return fmt.Errorf("invalid name %q: only letters, digits, '_', '.' and '-' are allowed, the first character must be a letter or digit", ec.Name)
+	}
+
+	for _, logFile := range []string{ec.StdoutLogFile, ec.StderrLogFile} {
+		if logFile != "" && !filepath.IsLocal(logFile) {
+			return fmt.Errorf("log file %s of the %s component must be relative to the logs directory", logFile, ec.Name)
+		}
+	}
+
+	if len(ec.Command) == 0 {
+		return fmt.Errorf("empty command for the %s component", ec.Name)
+	}
+
+	if len(ec.HealthCommand) > 0 && len(ec.HealthURL) > 0 {
+		return fmt.Errorf("only one of health_command and health_url can be set for the %s component", ec.Name)
+	}
+
+	return nil
 }
 
 func (n Network) Validate() error {
@@ -61,5 +153,35 @@ func (n Network) Validate() error {
 		return fmt.Errorf("empty artifacts repository")
 	}
 
+	componentNames := map[string]struct{}{}
+	for _, execComponent := range n.ExecComponents {
+		if err := execComponent.Validate(); err != nil {
+			return fmt.Errorf("invalid exec component: %w", err)
+		}
+
+		if slices.Contains(BuiltInComponentNames, execComponent.Name) {
+			return fmt.Errorf("exec component name %s is reserved for the built-in component", execComponent.Name)
+		}
+
+		if _, exists := componentNames[execComponent.Name]; exists {
+			return fmt.Errorf("duplicated exec component name: %s", execComponent.Name)
+		}
+		componentNames[execComponent.Name] = struct{}{}
+	}
+
+	if err := n.Watchdog.Merge(DefaultWatchdog).Validate(); err != nil {
+		return fmt.Errorf("invalid watchdog: %w", err)
+	}
+
+	if err := n.SmokeTests.Validate(); err != nil {
+		return fmt.Errorf("invalid smoke tests: %w", err)
+	}
+
+	for _, webhook := range n.Notifications.Webhooks {
+		if err := webhook.Validate(); err != nil {
+			return fmt.Errorf("invalid notifications: %w", err)
+		}
+	}
+
 	return nil
 }
@@ -0,0 +1,39 @@
+package config
+
+import "testing"
+
+func TestExecComponentValidate(t *testing.T) {
+	testCases := []struct {
+		name        string
+		component   ExecComponent
+		expectedErr bool
+	}{
+		{name: "valid", component: ExecComponent{Name: "trading-bot_1.2", Command: "bot"}},
+		{name: "empty name", component: ExecComponent{Command: "bot"}, expectedErr: true},
+		{name: "empty command", component: ExecComponent{Name: "bot"}, expectedErr: true},
+		{name: "name with slash", component: ExecComponent{Name: "../bot", Command: "bot"}, expectedErr: true},
+		{name: "parent directory name", component: ExecComponent{Name: "..", Command: "bot"}, expectedErr: true},
+		{name: "hidden name", component: ExecComponent{Name: ".bot", Command: "bot"}, expectedErr: true},
+		{name: "name with space", component: ExecComponent{Name: "trading bot", Command: "bot"}, expectedErr: true},
+		{name: "log file in sub directory", component: ExecComponent{Name: "bot", Command: "bot", StdoutLogFile: "bot/stdout.log"}},
+		{name: "absolute log file", component: ExecComponent{Name: "bot", Command: "bot", StdoutLogFile: "/tmp/stdout.log"}, expectedErr: true},
+		{name: "log file outside logs", component: ExecComponent{Name: "bot", Command: "bot", StderrLogFile: "../stderr.log"}, expectedErr: true},
+		{
+			name:        "health command and url",
+			component:   ExecComponent{Name: "bot", Command: "bot", HealthCommand: []string{"true"}, HealthURL: "http://localhost"},
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.component.Validate()
+			if tc.expectedErr && err == nil {
+				t.Error("expected error")
+			}
+			if !tc.expectedErr && err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+		})
+	}
+}
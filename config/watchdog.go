@@ -0,0 +1,158 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// Watchdog describes how the watchdog probes the local node and when it considers the node unhealthy.
+type Watchdog struct {
+	// Data-node REST API of the local node
+	LocalRESTURL string `toml:"local_rest_url"`
+	// Optional core REST API of the local node. When set, the core height is taken from it, not from the data-node
+	LocalCoreURL string `toml:"local_core_url"`
+
+	Interval time.Duration `toml:"interval"`
+	// Max number of blocks the local core can be behind the network. Zero is a valid value, so nil means not set
+	CoreLag *uint64 `toml:"core_lag"`
+	// Max number of blocks the local data-node can be behind the local core. Zero is a valid value, so nil means not set
+	DataNodeLag *uint64 `toml:"data_node_lag"`
+	// The node is considered stalled when its height did not increase for this time
+	StallWindow time.Duration `toml:"stall_window"`
+	// Max time the local node vega time can be behind the network vega time, when its height is up to date
+	VegaTimeLag time.Duration `toml:"vega_time_lag"`
+	// Replay speed of the local node is measured over this moving window
+	SpeedWindow time.Duration `toml:"speed_window"`
+	// Max time for the local node to catch the network up, the test duration is used when empty. The test
+	// fails early when the node is not going to catch up in this time with its current replay speed
+	CatchUpTimeout time.Duration `toml:"catch_up_timeout"`
+	// Max number of events reported in the results. Consecutive events of the same type count as one
+	EventsLimit int `toml:"events_limit"`
+
+	PassCriteria PassCriteria `toml:"pass_criteria"`
+}
+
+// PassCriteria are checked by the watchdog at the end of the test. Criteria with zero or nil value are not checked.
+type PassCriteria struct {
+	// Min time the node must be continuously healthy at the end of the test
+	MinHealthyDuration time.Duration `toml:"min_healthy_duration"`
+	// Max time from the start of the test to the catch-up
+	MaxCatchUpDuration time.Duration `toml:"max_catch_up_duration"`
+	// Max number of times the node started lagging after the catch-up. Zero is a valid value, so nil means not checked
+	MaxLagEpisodes *int `toml:"max_lag_episodes"`
+	// Max total time the node was lagging after the catch-up
+	MaxLaggingTime time.Duration `toml:"max_lagging_time"`
+}
+
+// Merge returns copy of the criteria where empty values are replaced with values from the other criteria.
+func (pc PassCriteria) Merge(other PassCriteria) PassCriteria {
+	if pc.MinHealthyDuration == 0 {
+		pc.MinHealthyDuration = other.MinHealthyDuration
+	}
+	if pc.MaxCatchUpDuration == 0 {
+		pc.MaxCatchUpDuration = other.MaxCatchUpDuration
+	}
+	if pc.MaxLagEpisodes == nil {
+		pc.MaxLagEpisodes = other.MaxLagEpisodes
+	}
+	if pc.MaxLaggingTime == 0 {
+		pc.MaxLaggingTime = other.MaxLaggingTime
+	}
+
+	return pc
+}
+
+var DefaultWatchdog = Watchdog{
+	LocalRESTURL: "http://localhost:3008",
+	LocalCoreURL: "",
+	Interval:     5 * time.Second,
+	CoreLag:      valuePointer[uint64](500),
+	DataNodeLag:  valuePointer[uint64](500),
+	StallWindow:  60 * time.Second,
+	VegaTimeLag:  5 * time.Minute,
+	SpeedWindow:  5 * time.Minute,
+	EventsLimit:  1000,
+}
+
+// Merge returns copy of the config where empty values are replaced with values from the other config.
+func (w Watchdog) Merge(other Watchdog) Watchdog {
+	if w.LocalRESTURL == "" {
+		w.LocalRESTURL = other.LocalRESTURL
+	}
+	if w.LocalCoreURL == "" {
+		w.LocalCoreURL = other.LocalCoreURL
+	}
+	if w.Interval == 0 {
+		w.Interval = other.Interval
+	}
+	if w.CoreLag == nil {
+		w.CoreLag = other.CoreLag
+	}
+	if w.DataNodeLag == nil {
+		w.DataNodeLag = other.DataNodeLag
+	}
+	if w.StallWindow == 0 {
+		w.StallWindow = other.StallWindow
+	}
+	if w.VegaTimeLag == 0 {
+		w.VegaTimeLag = other.VegaTimeLag
+	}
+	if w.SpeedWindow == 0 {
+		w.SpeedWindow = other.SpeedWindow
+	}
+	if w.CatchUpTimeout == 0 {
+		w.CatchUpTimeout = other.CatchUpTimeout
+	}
+	if w.EventsLimit == 0 {
+		w.EventsLimit = other.EventsLimit
+	}
+	w.PassCriteria = w.PassCriteria.Merge(other.PassCriteria)
+
+	return w
+}
+
+// Validate checks the config merged with the DefaultWatchdog, where only invalid values can be non-positive.
+func (w Watchdog) Validate() error {
+	if w.LocalRESTURL == "" {
+		return fmt.Errorf("empty local_rest_url")
+	}
+	if w.CoreLag == nil || w.DataNodeLag == nil {
+		return fmt.Errorf("core_lag and data_node_lag must be set")
+	}
+
+	durations := []struct {
+		name  string
+		value time.Duration
+	}{
+		{"interval", w.Interval},
+		{"stall_window", w.StallWindow},
+		{"vega_time_lag", w.VegaTimeLag},
+		{"speed_window", w.SpeedWindow},
+	}
+	for _, duration := range durations {
+		if duration.value <= 0 {
+			return fmt.Errorf("%s must be positive, got %s", duration.name, duration.value)
+		}
+	}
+
+	if w.CatchUpTimeout < 0 {
+		return fmt.Errorf("catch_up_timeout cannot be negative, got %s", w.CatchUpTimeout)
+	}
+	if w.EventsLimit < 0 {
+		return fmt.Errorf("events_limit cannot be negative, got %d", w.EventsLimit)
+	}
+
+	criteria := w.PassCriteria
+	if criteria.MinHealthyDuration < 0 || criteria.MaxCatchUpDuration < 0 || criteria.MaxLaggingTime < 0 {
+		return fmt.Errorf("durations of the pass_criteria cannot be negative")
+	}
+	if criteria.MaxLagEpisodes != nil && *criteria.MaxLagEpisodes < 0 {
+		return fmt.Errorf("max_lag_episodes of the pass_criteria cannot be negative, got %d", *criteria.MaxLagEpisodes)
+	}
+
+	return nil
+}
+
+func valuePointer[T any](value T) *T {
+	return &value
+}
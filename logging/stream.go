@@ -34,6 +34,13 @@ func (ei *ExtraInfo) String(lengthLimit int) string {
 	return fmt.Sprintf("%s ...", result[:lengthLimit])
 }
 
+func (ei *ExtraInfo) Lines() []string {
+	ei.mut.Lock()
+	defer ei.mut.Unlock()
+
+	return append([]string{}, ei.logLines...)
+}
+
 func (ei *ExtraInfo) Empty() bool {
 	ei.mut.Lock()
 	defer ei.mut.Unlock()
@@ -45,7 +52,7 @@ func NewExtraInfo() ExtraInfo {
 	return ExtraInfo{}
 }
 
-func StreamLogs(source io.Reader, out *zap.Logger, extraResults *ExtraInfo) error {
+func StreamLogs(source io.Reader, out *zap.Logger, extraResults *ExtraInfo, tail *LogTail) error {
 	if source == nil {
 		return fmt.Errorf("source stream is nil")
 	}
@@ -70,6 +77,10 @@ func StreamLogs(source io.Reader, out *zap.Logger, extraResults *ExtraInfo) erro
 			}
 		}
 
+		if tail != nil {
+			tail.push(text)
+		}
+
 		out.Info(text)
 	}
 
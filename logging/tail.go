@@ -0,0 +1,40 @@
+package logging
+
+import (
+	"strings"
+	"sync"
+)
+
+// LogTail keeps the last N lines written to the stream.
+type LogTail struct {
+	mut      sync.Mutex
+	limit    int
+	logLines []string
+}
+
+func NewLogTail(limit int) *LogTail {
+	return &LogTail{
+		limit: limit,
+	}
+}
+
+func (lt *LogTail) push(line string) {
+	lt.mut.Lock()
+	defer lt.mut.Unlock()
+
+	lt.logLines = append(lt.logLines, line)
+	if len(lt.logLines) > lt.limit {
+		lt.logLines = lt.logLines[len(lt.logLines)-lt.limit:]
+	}
+}
+
+func (lt *LogTail) Lines() []string {
+	lt.mut.Lock()
+	defer lt.mut.Unlock()
+
+	return append([]string{}, lt.logLines...)
+}
+
+func (lt *LogTail) String() string {
+	return strings.Join(lt.Lines(), "\n")
+}
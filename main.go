@@ -1,7 +1,14 @@
 package main
 
-import "github.com/vegaprotocol/snapshot-testing/cmd"
+import (
+	"os"
+
+	"github.com/vegaprotocol/snapshot-testing/cmd"
+)
 
 func main() {
-	cmd.Execute()
+	// Cobra already printed the error, e.g. of unknown flags
+	if err := cmd.Execute(); err != nil {
+		os.Exit(int(cmd.ExitInternalError))
+	}
 }
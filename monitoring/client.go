@@ -0,0 +1,105 @@
+package monitoring
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/vegaprotocol/snapshot-testing/components"
+)
+
+// Client reads the status of the run from the status server.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewClient creates the client for the status server address, e.g. localhost:2113 or http://host:2113.
+func NewClient(addr string) *Client {
+	baseURL := strings.TrimSuffix(addr, "/")
+	if !strings.HasPrefix(baseURL, "http://") && !strings.HasPrefix(baseURL, "https://") {
+		baseURL = "http://" + baseURL
+	}
+
+	return &Client{
+		baseURL: baseURL,
+		// Timeout is not set because the event stream is open until the run finishes
+		httpClient: &http.Client{},
+	}
+}
+
+// Status returns the current status of the run.
+func (c *Client) Status(ctx context.Context) (*Status, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+StatusPath, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create status request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get status: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get status: unexpected status code %d", resp.StatusCode)
+	}
+
+	status := &Status{}
+	if err := json.NewDecoder(resp.Body).Decode(status); err != nil {
+		return nil, fmt.Errorf("failed to decode status: %w", err)
+	}
+
+	return status, nil
+}
+
+// FollowEvents calls the handler for every event of the watchdog timeline until the context is
+// done or the server closes the stream.
+func (c *Client) FollowEvents(ctx context.Context, handler func(event components.EventResults)) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+EventsPath, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create events request: %w", err)
+	}
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to get events: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to get events: unexpected status code %d", resp.StatusCode)
+	}
+
+	eventName := ""
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			eventName = ""
+		case strings.HasPrefix(line, "event:"):
+			eventName = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:") && eventName == watchdogEventName:
+			event := components.EventResults{}
+			if err := json.Unmarshal([]byte(strings.TrimSpace(strings.TrimPrefix(line, "data:"))), &event); err != nil {
+				return fmt.Errorf("failed to decode event: %w", err)
+			}
+			handler(event)
+		}
+	}
+
+	if err := scanner.Err(); err != nil && ctx.Err() == nil {
+		return fmt.Errorf("failed to read events: %w", err)
+	}
+
+	return nil
+}
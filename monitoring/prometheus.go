@@ -0,0 +1,144 @@
+package monitoring
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/vegaprotocol/snapshot-testing/components"
+)
+
+const metricsPrefix = "snapshot_testing_"
+
+// allNodeStates are exported, so the state can be graphed without gaps.
+var allNodeStates = []components.NodeState{
+	components.NodeNotStarted,
+	components.NodeStarting,
+	components.NodeCatchingUp,
+	components.NodeCaughtUp,
+	components.NodeLagging,
+	components.NodeStalled,
+	components.NodeCrashed,
+}
+
+// prometheusWriter writes metrics in the Prometheus text exposition format.
+type prometheusWriter struct {
+	out io.Writer
+}
+
+func (pw prometheusWriter) metric(name, metricType, help string, samples ...sample) {
+	fmt.Fprintf(pw.out, "# HELP %s%s %s\n", metricsPrefix, name, help)
+	fmt.Fprintf(pw.out, "# TYPE %s%s %s\n", metricsPrefix, name, metricType)
+	for _, s := range samples {
+		fmt.Fprintf(pw.out, "%s%s%s %v\n", metricsPrefix, name, s.labelsString(), s.value)
+	}
+}
+
+type sample struct {
+	labels []string // Label names and values
+	value  float64
+}
+
+func value(v float64, labels ...string) sample {
+	return sample{labels: labels, value: v}
+}
+
+func boolValue(v bool, labels ...string) sample {
+	if v {
+		return value(1, labels...)
+	}
+	return value(0, labels...)
+}
+
+func (s sample) labelsString() string {
+	if len(s.labels) == 0 {
+		return ""
+	}
+
+	pairs := []string{}
+	for i := 0; i+1 < len(s.labels); i += 2 {
+		escaped := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s.labels[i+1])
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, s.labels[i], escaped))
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// metricsHandler serves the live state of the run as Prometheus metrics.
+func metricsHandler(monitor *components.Monitor) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		status := monitor.Status()
+		now := time.Now()
+
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		pw := prometheusWriter{out: w}
+
+		pw.metric("elapsed_seconds", "gauge", "Time since the test started.", value(now.Sub(status.StartedAt).Seconds()))
+		if status.Phase != "" {
+			pw.metric("phase", "gauge", "Current phase of the test, the value is always 1.",
+				value(1, "phase", status.Phase, "type", status.PhaseType))
+			pw.metric("phase_elapsed_seconds", "gauge", "Time since the current phase started.",
+				value(now.Sub(status.PhaseStartedAt).Seconds()))
+		}
+
+		pw.metric("watchdog_probes_total", "counter", "Number of the watchdog probes.", value(float64(status.Probes)))
+		if probe := status.LastProbe; probe != nil {
+			pw.metric("local_node_up", "gauge", "Whether the local node responded to the last probe.", boolValue(probe.LocalNodeUp))
+			pw.metric("local_core_height", "gauge", "Block height of the local core.", value(float64(probe.LocalCoreHeight)))
+			pw.metric("local_data_node_up", "gauge", "Whether the local data-node responded to the last probe.", boolValue(probe.DataNodeLagKnown()))
+			pw.metric("network_height", "gauge", "Highest block height reported by the network.", value(float64(probe.NetworkHeight)))
+			pw.metric("core_lag", "gauge", "Number of blocks the local core is behind the network.", value(float64(probe.CoreLag)))
+			// Data-node height and lag are not known when the data-node did not respond
+			if probe.DataNodeLagKnown() {
+				pw.metric("local_data_node_height", "gauge", "Block height of the local data-node.", value(float64(probe.LocalDataNodeHeight)))
+				pw.metric("data_node_lag", "gauge", "Number of blocks the local data-node is behind the local core.", value(float64(probe.DataNodeLag)))
+			}
+			pw.metric("vega_time_drift_seconds", "gauge", "Wall clock minus the local node vega time.", value(probe.VegaTimeDriftSeconds))
+		}
+
+		stateSamples := []sample{}
+		for _, state := range allNodeStates {
+			stateSamples = append(stateSamples, boolValue(status.NodeState == state, "state", string(state)))
+		}
+		pw.metric("node_state", "gauge", "State of the local node tracked by the watchdog.", stateSamples...)
+
+		if status.Snapshots >= 0 {
+			pw.metric("local_snapshots", "gauge", "Number of snapshots listed by the local data-node.", value(float64(status.Snapshots)))
+		}
+
+		componentNames := []string{}
+		for name := range status.Components {
+			componentNames = append(componentNames, name)
+		}
+		slices.Sort(componentNames)
+		healthSamples := []sample{}
+		for _, name := range componentNames {
+			health := status.Components[name]
+			if health.Status == components.ComponentHealthUnknown {
+				continue
+			}
+			healthSamples = append(healthSamples, boolValue(health.Status == components.ComponentHealthHealthy, "component", name))
+		}
+		pw.metric("component_healthy", "gauge", "Result of the last health check of the component.", healthSamples...)
+
+		if health, ok := status.Components[components.ComponentNamePostgresql]; ok && health.Status != components.ComponentHealthUnknown {
+			pw.metric("postgresql_up", "gauge", "Whether the PostgreSQL container was healthy at the last health check.",
+				boolValue(health.Status == components.ComponentHealthHealthy))
+		}
+
+		pw.metric("visor_starts_total", "counter", "Number of times the vegavisor process was started.", value(float64(status.VisorStarts)))
+		exitReasons := []string{}
+		for reason := range status.VisorExits {
+			exitReasons = append(exitReasons, string(reason))
+		}
+		slices.Sort(exitReasons)
+		exitSamples := []sample{}
+		for _, reason := range exitReasons {
+			exitSamples = append(exitSamples, value(float64(status.VisorExits[components.VisorExitReason(reason)]), "reason", reason))
+		}
+		pw.metric("visor_exits_total", "counter", "Number of times the vegavisor process exited by the exit reason.", exitSamples...)
+	}
+}
@@ -0,0 +1,80 @@
+package monitoring
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/vegaprotocol/snapshot-testing/components"
+)
+
+// Server serves the live state of the run over HTTP.
+type Server struct {
+	logger  *zap.Logger
+	monitor *components.Monitor
+	mux     *http.ServeMux
+	server  *http.Server
+	// done is closed when the server is stopped to finish the long-lived event streams
+	done chan struct{}
+}
+
+// NewServer creates the server without any endpoints, they are added with the Serve* functions.
+func NewServer(addr string, monitor *components.Monitor, logger *zap.Logger) *Server {
+	mux := http.NewServeMux()
+
+	return &Server{
+		logger:  logger,
+		monitor: monitor,
+		mux:     mux,
+		server: &http.Server{
+			Addr:              addr,
+			Handler:           mux,
+			ReadHeaderTimeout: 10 * time.Second,
+		},
+		done: make(chan struct{}),
+	}
+}
+
+// ServeMetrics exposes Prometheus metrics on the /metrics path.
+func (s *Server) ServeMetrics() {
+	s.mux.Handle("/metrics", metricsHandler(s.monitor))
+}
+
+// ServeStatus exposes the status of the run on the /status path and the watchdog timeline
+// as server-sent events on the /events path.
+func (s *Server) ServeStatus() {
+	s.mux.Handle(StatusPath, statusHandler(s.monitor))
+	s.mux.Handle(EventsPath, eventsHandler(s.monitor, s.done))
+}
+
+// Start starts listening on the address and serves requests in the background.
+func (s *Server) Start() error {
+	listener, err := net.Listen("tcp", s.server.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.server.Addr, err)
+	}
+
+	s.logger.Sugar().Infof("Serving monitoring endpoints on %s", listener.Addr().String())
+	go func() {
+		if err := s.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.logger.Error("Monitoring server failed", zap.Error(err))
+		}
+	}()
+
+	return nil
+}
+
+// Stop shuts the server down.
+func (s *Server) Stop(ctx context.Context) error {
+	close(s.done)
+	if err := s.server.Shutdown(ctx); err != nil {
+		return fmt.Errorf("failed to shutdown monitoring server: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,101 @@
+package monitoring
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/vegaprotocol/snapshot-testing/components"
+)
+
+const (
+	StatusPath = "/status"
+	EventsPath = "/events"
+
+	// watchdogEventName is the name of the server-sent event with the watchdog event.
+	watchdogEventName = "watchdog"
+	// keepAliveInterval is how often the comment is sent to the idle event stream, so proxies do not close it.
+	keepAliveInterval = 15 * time.Second
+)
+
+// Status is served on the /status path.
+type Status struct {
+	components.MonitorStatus
+	// Results of the running components collected on request, null before the components are started
+	PartialResults *components.Results `json:"partial_results"`
+}
+
+func statusHandler(monitor *components.Monitor) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		status := Status{
+			MonitorStatus:  monitor.Status(),
+			PartialResults: monitor.PartialResults(),
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(status); err != nil {
+			http.Error(w, fmt.Sprintf("failed to encode status: %s", err.Error()), http.StatusInternalServerError)
+		}
+	}
+}
+
+// eventsHandler streams the watchdog timeline as server-sent events. Events received before
+// the client connected are sent first.
+func eventsHandler(monitor *components.Monitor, done <-chan struct{}) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+			return
+		}
+
+		history, events, unsubscribe := monitor.SubscribeEvents()
+		defer unsubscribe()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+
+		for _, event := range history {
+			if err := writeEvent(w, event); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+
+		keepAlive := time.NewTicker(keepAliveInterval)
+		defer keepAlive.Stop()
+
+		for {
+			select {
+			case event := <-events:
+				if err := writeEvent(w, event); err != nil {
+					return
+				}
+			case <-keepAlive.C:
+				if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+					return
+				}
+			case <-r.Context().Done():
+				return
+			case <-done:
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func writeEvent(w http.ResponseWriter, event components.EventResults) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", watchdogEventName, data); err != nil {
+		return fmt.Errorf("failed to write event: %w", err)
+	}
+
+	return nil
+}
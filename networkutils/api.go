@@ -1,16 +1,19 @@
 package networkutils
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/vegaprotocol/snapshot-testing/tools"
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
 )
 
@@ -35,10 +38,21 @@ type Statistics struct {
 	AppVersion string
 }
 
-func GetStatistics(httpClient *http.Client, restURL string) (*Statistics, error) {
+func GetStatistics(ctx context.Context, httpClient *http.Client, restURL string) (statistics *Statistics, err error) {
 	statisticsURL := fmt.Sprintf("%s/statistics", strings.TrimRight(restURL, "/"))
 
-	ctx, cancel := context.WithTimeout(context.Background(), RequestTimeout)
+	ctx, span := startClientSpan(ctx, "GET /statistics", attribute.String("url.full", statisticsURL))
+	defer func() {
+		if statistics != nil {
+			span.SetAttributes(
+				attribute.Int64("vega.block_height", int64(statistics.BlockHeight)),
+				attribute.Int64("vega.data_node_height", int64(statistics.DataNodeHeight)),
+			)
+		}
+		endSpan(span, err)
+	}()
+
+	ctx, cancel := context.WithTimeout(ctx, RequestTimeout)
 	defer cancel()
 	request, err := http.NewRequestWithContext(ctx, http.MethodGet, statisticsURL, nil)
 	if err != nil {
@@ -49,6 +63,7 @@ func GetStatistics(httpClient *http.Client, restURL string) (*Statistics, error)
 	if err != nil {
 		return nil, fmt.Errorf("failed to send get query to the statistics endpoint: %w", err)
 	}
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
 
 	defer resp.Body.Close()
 	body, err := io.ReadAll(resp.Body)
@@ -97,7 +112,7 @@ func GetStatistics(httpClient *http.Client, restURL string) (*Statistics, error)
 	return result, nil
 }
 
-func GetLatestStatistics(httpClient *http.Client, restEndpoints []string) (*Statistics, error) {
+func GetLatestStatistics(ctx context.Context, httpClient *http.Client, restEndpoints []string) (*Statistics, error) {
 	if len(restEndpoints) < 1 {
 		return nil, fmt.Errorf("no rest endpoint passed")
 	}
@@ -106,7 +121,7 @@ func GetLatestStatistics(httpClient *http.Client, restEndpoints []string) (*Stat
 
 	for _, endpoint := range restEndpoints {
 		statistics, err := tools.RetryReturn(3, 500*time.Millisecond, func() (*Statistics, error) {
-			return GetStatistics(httpClient, endpoint)
+			return GetStatistics(ctx, httpClient, endpoint)
 		})
 
 		if err != nil {
@@ -152,10 +167,16 @@ func (s Snapshot) Clone() Snapshot {
 	}
 }
 
-func getSnapshots(httpClient *http.Client, restURL string) ([]Snapshot, error) {
+func GetSnapshots(ctx context.Context, httpClient *http.Client, restURL string) (snapshots []Snapshot, err error) {
 	snapshotsURL := fmt.Sprintf("%s/api/v2/snapshots", strings.TrimRight(restURL, "/"))
 
-	ctx, cancel := context.WithTimeout(context.Background(), RequestTimeout)
+	ctx, span := startClientSpan(ctx, "GET /api/v2/snapshots", attribute.String("url.full", snapshotsURL))
+	defer func() {
+		span.SetAttributes(attribute.Int("vega.snapshots", len(snapshots)))
+		endSpan(span, err)
+	}()
+
+	ctx, cancel := context.WithTimeout(ctx, RequestTimeout)
 	defer cancel()
 	request, err := http.NewRequestWithContext(ctx, http.MethodGet, snapshotsURL, nil)
 	if err != nil {
@@ -167,6 +188,7 @@ func getSnapshots(httpClient *http.Client, restURL string) ([]Snapshot, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to send get query to the statistics endpoint: %w", err)
 	}
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
 
 	defer resp.Body.Close()
 	body, err := io.ReadAll(resp.Body)
@@ -197,10 +219,26 @@ func getSnapshots(httpClient *http.Client, restURL string) ([]Snapshot, error) {
 	return response, nil
 }
 
-func isRESTEndpointHealthy(httpClient *http.Client, logger *zap.Logger, networkHeadHeight uint64, restURL string) bool {
+// isRESTEndpointHealthy checks the remote endpoint responds and its core is at most maxCoreLag blocks behind the
+// network head, and its data-node at most maxDataNodeLag blocks behind its core.
+func isRESTEndpointHealthy(
+	ctx context.Context,
+	httpClient *http.Client,
+	logger *zap.Logger,
+	networkHeadHeight uint64,
+	maxCoreLag uint64,
+	maxDataNodeLag uint64,
+	restURL string,
+) (healthy bool) {
+	ctx, span := startClientSpan(ctx, "check-rest-endpoint", attribute.String("url.full", restURL))
+	defer func() {
+		span.SetAttributes(attribute.Bool("healthy", healthy))
+		span.End()
+	}()
+
 	logger.Sugar().Infof("Fetching statistics from %s", restURL)
 	statistics, err := tools.RetryReturn(3, 500*time.Millisecond, func() (*Statistics, error) {
-		return GetStatistics(httpClient, restURL)
+		return GetStatistics(ctx, httpClient, restURL)
 	})
 
 	if err != nil {
@@ -208,32 +246,11 @@ func isRESTEndpointHealthy(httpClient *http.Client, logger *zap.Logger, networkH
 		return false
 	}
 
-	headBlocksDiff := networkHeadHeight - statistics.BlockHeight
-	if statistics.BlockHeight < networkHeadHeight && headBlocksDiff > HealthyBlocksThreshold {
-		logger.Sugar().Infof(
-			"The %s endpoint unhealthy: core height(%d) is %d behind the network head(%d), only %d blocks lag allowed",
-			restURL,
-			statistics.BlockHeight,
-			headBlocksDiff,
-			networkHeadHeight,
-			HealthyBlocksThreshold,
-		)
+	if err := CheckStatisticsHeights(statistics, networkHeadHeight, maxCoreLag, maxDataNodeLag); err != nil {
+		logger.Sugar().Infof("The %s endpoint unhealthy: %s", restURL, err.Error())
 		return false
 	}
 
-	if statistics.DataNodeHeight > 0 {
-		blocksDiff := statistics.BlockHeight - statistics.DataNodeHeight
-		if statistics.DataNodeHeight < statistics.BlockHeight && blocksDiff > HealthyBlocksThreshold {
-			logger.Sugar().Infof(
-				"The %s endpoint unhealthy: data node is %d blocks behind core, only %d blocks lag allowed",
-				restURL,
-				blocksDiff,
-				HealthyBlocksThreshold,
-			)
-			return false
-		}
-	}
-
 	// We do not check time diff here, because we want run test even if the network is not producing blocks.
 	// It can give us extra information
 	//
@@ -252,3 +269,169 @@ func isRESTEndpointHealthy(httpClient *http.Client, logger *zap.Logger, networkH
 
 	return true
 }
+
+// CheckStatisticsHeights checks the core is at most maxCoreLag blocks behind the network head, and the
+// data-node at most maxDataNodeLag blocks behind its core.
+func CheckStatisticsHeights(statistics *Statistics, networkHeadHeight, maxCoreLag, maxDataNodeLag uint64) error {
+	headBlocksDiff := networkHeadHeight - statistics.BlockHeight
+	if statistics.BlockHeight < networkHeadHeight && headBlocksDiff > maxCoreLag {
+		return fmt.Errorf(
+			"core height(%d) is %d behind the network head(%d), only %d blocks lag allowed",
+			statistics.BlockHeight,
+			headBlocksDiff,
+			networkHeadHeight,
+			maxCoreLag,
+		)
+	}
+
+	if statistics.DataNodeHeight > 0 {
+		blocksDiff := statistics.BlockHeight - statistics.DataNodeHeight
+		if statistics.DataNodeHeight < statistics.BlockHeight && blocksDiff > maxDataNodeLag {
+			return fmt.Errorf("data node is %d blocks behind core, only %d blocks lag allowed", blocksDiff, maxDataNodeLag)
+		}
+	}
+
+	return nil
+}
+
+// GetRESTResource sends the GET query for the given path of the REST API and returns the status code
+// with the response body. Non-2xx status codes are not treated as an error.
+func GetRESTResource(ctx context.Context, httpClient *http.Client, restURL string, path string) (int, []byte, error) {
+	resourceURL := fmt.Sprintf("%s/%s", strings.TrimRight(restURL, "/"), strings.TrimLeft(path, "/"))
+
+	ctx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	defer cancel()
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, resourceURL, nil)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to create request for %s: %w", resourceURL, err)
+	}
+
+	resp, err := httpClient.Do(request)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to send get query to %s: %w", resourceURL, err)
+	}
+
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, nil, fmt.Errorf("failed to read response body from %s: %w", resourceURL, err)
+	}
+
+	return resp.StatusCode, body, nil
+}
+
+// MaxDataNodePages limits the number of pages read from the paginated data-node response, so the
+// misbehaving data-node cannot return the next page forever.
+const MaxDataNodePages = 1000
+
+// GetDataNodeResourceIDs returns identifiers of all of the nodes in the paginated data-node response,
+// e.g. market ids for the /api/v2/markets. All pages are read by following the page cursor. The key
+// is used as identifier for the nodes without id, e.g. network parameters.
+func GetDataNodeResourceIDs(ctx context.Context, httpClient *http.Client, restURL string, path string) ([]string, error) {
+	ids := []string{}
+	pagePath := path
+	for page := 0; page < MaxDataNodePages; page++ {
+		statusCode, body, err := GetRESTResource(ctx, httpClient, restURL, pagePath)
+		if err != nil {
+			return nil, err
+		}
+
+		if statusCode != http.StatusOK {
+			return nil, fmt.Errorf("unexpected status code %d for %s", statusCode, pagePath)
+		}
+
+		var response any
+		if err := json.Unmarshal(body, &response); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal %s response: %w", pagePath, err)
+		}
+
+		pageInfo := dataNodePageInfo{}
+		collectEdgesIDs(response, &ids, &pageInfo)
+		if !pageInfo.HasNextPage || pageInfo.EndCursor == "" {
+			return ids, nil
+		}
+
+		pagePath = withQueryParam(path, "pagination.after", pageInfo.EndCursor)
+	}
+
+	return nil, fmt.Errorf("the %s response has more than %d pages", path, MaxDataNodePages)
+}
+
+type dataNodePageInfo struct {
+	HasNextPage bool
+	EndCursor   string
+}
+
+func withQueryParam(path, key, value string) string {
+	separator := "?"
+	if strings.Contains(path, "?") {
+		separator = "&"
+	}
+
+	return fmt.Sprintf("%s%s%s=%s", path, separator, url.QueryEscape(key), url.QueryEscape(value))
+}
+
+func collectEdgesIDs(value any, ids *[]string, pageInfo *dataNodePageInfo) {
+	switch v := value.(type) {
+	case []any:
+		for _, item := range v {
+			collectEdgesIDs(item, ids, pageInfo)
+		}
+	case map[string]any:
+		edges, hasEdges := v["edges"].([]any)
+		if !hasEdges {
+			for _, item := range v {
+				collectEdgesIDs(item, ids, pageInfo)
+			}
+			return
+		}
+
+		for _, edge := range edges {
+			edgeMap, _ := edge.(map[string]any)
+			node, _ := edgeMap["node"].(map[string]any)
+			if id, ok := node["id"].(string); ok {
+				*ids = append(*ids, id)
+			} else if key, ok := node["key"].(string); ok {
+				*ids = append(*ids, key)
+			}
+		}
+
+		if info, ok := v["pageInfo"].(map[string]any); ok {
+			pageInfo.HasNextPage, _ = info["hasNextPage"].(bool)
+			pageInfo.EndCursor, _ = info["endCursor"].(string)
+		}
+	}
+}
+
+// GetJSONField returns value of the field from the JSON document. The path is dot separated, numeric
+// segments are used as index of the arrays, e.g. "markets.edges.0.node.id".
+func GetJSONField(document []byte, path string) (any, error) {
+	// Numbers are kept as json.Number, so big integers are not rounded to float
+	decoder := json.NewDecoder(bytes.NewReader(document))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal json document: %w", err)
+	}
+
+	for _, segment := range strings.Split(path, ".") {
+		switch v := value.(type) {
+		case map[string]any:
+			field, ok := v[segment]
+			if !ok {
+				return nil, fmt.Errorf("field %s not found", segment)
+			}
+			value = field
+		case []any:
+			idx, err := strconv.Atoi(segment)
+			if err != nil || idx < 0 || idx >= len(v) {
+				return nil, fmt.Errorf("invalid index %s for array of length %d", segment, len(v))
+			}
+			value = v[idx]
+		default:
+			return nil, fmt.Errorf("cannot get %s from the scalar value", segment)
+		}
+	}
+
+	return value, nil
+}
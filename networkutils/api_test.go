@@ -0,0 +1,75 @@
+package networkutils
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestGetDataNodeResourceIDsReadsAllPages(t *testing.T) {
+	pages := map[string]string{
+		"": `{"markets": {"edges": [{"node": {"id": "m1"}}, {"node": {"id": "m2"}}],
+			"pageInfo": {"hasNextPage": true, "endCursor": "c1"}}}`,
+		"c1": `{"markets": {"edges": [{"node": {"id": "m3"}}],
+			"pageInfo": {"hasNextPage": true, "endCursor": "c2"}}}`,
+		"c2": `{"markets": {"edges": [{"node": {"id": "m4"}}],
+			"pageInfo": {"hasNextPage": false, "endCursor": "c3"}}}`,
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v2/markets" {
+			http.NotFound(w, r)
+			return
+		}
+
+		page, ok := pages[r.URL.Query().Get("pagination.after")]
+		if !ok {
+			http.Error(w, "unknown cursor", http.StatusBadRequest)
+			return
+		}
+		fmt.Fprint(w, page)
+	}))
+	defer server.Close()
+
+	ids, err := GetDataNodeResourceIDs(context.Background(), server.Client(), server.URL, "/api/v2/markets")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if expected := []string{"m1", "m2", "m3", "m4"}; !slices.Equal(ids, expected) {
+		t.Errorf("expected %v, got %v", expected, ids)
+	}
+}
+
+func TestGetDataNodeResourceIDsUsesKeyWithoutID(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"networkParameters": {"edges": [{"node": {"key": "a", "value": "1"}}]}}`)
+	}))
+	defer server.Close()
+
+	ids, err := GetDataNodeResourceIDs(context.Background(), server.Client(), server.URL, "/api/v2/network/parameters")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if expected := []string{"a"}; !slices.Equal(ids, expected) {
+		t.Errorf("expected %v, got %v", expected, ids)
+	}
+}
+
+func TestGetDataNodeResourceIDsStopsWhenContextIsCancelled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"markets": {"edges": [], "pageInfo": {"hasNextPage": true, "endCursor": "next"}}}`)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := GetDataNodeResourceIDs(ctx, server.Client(), server.URL, "/api/v2/markets"); err == nil {
+		t.Fatal("expected error for the cancelled context")
+	}
+}
@@ -17,7 +17,8 @@ type CliSnapshot struct {
 	Height int64 `json:"height"`
 }
 
-func LocalSnapshotsRange(pathManager *PathManager) (int64, int64, error) {
+// LocalSnapshots returns sorted heights of all snapshots stored by the local node.
+func LocalSnapshots(pathManager *PathManager) ([]int64, error) {
 	toolsSnapshotCmd := []string{
 		"tools", "snapshot",
 		"--home", pathManager.VegaHome(),
@@ -36,15 +37,30 @@ func LocalSnapshotsRange(pathManager *PathManager) (int64, int64, error) {
 		return nil
 	}); err != nil {
 		if strings.Contains(err.Error(), "file does not exist") {
-			return 0, 0, SnapshotDatabaseDoesNotExistErr
+			return nil, SnapshotDatabaseDoesNotExistErr
 		}
-		return 0, 0, fmt.Errorf("failed to get snapshot from the cli: %w", err)
+		return nil, fmt.Errorf("failed to get snapshot from the cli: %w", err)
 	}
 
 	heightSlice := []int64{}
 	for _, snapshot := range response.Snapshots {
 		heightSlice = append(heightSlice, snapshot.Height)
 	}
+	slices.Sort(heightSlice)
 
-	return slices.Min(heightSlice), slices.Max(heightSlice), nil
+	return heightSlice, nil
+}
+
+// PrepareLocalSnapshotRestart updates the already initialized local node, so the next start loads
+// the latest snapshot produced by the node instead of the remote one.
+func PrepareLocalSnapshotRestart(pathManager *PathManager) error {
+	if !pathManager.IsNodeInitialized() {
+		return fmt.Errorf("local node is not initialized")
+	}
+
+	if err := updateConfigsForLocalRestart(pathManager.VegaHome(), pathManager.TendermintHome()); err != nil {
+		return fmt.Errorf("failed to update configs for restart: %w", err)
+	}
+
+	return nil
 }
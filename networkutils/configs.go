@@ -134,3 +134,39 @@ func updateDataNodeConfig(vegaHome string, bootstrapPeers []string, psqlCreds co
 
 	return nil
 }
+
+// updateConfigsForLocalRestart configures vega to load the latest local snapshot and disables the
+// tendermint state sync, because the block store already exists locally. The data-node keeps its
+// database, so it is not wiped and not initialised from the network history again.
+func updateConfigsForLocalRestart(vegaHome string, tendermintHome string) error {
+	vegaConfigFilePath := filepath.Join(vegaHome, "config", "node", "config.toml")
+	newVegaConfigValues := map[string]interface{}{
+		// -1 means the latest local snapshot
+		"Snapshot.StartHeight": -1,
+	}
+
+	if err := tools.UpdateConfig(vegaConfigFilePath, "toml", newVegaConfigValues); err != nil {
+		return fmt.Errorf("failed to update vega config: %w", err)
+	}
+
+	tendermintConfigFilePath := filepath.Join(tendermintHome, "config", "config.toml")
+	newTendermintConfigValues := map[string]interface{}{
+		"statesync.enable": false,
+	}
+
+	if err := tools.UpdateConfig(tendermintConfigFilePath, "toml", newTendermintConfigValues); err != nil {
+		return fmt.Errorf("failed to update tendermint config: %w", err)
+	}
+
+	dataNodeConfigFilePath := filepath.Join(vegaHome, "config", "data-node", "config.toml")
+	newDataNodeConfigValues := map[string]interface{}{
+		"SQLStore.WipeOnStartup":           false,
+		"AutoInitialiseFromNetworkHistory": false,
+	}
+
+	if err := tools.UpdateConfig(dataNodeConfigFilePath, "toml", newDataNodeConfigValues); err != nil {
+		return fmt.Errorf("failed to update data-node config: %w", err)
+	}
+
+	return nil
+}
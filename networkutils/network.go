@@ -1,6 +1,7 @@
 package networkutils
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
@@ -10,6 +11,7 @@ import (
 	"slices"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
 
 	"github.com/vegaprotocol/snapshot-testing/config"
@@ -17,8 +19,7 @@ import (
 )
 
 const (
-	HealthyTimeThreshold   = time.Second * 300
-	HealthyBlocksThreshold = 450
+	HealthyTimeThreshold = time.Second * 300
 )
 
 const (
@@ -34,6 +35,9 @@ type Network struct {
 	logger      *zap.Logger
 	conf        config.Network
 	pathManager PathManager
+	// Remote endpoints are healthy with the same lags as the local node checked by the watchdog
+	maxCoreLag     uint64
+	maxDataNodeLag uint64
 
 	healthyRESTEndpoints []string
 	healthyRPCPeers      []string
@@ -44,6 +48,8 @@ type Network struct {
 	height     uint64
 
 	restHTTPClient *http.Client
+
+	setupReport SetupReport
 }
 
 func NewNetwork(logger *zap.Logger, conf config.Network, pm PathManager, restHTTPClient *http.Client) (*Network, error) {
@@ -51,22 +57,26 @@ func NewNetwork(logger *zap.Logger, conf config.Network, pm PathManager, restHTT
 		return nil, fmt.Errorf("invalid config for network: %w", err)
 	}
 
+	watchdog := conf.Watchdog.Merge(config.DefaultWatchdog)
+
 	return &Network{
 		logger:         logger,
 		conf:           conf,
 		pathManager:    pm,
+		maxCoreLag:     *watchdog.CoreLag,
+		maxDataNodeLag: *watchdog.DataNodeLag,
 		restHTTPClient: restHTTPClient,
 	}, nil
 }
 
-func (n *Network) getHealthyRPCPeers() ([]string, error) {
+func (n *Network) getHealthyRPCPeers(ctx context.Context) ([]string, error) {
 	if len(n.healthyRPCPeers) > 0 {
 		return n.healthyRPCPeers, nil
 	}
 
 	n.logger.Info("Looking for a healthy RPC peers")
 
-	networkHeadHeight, err := n.getNetworkHeight()
+	networkHeadHeight, err := n.getNetworkHeight(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get network height: %w", err)
 	}
@@ -77,7 +87,7 @@ func (n *Network) getHealthyRPCPeers() ([]string, error) {
 			n.logger.Sugar().Infof("The %s peer does not have core REST assigned. Skipping", rpcPeer.Endpoint)
 			continue
 		}
-		if isRESTEndpointHealthy(n.restHTTPClient, n.logger, networkHeadHeight, rpcPeer.CoreREST) {
+		if isRESTEndpointHealthy(ctx, n.restHTTPClient, n.logger, networkHeadHeight, n.maxCoreLag, n.maxDataNodeLag, rpcPeer.CoreREST) {
 			n.logger.Sugar().Infof("The %s RPC peer is healthy", rpcPeer.Endpoint)
 			healthyPeers = append(healthyPeers, rpcPeer.Endpoint)
 		}
@@ -92,7 +102,7 @@ func (n *Network) getHealthyRPCPeers() ([]string, error) {
 	return healthyPeers, nil
 }
 
-func (n *Network) getNetworkHeight() (uint64, error) {
+func (n *Network) getNetworkHeight(ctx context.Context) (uint64, error) {
 	// We do not care about latest results here
 	if n.height > 0 {
 		return n.height, nil
@@ -104,7 +114,7 @@ func (n *Network) getNetworkHeight() (uint64, error) {
 	for _, restURL := range n.conf.DataNodesREST {
 		n.logger.Sugar().Infof("Fetching statistics from %s", restURL)
 		statistics, err := tools.RetryReturn(3, 500*time.Millisecond, func() (*Statistics, error) {
-			return GetStatistics(n.restHTTPClient, restURL)
+			return GetStatistics(ctx, n.restHTTPClient, restURL)
 		})
 
 		if err != nil {
@@ -127,7 +137,7 @@ func (n *Network) getNetworkHeight() (uint64, error) {
 	return maxHeight, nil
 }
 
-func (n *Network) getChainID() (string, error) {
+func (n *Network) getChainID(ctx context.Context) (string, error) {
 	// We do not care about latest results here
 	if n.chainId != "" {
 		return n.chainId, nil
@@ -137,7 +147,7 @@ func (n *Network) getChainID() (string, error) {
 	for _, restURL := range n.conf.DataNodesREST {
 		n.logger.Sugar().Infof("Fetching statistics from %s", restURL)
 		statistics, err := tools.RetryReturn(3, 500*time.Millisecond, func() (*Statistics, error) {
-			return GetStatistics(n.restHTTPClient, restURL)
+			return GetStatistics(ctx, n.restHTTPClient, restURL)
 		})
 
 		if err != nil {
@@ -156,7 +166,7 @@ func (n *Network) getChainID() (string, error) {
 	return "", fmt.Errorf("not received any valid response from statistics rest endpoints")
 }
 
-func (n *Network) getAppVersion() (string, error) {
+func (n *Network) getAppVersion(ctx context.Context) (string, error) {
 	if len(n.conf.BinaryVersionOverride) > 0 {
 		n.logger.Sugar().Infof("Binary version is override in the config to version %s", n.conf.BinaryVersionOverride)
 
@@ -168,7 +178,7 @@ func (n *Network) getAppVersion() (string, error) {
 	}
 	n.logger.Info("Fetching the network app version")
 
-	healthyRESTEndpoints, err := n.getHealthyRESTEndpoints()
+	healthyRESTEndpoints, err := n.getHealthyRESTEndpoints(ctx)
 	if err != nil {
 		return "", fmt.Errorf("failed to get healthy rest endpoints: %w", err)
 	}
@@ -176,7 +186,7 @@ func (n *Network) getAppVersion() (string, error) {
 	for _, restURL := range healthyRESTEndpoints {
 		n.logger.Sugar().Infof("Fetching statistics from %s", restURL)
 		statistics, err := tools.RetryReturn(3, 500*time.Millisecond, func() (*Statistics, error) {
-			return GetStatistics(n.restHTTPClient, restURL)
+			return GetStatistics(ctx, n.restHTTPClient, restURL)
 		})
 
 		if err != nil {
@@ -194,21 +204,21 @@ func (n *Network) getAppVersion() (string, error) {
 	return "", fmt.Errorf("failed to find the app version for the network: no valid response received from the healthy endpoints")
 }
 
-func (n *Network) getHealthyRESTEndpoints() ([]string, error) {
+func (n *Network) getHealthyRESTEndpoints(ctx context.Context) ([]string, error) {
 	if len(n.healthyRESTEndpoints) > 0 {
 		return n.healthyRESTEndpoints, nil
 	}
 
 	n.logger.Info("Getting all healthy rest endpoints for the network")
 
-	networkHeadHeight, err := n.getNetworkHeight()
+	networkHeadHeight, err := n.getNetworkHeight(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get network height: %w", err)
 	}
 
 	healthyNodes := []string{}
 	for _, restURL := range n.conf.DataNodesREST {
-		if isRESTEndpointHealthy(n.restHTTPClient, n.logger, networkHeadHeight, restURL) {
+		if isRESTEndpointHealthy(ctx, n.restHTTPClient, n.logger, networkHeadHeight, n.maxCoreLag, n.maxDataNodeLag, restURL) {
 			healthyNodes = append(healthyNodes, restURL)
 		}
 	}
@@ -223,7 +233,7 @@ func (n *Network) getHealthyRESTEndpoints() ([]string, error) {
 	return n.healthyRESTEndpoints, nil
 }
 
-func (n *Network) binaryArtifactURL(kind string) (string, error) {
+func (n *Network) binaryArtifactURL(ctx context.Context, kind string) (string, error) {
 	osPart := "linux"
 
 	switch runtime.GOOS {
@@ -235,7 +245,7 @@ func (n *Network) binaryArtifactURL(kind string) (string, error) {
 		return "", fmt.Errorf("operating system not supported: only windows and linux supported, got %s", runtime.GOOS)
 	}
 
-	appVersion, err := n.getAppVersion()
+	appVersion, err := n.getAppVersion(ctx)
 	if err != nil {
 		return "", fmt.Errorf("failed to get app version: %w", err)
 	}
@@ -263,7 +273,7 @@ func (n *Network) binaryArtifactURL(kind string) (string, error) {
 	return url, nil
 }
 
-func (n *Network) DownloadFile(kind string, force bool, cleanup bool) (string, error) {
+func (n *Network) DownloadFile(ctx context.Context, kind string, force bool, cleanup bool) (string, error) {
 	n.logger.Sugar().Infof("Preparing URL for %s binary", kind)
 
 	zipOutputFile := filepath.Join(n.pathManager.workDir, fmt.Sprintf("%s.zip", kind))
@@ -274,7 +284,7 @@ func (n *Network) DownloadFile(kind string, force bool, cleanup bool) (string, e
 		}
 	}
 
-	url, err := n.binaryArtifactURL(kind)
+	url, err := n.binaryArtifactURL(ctx, kind)
 	if err != nil {
 		return "", fmt.Errorf("failed to get url for %s binary: %w", kind, err)
 	}
@@ -308,8 +318,8 @@ func (n *Network) DownloadFile(kind string, force bool, cleanup bool) (string, e
 	return filepath.Join(binariesPath, kind), nil
 }
 
-func (n *Network) downloadVegaBinary() error {
-	_, err := n.DownloadFile("vega", true, true)
+func (n *Network) downloadVegaBinary(ctx context.Context) error {
+	_, err := n.DownloadFile(ctx, "vega", true, true)
 	if err != nil {
 		return fmt.Errorf("failed to download vega binary: %w", err)
 	}
@@ -317,8 +327,8 @@ func (n *Network) downloadVegaBinary() error {
 	return nil
 }
 
-func (n *Network) downloadVegaVisorBinary() error {
-	_, err := n.DownloadFile("visor", true, true)
+func (n *Network) downloadVegaVisorBinary(ctx context.Context) error {
+	_, err := n.DownloadFile(ctx, "visor", true, true)
 	if err != nil {
 		return fmt.Errorf("failed to download visor binary: %w", err)
 	}
@@ -329,18 +339,18 @@ func (n *Network) downloadVegaVisorBinary() error {
 // GetRestartSnapshot select snapshot for tendermint trusted block and height.
 // It does not select the latest available snapshot. It select random snapshot
 // between <X-6000; X-500>, where X is current block
-func (n *Network) getRestartSnapshot() (*Snapshot, error) {
+func (n *Network) getRestartSnapshot(ctx context.Context) (*Snapshot, error) {
 	if n.restartSnapshot != nil {
 		return n.restartSnapshot, nil
 	}
 	n.logger.Info("Getting restart snapshot from the network REST API")
 
-	healthyRESTEndpoints, err := n.getHealthyRESTEndpoints()
+	healthyRESTEndpoints, err := n.getHealthyRESTEndpoints(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get healthy rest endpoints: %w", err)
 	}
 
-	networkHeadHeight, err := n.getNetworkHeight()
+	networkHeadHeight, err := n.getNetworkHeight(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get network head height: %w", err)
 	}
@@ -348,7 +358,7 @@ func (n *Network) getRestartSnapshot() (*Snapshot, error) {
 	for _, endpoint := range healthyRESTEndpoints {
 		n.logger.Sugar().Infof("Searching restart snapshot from REST api %s", endpoint)
 		response, err := tools.RetryReturn(3, 500*time.Millisecond, func() ([]Snapshot, error) {
-			return getSnapshots(n.restHTTPClient, endpoint)
+			return GetSnapshots(ctx, n.restHTTPClient, endpoint)
 		})
 
 		if err != nil {
@@ -370,7 +380,7 @@ func (n *Network) getRestartSnapshot() (*Snapshot, error) {
 	return nil, ErrNoSnapshotForRestartFound
 }
 
-func (n *Network) initLocally(force bool) error {
+func (n *Network) initLocally(ctx context.Context, force bool) error {
 	if !n.pathManager.AreBinariesDownloaded() {
 		return fmt.Errorf("Binaries are not downloaded")
 	}
@@ -379,7 +389,7 @@ func (n *Network) initLocally(force bool) error {
 		return fmt.Errorf("missing restart snapshot")
 	}
 
-	chainID, err := n.getChainID()
+	chainID, err := n.getChainID(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to get chain ID: %w", err)
 	}
@@ -445,18 +455,18 @@ func (n *Network) downloadGenesis(tendermintHome string) error {
 	return nil
 }
 
-func (n *Network) getHealthyBootstrapPeers() ([]string, error) {
+func (n *Network) getHealthyBootstrapPeers(ctx context.Context) ([]string, error) {
 	result := []string{}
 
 	n.logger.Info("Getting all healthy bootstrap peers for the network")
 
-	networkHeadHeight, err := n.getNetworkHeight()
+	networkHeadHeight, err := n.getNetworkHeight(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get network height: %w", err)
 	}
 
 	for _, peer := range n.conf.BootstrapPeers {
-		if !isRESTEndpointHealthy(n.restHTTPClient, n.logger, networkHeadHeight, peer.CoreREST) {
+		if !isRESTEndpointHealthy(ctx, n.restHTTPClient, n.logger, networkHeadHeight, n.maxCoreLag, n.maxDataNodeLag, peer.CoreREST) {
 			continue
 		}
 
@@ -475,47 +485,83 @@ func (n *Network) getHealthyBootstrapPeers() ([]string, error) {
 	return result, nil
 }
 
-func (n *Network) SetupLocalNode(psqlCreds config.PostgreSQLCreds, externalAddress string) error {
-	if err := n.downloadVegaBinary(); err != nil {
+// SetupLocalNode downloads binaries and initializes the local node from the remote snapshot. Every step
+// is traced as the child span of the setup-local-node span.
+func (n *Network) SetupLocalNode(ctx context.Context, psqlCreds config.PostgreSQLCreds, externalAddress string) (err error) {
+	ctx, span := tracer.Start(ctx, "setup-local-node")
+	defer func() {
+		span.SetAttributes(
+			attribute.String("vega.chain_id", n.setupReport.ChainID),
+			attribute.String("vega.app_version", n.setupReport.AppVersion),
+			attribute.Int64("vega.network_head_height", int64(n.setupReport.NetworkHeadHeight)),
+		)
+		endSpan(span, err)
+	}()
+
+	n.setupReport = SetupReport{TraceID: traceID(span)}
+
+	if err := n.setupStep(ctx, "download-vega-binary", n.downloadVegaBinary); err != nil {
 		return fmt.Errorf("failed to download vega binary: %w", err)
 	}
 
-	if err := n.downloadVegaVisorBinary(); err != nil {
+	if err := n.setupStep(ctx, "download-visor-binary", n.downloadVegaVisorBinary); err != nil {
 		return fmt.Errorf("failed to download visor binary: %w", err)
 	}
 
-	restartSnapshot, err := n.getRestartSnapshot()
-	if err != nil {
+	var restartSnapshot *Snapshot
+	if err := n.setupStep(ctx, "get-restart-snapshot", func(ctx context.Context) (err error) {
+		restartSnapshot, err = n.getRestartSnapshot(ctx)
+		return err
+	}); err != nil {
 		return fmt.Errorf("failed to get restart snapshot from the api: %w", err)
 	}
+	n.setupReport.RestartSnapshot = restartSnapshot
 
-	headHeight, err := n.getNetworkHeight()
-	if err != nil {
+	var headHeight uint64
+	if err := n.setupStep(ctx, "get-network-height", func(ctx context.Context) (err error) {
+		headHeight, err = n.getNetworkHeight(ctx)
+		return err
+	}); err != nil {
 		return fmt.Errorf("failed to get network head height: %w", err)
 	}
+	n.setupReport.NetworkHeadHeight = headHeight
 
-	chainId, err := n.getChainID()
-	if err != nil {
-		return fmt.Errorf("failed to get chain id: %d", err)
+	var chainId string
+	if err := n.setupStep(ctx, "get-chain-id", func(ctx context.Context) (err error) {
+		chainId, err = n.getChainID(ctx)
+		return err
+	}); err != nil {
+		return fmt.Errorf("failed to get chain id: %w", err)
 	}
+	n.setupReport.ChainID = chainId
 
-	rpcPeers, err := n.getHealthyRPCPeers()
-	if err != nil {
+	var rpcPeers []string
+	if err := n.setupStep(ctx, "get-rpc-peers", func(ctx context.Context) (err error) {
+		rpcPeers, err = n.getHealthyRPCPeers(ctx)
+		return err
+	}); err != nil {
 		return fmt.Errorf("failed to get RPC peers: %w", err)
 	}
 
-	appVersion, err := n.getAppVersion()
-	if err != nil {
+	var appVersion string
+	if err := n.setupStep(ctx, "get-app-version", func(ctx context.Context) (err error) {
+		appVersion, err = n.getAppVersion(ctx)
+		return err
+	}); err != nil {
 		return fmt.Errorf("failed to get app version: %w", err)
 	}
+	n.setupReport.AppVersion = appVersion
 
 	overrideVersion := "no"
 	if n.conf.BinaryVersionOverride != "" {
 		overrideVersion = n.conf.BinaryVersionOverride
 	}
 
-	bootstrapPeers, err := n.getHealthyBootstrapPeers()
-	if err != nil {
+	var bootstrapPeers []string
+	if err := n.setupStep(ctx, "get-bootstrap-peers", func(ctx context.Context) (err error) {
+		bootstrapPeers, err = n.getHealthyBootstrapPeers(ctx)
+		return err
+	}); err != nil {
 		return fmt.Errorf("failed to get healthy bootstrap peers: %w", err)
 	}
 
@@ -538,42 +584,54 @@ func (n *Network) SetupLocalNode(psqlCreds config.PostgreSQLCreds, externalAddre
 	n.logger.Sugar().Infof("Network version: %s", appVersion)
 	n.logger.Sugar().Infof("Override release: %s", overrideVersion)
 
-	if err := n.initLocally(true); err != nil {
+	if err := n.setupStep(ctx, "init-locally", func(ctx context.Context) error {
+		return n.initLocally(ctx, true)
+	}); err != nil {
 		return fmt.Errorf("failed to initialize node locally: %w", err)
 	}
 
-	if err := n.downloadGenesis(n.pathManager.TendermintHome()); err != nil {
+	if err := n.setupStep(ctx, "download-genesis", func(context.Context) error {
+		return n.downloadGenesis(n.pathManager.TendermintHome())
+	}); err != nil {
 		return fmt.Errorf("failed to download genesis: %w", err)
 	}
 
 	n.logger.Info("Updating vegavisor config")
-	if err := updateVisorConfig(
-		n.pathManager.VisorHome(),
-		n.pathManager.VegaBin(),
-		n.pathManager.VegaHome(),
-		n.pathManager.TendermintHome(),
-		n.pathManager.WorkDir()); err != nil {
+	if err := n.setupStep(ctx, "update-visor-config", func(context.Context) error {
+		return updateVisorConfig(
+			n.pathManager.VisorHome(),
+			n.pathManager.VegaBin(),
+			n.pathManager.VegaHome(),
+			n.pathManager.TendermintHome(),
+			n.pathManager.WorkDir())
+	}); err != nil {
 		return fmt.Errorf("failed to update vegavisor config: %w", err)
 	}
 
 	n.logger.Info("Updating vega config")
-	if err := updateVegaConfig(n.pathManager.VegaHome(), n.pathManager.WorkDir(), *restartSnapshot); err != nil {
+	if err := n.setupStep(ctx, "update-vega-config", func(context.Context) error {
+		return updateVegaConfig(n.pathManager.VegaHome(), n.pathManager.WorkDir(), *restartSnapshot)
+	}); err != nil {
 		return fmt.Errorf("failed to update vega config: %w", err)
 	}
 
 	n.logger.Info("Updating tendermint config")
-	if err := updateTendermintConfig(
-		n.pathManager.TendermintHome(),
-		rpcPeers,
-		n.conf.Seeds,
-		*restartSnapshot,
-		externalAddress,
-	); err != nil {
+	if err := n.setupStep(ctx, "update-tendermint-config", func(context.Context) error {
+		return updateTendermintConfig(
+			n.pathManager.TendermintHome(),
+			rpcPeers,
+			n.conf.Seeds,
+			*restartSnapshot,
+			externalAddress,
+		)
+	}); err != nil {
 		return fmt.Errorf("failed to update tendermint config: %w", err)
 	}
 
 	n.logger.Info("Updating data-node config")
-	if err := updateDataNodeConfig(n.pathManager.VegaHome(), bootstrapPeers, psqlCreds); err != nil {
+	if err := n.setupStep(ctx, "update-data-node-config", func(context.Context) error {
+		return updateDataNodeConfig(n.pathManager.VegaHome(), bootstrapPeers, psqlCreds)
+	}); err != nil {
 		return fmt.Errorf("failed to update data-node config: %w", err)
 	}
 
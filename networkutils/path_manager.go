@@ -52,10 +52,26 @@ func (pm PathManager) LogFile(fileName string) string {
 	return filepath.Join(pm.Logs(), fileName)
 }
 
+func (pm PathManager) WatchdogMetrics() string {
+	return pm.LogFile("watchdog-metrics.jsonl")
+}
+
 func (pm PathManager) Results() string {
 	return filepath.Join(pm.workDir, "results.json")
 }
 
+func (pm PathManager) JUnitReport() string {
+	return filepath.Join(pm.workDir, "results.xml")
+}
+
+func (pm PathManager) HTMLReport(fileName string) string {
+	return filepath.Join(pm.workDir, fileName)
+}
+
+func (pm PathManager) History() string {
+	return filepath.Join(pm.workDir, "history.jsonl")
+}
+
 func (pm PathManager) fileExists(filePath string) bool {
 	_, err := os.Stat(filePath)
 	if os.IsNotExist(err) {
@@ -0,0 +1,52 @@
+package networkutils
+
+import (
+	"context"
+	"time"
+)
+
+// SetupStep is a single step of the local node setup.
+type SetupStep struct {
+	Name      string
+	StartedAt time.Time
+	Duration  time.Duration
+	Err       error
+}
+
+// SetupReport describes how the local node was set up. Steps are reported also when the setup failed,
+// the failed step is the last one.
+type SetupReport struct {
+	Steps []SetupStep
+	// ID of the trace with the setup steps, empty when tracing is disabled
+	TraceID string
+
+	ChainID           string
+	AppVersion        string
+	NetworkHeadHeight uint64
+	RestartSnapshot   *Snapshot
+}
+
+// SetupReport returns steps executed by the SetupLocalNode with the network details it found.
+func (n *Network) SetupReport() SetupReport {
+	report := n.setupReport
+	report.Steps = append([]SetupStep{}, n.setupReport.Steps...)
+
+	return report
+}
+
+// setupStep executes the step of the local node setup and records it in the setup report. The step
+// is traced as the span, remote calls made by the step are its child spans.
+func (n *Network) setupStep(ctx context.Context, name string, step func(ctx context.Context) error) error {
+	ctx, span := tracer.Start(ctx, name)
+	startedAt := time.Now()
+	err := step(ctx)
+	endSpan(span, err)
+	n.setupReport.Steps = append(n.setupReport.Steps, SetupStep{
+		Name:      name,
+		StartedAt: startedAt,
+		Duration:  time.Since(startedAt),
+		Err:       err,
+	})
+
+	return err
+}
@@ -0,0 +1,117 @@
+package networkutils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type rawTendermintStatus struct {
+	Result struct {
+		SyncInfo struct {
+			LatestBlockHeight string `json:"latest_block_height"`
+		} `json:"sync_info"`
+	} `json:"result"`
+}
+
+type rawTendermintBlock struct {
+	Result struct {
+		BlockID struct {
+			Hash string `json:"hash"`
+		} `json:"block_id"`
+		Block struct {
+			Header struct {
+				Height  string `json:"height"`
+				AppHash string `json:"app_hash"`
+			} `json:"header"`
+		} `json:"block"`
+	} `json:"result"`
+}
+
+type TendermintBlock struct {
+	Height    uint64
+	BlockHash string
+	AppHash   string
+}
+
+// TendermintRPCURL returns the HTTP URL for the RPC peer endpoint, which is usually given as host:port.
+func TendermintRPCURL(endpoint string) string {
+	if strings.HasPrefix(endpoint, "http://") || strings.HasPrefix(endpoint, "https://") {
+		return strings.TrimRight(endpoint, "/")
+	}
+
+	return fmt.Sprintf("http://%s", strings.TrimRight(endpoint, "/"))
+}
+
+func getTendermintRPC(ctx context.Context, httpClient *http.Client, url string, result any) error {
+	ctx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	defer cancel()
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create tendermint rpc request: %w", err)
+	}
+
+	resp, err := httpClient.Do(request)
+	if err != nil {
+		return fmt.Errorf("failed to send get query to the tendermint rpc: %w", err)
+	}
+
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read tendermint rpc response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("tendermint rpc returned unexpected status code %d: %s", resp.StatusCode, string(body))
+	}
+
+	if err := json.Unmarshal(body, result); err != nil {
+		return fmt.Errorf("failed to unmarshal tendermint rpc response: %w", err)
+	}
+
+	return nil
+}
+
+// GetTendermintHeight returns the latest block height known by the tendermint node.
+func GetTendermintHeight(ctx context.Context, httpClient *http.Client, rpcURL string) (uint64, error) {
+	rawResult := &rawTendermintStatus{}
+	if err := getTendermintRPC(ctx, httpClient, fmt.Sprintf("%s/status", TendermintRPCURL(rpcURL)), rawResult); err != nil {
+		return 0, fmt.Errorf("failed to get tendermint status: %w", err)
+	}
+
+	height, err := strconv.ParseUint(rawResult.Result.SyncInfo.LatestBlockHeight, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse latest block height: %w", err)
+	}
+
+	return height, nil
+}
+
+// GetTendermintBlock returns the block hash and the app hash for the block at the given height.
+func GetTendermintBlock(ctx context.Context, httpClient *http.Client, rpcURL string, height uint64) (*TendermintBlock, error) {
+	rawResult := &rawTendermintBlock{}
+	blockURL := fmt.Sprintf("%s/block?height=%d", TendermintRPCURL(rpcURL), height)
+	if err := getTendermintRPC(ctx, httpClient, blockURL, rawResult); err != nil {
+		return nil, fmt.Errorf("failed to get block %d: %w", height, err)
+	}
+
+	blockHeight, err := strconv.ParseUint(rawResult.Result.Block.Header.Height, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse block height: %w", err)
+	}
+
+	if blockHeight != height {
+		return nil, fmt.Errorf("tendermint returned block %d instead of %d", blockHeight, height)
+	}
+
+	return &TendermintBlock{
+		Height:    blockHeight,
+		BlockHash: rawResult.Result.BlockID.Hash,
+		AppHash:   rawResult.Result.Block.Header.AppHash,
+	}, nil
+}
@@ -0,0 +1,41 @@
+package networkutils
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer uses the global tracer provider, it does nothing until the provider is set up.
+var tracer = otel.Tracer("github.com/vegaprotocol/snapshot-testing/networkutils")
+
+// startClientSpan starts the span of the remote call when the context is already traced, e.g. by the
+// setup step. Calls made outside of the traced operations, like the watchdog probes, are not traced.
+func startClientSpan(ctx context.Context, name string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	if !trace.SpanFromContext(ctx).IsRecording() {
+		return ctx, trace.SpanFromContext(context.Background())
+	}
+
+	return tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attributes...))
+}
+
+// endSpan ends the span and marks it failed when err is not nil.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// traceID returns ID of the trace the span belongs to, or empty string when the span is not traced.
+func traceID(span trace.Span) string {
+	if !span.SpanContext().HasTraceID() {
+		return ""
+	}
+
+	return span.SpanContext().TraceID().String()
+}
@@ -0,0 +1,98 @@
+package notifications
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/vegaprotocol/snapshot-testing/components"
+	"github.com/vegaprotocol/snapshot-testing/config"
+)
+
+// Notification is sent to the webhooks. It is also the data of the webhook body template.
+type Notification struct {
+	Event config.NotificationEvent `json:"event"`
+	Time  time.Time                `json:"time"`
+	// Work dir of the run, with the phase name for the scenario run phases
+	Run         string `json:"run"`
+	Environment string `json:"environment"`
+	// Human readable summary of the notification
+	Message string `json:"message"`
+
+	// Set for the run_failed and run_finished events
+	Status            components.HealthyStatus `json:"status,omitempty"`
+	ReasonCode        components.ReasonCode    `json:"reason_code,omitempty"`
+	Reason            string                   `json:"reason,omitempty"`
+	ShouldSkipFailure bool                     `json:"should_skip_failure"`
+	ChainID           string                   `json:"chain_id,omitempty"`
+	AppVersion        string                   `json:"app_version,omitempty"`
+
+	// Set for the state_changed event
+	PreviousState   components.NodeState  `json:"previous_state,omitempty"`
+	State           components.NodeState  `json:"state,omitempty"`
+	StateReason     components.ReasonCode `json:"state_reason,omitempty"`
+	LocalCoreHeight uint64                `json:"local_core_height,omitempty"`
+	NetworkHeight   uint64                `json:"network_height,omitempty"`
+
+	// Full results of the run, available in the body template only
+	Results *components.Results `json:"-"`
+}
+
+// Failed tells if the finished run should be reported as the failure.
+func (n Notification) Failed() bool {
+	return n.Status != components.Healthy && !n.ShouldSkipFailure
+}
+
+// RunFinished creates the notification about the finished run.
+func RunFinished(run, environment string, results *components.Results) Notification {
+	notification := Notification{
+		Event:             config.NotificationRunFinished,
+		Time:              time.Now(),
+		Run:               run,
+		Environment:       environment,
+		Status:            results.Status,
+		ReasonCode:        results.ReasonCode,
+		Reason:            results.Reason,
+		ShouldSkipFailure: results.ShouldSkipFailure,
+		Results:           results,
+	}
+	if results.Setup != nil {
+		notification.ChainID = results.Setup.ChainID
+		notification.AppVersion = results.Setup.AppVersion
+	}
+
+	if notification.Failed() {
+		notification.Event = config.NotificationRunFailed
+	}
+	notification.Message = fmt.Sprintf("Snapshot testing of %s finished with the %s status", environment, results.Status)
+	if results.Reason != "" {
+		notification.Message += ": " + results.Reason
+	}
+	if results.ShouldSkipFailure {
+		notification.Message += " (failure should be skipped)"
+	}
+
+	return notification
+}
+
+// StateChanged creates the notification about the new state of the local node.
+func StateChanged(run, environment string, change components.NodeStateChange) Notification {
+	return Notification{
+		Event:           config.NotificationStateChanged,
+		Time:            change.Time,
+		Run:             run,
+		Environment:     environment,
+		PreviousState:   change.PreviousState,
+		State:           change.State,
+		StateReason:     change.Reason,
+		LocalCoreHeight: change.LocalCoreHeight,
+		NetworkHeight:   change.NetworkHeight,
+		Message: fmt.Sprintf(
+			"Local node of %s moved from %s to %s at block %d, network is at block %d",
+			environment,
+			change.PreviousState,
+			change.State,
+			change.LocalCoreHeight,
+			change.NetworkHeight,
+		),
+	}
+}
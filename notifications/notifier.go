@@ -0,0 +1,85 @@
+package notifications
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/vegaprotocol/snapshot-testing/components"
+	"github.com/vegaprotocol/snapshot-testing/config"
+)
+
+// Notifier sends notifications about the run to all configured webhooks.
+type Notifier struct {
+	logger      *zap.Logger
+	webhooks    []*webhook
+	run         string
+	environment string
+
+	// pending tracks state change notifications sent in the background
+	pending sync.WaitGroup
+}
+
+func NewNotifier(conf config.Notifications, run, environment string, logger *zap.Logger) (*Notifier, error) {
+	webhooks := []*webhook{}
+	for _, webhookConf := range conf.Webhooks {
+		w, err := newWebhook(webhookConf)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create webhook: %w", err)
+		}
+		webhooks = append(webhooks, w)
+	}
+
+	return &Notifier{
+		logger:      logger,
+		webhooks:    webhooks,
+		run:         run,
+		environment: environment,
+	}, nil
+}
+
+// RunFinished notifies webhooks about the finished run and waits until all notifications are sent.
+func (n *Notifier) RunFinished(results *components.Results) {
+	n.Send(RunFinished(n.run, n.environment, results))
+}
+
+// Send sends the notification to the interested webhooks and waits until it is sent, together with
+// the state change notifications still pending.
+func (n *Notifier) Send(notification Notification) {
+	n.send(notification)
+	n.pending.Wait()
+}
+
+// NodeStateChanged implements components.StateListener. The notification is sent in the background,
+// so the watchdog is not blocked.
+func (n *Notifier) NodeStateChanged(change components.NodeStateChange) {
+	notification := StateChanged(n.run, n.environment, change)
+
+	n.pending.Add(1)
+	go func() {
+		defer n.pending.Done()
+		n.send(notification)
+	}()
+}
+
+func (n *Notifier) send(notification Notification) {
+	for _, w := range n.webhooks {
+		if !w.accepts(notification) {
+			continue
+		}
+
+		n.logger.Sugar().Infof("Sending the %s notification to the %s webhook", notification.Event, w.conf.Name)
+		// Timeout bounds all of the retries
+		ctx, cancel := context.WithTimeout(
+			context.Background(),
+			time.Duration(*w.conf.Retries+1)*(w.conf.Timeout+w.conf.RetryInterval),
+		)
+		if err := w.send(ctx, notification); err != nil {
+			n.logger.Error("Failed to send notification", zap.Error(err))
+		}
+		cancel()
+	}
+}
@@ -0,0 +1,148 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"text/template"
+	"time"
+
+	"github.com/vegaprotocol/snapshot-testing/config"
+)
+
+// webhook sends notifications as HTTP POST requests.
+type webhook struct {
+	conf       config.Webhook
+	body       *template.Template // Nil when the notification is sent as JSON
+	httpClient *http.Client
+}
+
+var templateFuncs = template.FuncMap{
+	// json quotes the value, so it can be safely embedded into the JSON body
+	"json": func(value any) (string, error) {
+		content, err := json.Marshal(value)
+		if err != nil {
+			return "", err
+		}
+		return string(content), nil
+	},
+}
+
+func newWebhook(conf config.Webhook) (*webhook, error) {
+	conf = conf.Merge(config.DefaultWebhook)
+	if conf.Name == "" {
+		conf.Name = conf.URL
+	}
+
+	if err := conf.Validate(); err != nil {
+		return nil, err
+	}
+
+	w := &webhook{
+		conf:       conf,
+		httpClient: &http.Client{Timeout: conf.Timeout},
+	}
+
+	if conf.Body != "" {
+		body, err := template.New(conf.Name).Funcs(templateFuncs).Parse(conf.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse body template of the %s webhook: %w", conf.Name, err)
+		}
+		w.body = body
+	}
+
+	return w, nil
+}
+
+// accepts tells if the webhook is interested in the notification.
+func (w *webhook) accepts(notification Notification) bool {
+	switch notification.Event {
+	case config.NotificationStateChanged:
+		return slices.Contains(w.conf.Events, config.NotificationStateChanged) &&
+			(len(w.conf.States) == 0 || slices.Contains(w.conf.States, string(notification.State)))
+	case config.NotificationRunFailed:
+		// Failure is also the finish of the run
+		return slices.Contains(w.conf.Events, config.NotificationRunFailed) ||
+			slices.Contains(w.conf.Events, config.NotificationRunFinished)
+	default:
+		return slices.Contains(w.conf.Events, notification.Event)
+	}
+}
+
+// send posts the notification and retries when the request failed.
+func (w *webhook) send(ctx context.Context, notification Notification) error {
+	body, err := w.renderBody(notification)
+	if err != nil {
+		return err
+	}
+
+	var sendErr error
+	for attempt := 0; attempt <= *w.conf.Retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(w.conf.RetryInterval):
+			case <-ctx.Done():
+				return fmt.Errorf("failed to send notification to the %s webhook: %w", w.conf.Name, sendErr)
+			}
+		}
+
+		var retryable bool
+		retryable, sendErr = w.post(ctx, body)
+		if sendErr == nil || !retryable {
+			break
+		}
+	}
+
+	if sendErr != nil {
+		return fmt.Errorf("failed to send notification to the %s webhook: %w", w.conf.Name, sendErr)
+	}
+
+	return nil
+}
+
+func (w *webhook) renderBody(notification Notification) ([]byte, error) {
+	if w.body == nil {
+		content, err := json.Marshal(notification)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal notification: %w", err)
+		}
+		return content, nil
+	}
+
+	buf := &bytes.Buffer{}
+	if err := w.body.Execute(buf, notification); err != nil {
+		return nil, fmt.Errorf("failed to execute body template of the %s webhook: %w", w.conf.Name, err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// post sends the request once. It also tells if the failed request is worth retrying.
+func (w *webhook) post(ctx context.Context, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.conf.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("failed to create request: %w", err)
+	}
+	for name, value := range w.conf.Headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := w.httpClient.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	retryable := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+
+	return retryable, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, string(respBody))
+}
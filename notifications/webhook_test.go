@@ -0,0 +1,230 @@
+package notifications
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/vegaprotocol/snapshot-testing/components"
+	"github.com/vegaprotocol/snapshot-testing/config"
+)
+
+type receivedRequest struct {
+	headers http.Header
+	body    string
+}
+
+// webhookServer records the received requests and responds with the given status codes, the last
+// status code is repeated for the remaining requests.
+type webhookServer struct {
+	*httptest.Server
+
+	mut         sync.Mutex
+	requests    []receivedRequest
+	statusCodes []int
+}
+
+func newWebhookServer(t *testing.T, statusCodes ...int) *webhookServer {
+	ws := &webhookServer{statusCodes: statusCodes}
+	ws.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		ws.mut.Lock()
+		ws.requests = append(ws.requests, receivedRequest{headers: r.Header.Clone(), body: string(body)})
+		statusCode := http.StatusOK
+		if len(ws.statusCodes) > 0 {
+			statusCode = ws.statusCodes[min(len(ws.requests), len(ws.statusCodes))-1]
+		}
+		ws.mut.Unlock()
+
+		w.WriteHeader(statusCode)
+	}))
+	t.Cleanup(ws.Close)
+
+	return ws
+}
+
+func (ws *webhookServer) received() []receivedRequest {
+	ws.mut.Lock()
+	defer ws.mut.Unlock()
+
+	return append([]receivedRequest{}, ws.requests...)
+}
+
+func intPointer(value int) *int {
+	return &value
+}
+
+func failedResults(shouldSkipFailure bool) *components.Results {
+	results := components.NewResults(time.Now())
+	results.SetStatus(components.Unhealthy, components.ReasonNeverCaughtUp, "Node never caught up rest of the network")
+	results.ShouldSkipFailure = shouldSkipFailure
+
+	return results
+}
+
+func TestWebhookSendsTemplatedBodyWithHeaders(t *testing.T) {
+	server := newWebhookServer(t)
+
+	w, err := newWebhook(config.Webhook{
+		URL:     server.URL,
+		Headers: map[string]string{"Authorization": "Bearer TOKEN"},
+		Body:    `{"text": {{ .Message | json }}, "status": "{{ .Status }}", "env": "{{ .Environment }}"}`,
+	})
+	if err != nil {
+		t.Fatalf("failed to create webhook: %v", err)
+	}
+
+	notification := RunFinished("/tmp/run", "devnet", failedResults(false))
+	if err := w.send(context.Background(), notification); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	requests := server.received()
+	if len(requests) != 1 {
+		t.Fatalf("expected 1 request, got %d", len(requests))
+	}
+
+	expectedBody := `{"text": "Snapshot testing of devnet finished with the UNHEALTHY status: Node never caught up rest of the network", "status": "UNHEALTHY", "env": "devnet"}`
+	if requests[0].body != expectedBody {
+		t.Errorf("expected body %s, got %s", expectedBody, requests[0].body)
+	}
+	if header := requests[0].headers.Get("Authorization"); header != "Bearer TOKEN" {
+		t.Errorf("expected the custom Authorization header, got %q", header)
+	}
+	if header := requests[0].headers.Get("Content-Type"); header != "application/json" {
+		t.Errorf("expected the default Content-Type header, got %q", header)
+	}
+}
+
+func TestWebhookRetries(t *testing.T) {
+	testCases := []struct {
+		name             string
+		retries          *int
+		statusCodes      []int
+		expectedRequests int
+		expectedErr      bool
+	}{
+		{
+			name:             "retried after 5xx",
+			retries:          intPointer(3),
+			statusCodes:      []int{http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusOK},
+			expectedRequests: 3,
+		},
+		{
+			name:             "gives up after the retries",
+			retries:          intPointer(2),
+			statusCodes:      []int{http.StatusInternalServerError},
+			expectedRequests: 3,
+			expectedErr:      true,
+		},
+		{
+			name:             "default retries",
+			statusCodes:      []int{http.StatusInternalServerError},
+			expectedRequests: 4,
+			expectedErr:      true,
+		},
+		{
+			name:             "zero retries",
+			retries:          intPointer(0),
+			statusCodes:      []int{http.StatusServiceUnavailable, http.StatusOK},
+			expectedRequests: 1,
+			expectedErr:      true,
+		},
+		{
+			name:             "4xx is not retried",
+			retries:          intPointer(3),
+			statusCodes:      []int{http.StatusBadRequest, http.StatusOK},
+			expectedRequests: 1,
+			expectedErr:      true,
+		},
+		{
+			name:             "429 is retried",
+			retries:          intPointer(3),
+			statusCodes:      []int{http.StatusTooManyRequests, http.StatusOK},
+			expectedRequests: 2,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := newWebhookServer(t, tc.statusCodes...)
+
+			w, err := newWebhook(config.Webhook{
+				URL:           server.URL,
+				Retries:       tc.retries,
+				RetryInterval: time.Millisecond,
+			})
+			if err != nil {
+				t.Fatalf("failed to create webhook: %v", err)
+			}
+
+			err = w.send(context.Background(), RunFinished("/tmp/run", "devnet", failedResults(false)))
+			if tc.expectedErr && err == nil {
+				t.Error("expected error")
+			}
+			if !tc.expectedErr && err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+
+			if requests := server.received(); len(requests) != tc.expectedRequests {
+				t.Errorf("expected %d requests, got %d", tc.expectedRequests, len(requests))
+			}
+		})
+	}
+}
+
+func TestNotifierSkipsFailureThatShouldBeSkipped(t *testing.T) {
+	testCases := []struct {
+		name              string
+		events            []config.NotificationEvent
+		shouldSkipFailure bool
+		expectedRequests  int
+	}{
+		{
+			name:             "failure",
+			events:           []config.NotificationEvent{config.NotificationRunFailed},
+			expectedRequests: 1,
+		},
+		{
+			name:              "failure that should be skipped",
+			events:            []config.NotificationEvent{config.NotificationRunFailed},
+			shouldSkipFailure: true,
+			expectedRequests:  0,
+		},
+		{
+			name:              "finished run with the failure that should be skipped",
+			events:            []config.NotificationEvent{config.NotificationRunFinished},
+			shouldSkipFailure: true,
+			expectedRequests:  1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := newWebhookServer(t)
+
+			notifier, err := NewNotifier(
+				config.Notifications{Webhooks: []config.Webhook{{URL: server.URL, Events: tc.events}}},
+				"/tmp/run",
+				"devnet",
+				zap.NewNop(),
+			)
+			if err != nil {
+				t.Fatalf("failed to create notifier: %v", err)
+			}
+
+			notifier.RunFinished(failedResults(tc.shouldSkipFailure))
+
+			if requests := server.received(); len(requests) != tc.expectedRequests {
+				t.Errorf("expected %d requests, got %d", tc.expectedRequests, len(requests))
+			}
+		})
+	}
+}
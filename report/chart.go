@@ -0,0 +1,194 @@
+package report
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/vegaprotocol/snapshot-testing/components"
+)
+
+const (
+	chartWidth   = 960
+	chartHeight  = 320
+	chartPadding = 60
+	// Max number of points drawn for each series, longer series are downsampled
+	chartMaxPoints = 1000
+)
+
+// svgChart is the line chart rendered as inline SVG, so the report has no external assets.
+type svgChart struct {
+	Title   string
+	Width   int
+	Height  int
+	Padding int
+	// Vertical position of the time labels, below the plot
+	XLabelsY int
+	Series   []svgSeries
+	YTicks   []svgTick
+	XTicks   []svgTick
+}
+
+type svgSeries struct {
+	Name   string
+	Color  string
+	Points string
+}
+
+type svgTick struct {
+	Position float64
+	Label    string
+}
+
+type chartSeriesSpec struct {
+	name  string
+	color string
+	value func(components.ProbeMetrics) (float64, bool)
+}
+
+// newSVGChart draws the series of values taken from the probe metrics.
+func newSVGChart(title string, metrics []components.ProbeMetrics, specs ...chartSeriesSpec) *svgChart {
+	if len(metrics) < 2 {
+		return nil
+	}
+
+	metrics = downsample(metrics, chartMaxPoints)
+	start := metrics[0].Time
+	end := metrics[len(metrics)-1].Time
+	span := end.Sub(start).Seconds()
+	if span <= 0 {
+		return nil
+	}
+
+	yMin, yMax := math.Inf(1), math.Inf(-1)
+	for _, probe := range metrics {
+		for _, spec := range specs {
+			if value, ok := spec.value(probe); ok {
+				yMin = math.Min(yMin, value)
+				yMax = math.Max(yMax, value)
+			}
+		}
+	}
+	if math.IsInf(yMin, 1) {
+		return nil
+	}
+	if yMax == yMin {
+		yMax = yMin + 1
+	}
+
+	plotWidth := float64(chartWidth - 2*chartPadding)
+	plotHeight := float64(chartHeight - 2*chartPadding)
+	x := func(t time.Time) float64 {
+		return chartPadding + t.Sub(start).Seconds()/span*plotWidth
+	}
+	y := func(value float64) float64 {
+		return chartHeight - chartPadding - (value-yMin)/(yMax-yMin)*plotHeight
+	}
+
+	chart := &svgChart{
+		Title:    title,
+		Width:    chartWidth,
+		Height:   chartHeight,
+		Padding:  chartPadding,
+		XLabelsY: chartHeight - chartPadding + 16,
+	}
+
+	for _, spec := range specs {
+		points := strings.Builder{}
+		for _, probe := range metrics {
+			value, ok := spec.value(probe)
+			if !ok {
+				continue
+			}
+			fmt.Fprintf(&points, "%.1f,%.1f ", x(probe.Time), y(value))
+		}
+		chart.Series = append(chart.Series, svgSeries{
+			Name:   spec.name,
+			Color:  spec.color,
+			Points: strings.TrimSpace(points.String()),
+		})
+	}
+
+	const ticks = 4
+	for i := 0; i <= ticks; i++ {
+		value := yMin + (yMax-yMin)*float64(i)/ticks
+		chart.YTicks = append(chart.YTicks, svgTick{
+			Position: y(value),
+			Label:    fmt.Sprintf("%.0f", value),
+		})
+
+		tickTime := start.Add(time.Duration(span * float64(i) / ticks * float64(time.Second)))
+		chart.XTicks = append(chart.XTicks, svgTick{
+			Position: x(tickTime),
+			Label:    tickTime.UTC().Format("01-02 15:04"),
+		})
+	}
+
+	return chart
+}
+
+// downsample keeps every n-th probe, so at most maxPoints probes are left. The last probe is always kept.
+func downsample(metrics []components.ProbeMetrics, maxPoints int) []components.ProbeMetrics {
+	if len(metrics) <= maxPoints {
+		return metrics
+	}
+
+	step := int(math.Ceil(float64(len(metrics)) / float64(maxPoints-1)))
+	result := []components.ProbeMetrics{}
+	for i := 0; i < len(metrics); i += step {
+		result = append(result, metrics[i])
+	}
+	if result[len(result)-1].Time != metrics[len(metrics)-1].Time {
+		result = append(result, metrics[len(metrics)-1])
+	}
+
+	return result
+}
+
+func heightChart(metrics []components.ProbeMetrics) *svgChart {
+	return newSVGChart("Block height",
+		metrics,
+		chartSeriesSpec{
+			name:  "network",
+			color: "#1f77b4",
+			value: func(probe components.ProbeMetrics) (float64, bool) {
+				return float64(probe.NetworkHeight), probe.NetworkHeight > 0
+			},
+		},
+		chartSeriesSpec{
+			name:  "local core",
+			color: "#ff7f0e",
+			value: func(probe components.ProbeMetrics) (float64, bool) {
+				return float64(probe.LocalCoreHeight), probe.LocalNodeUp
+			},
+		},
+		chartSeriesSpec{
+			name:  "local data-node",
+			color: "#2ca02c",
+			value: func(probe components.ProbeMetrics) (float64, bool) {
+				return float64(probe.LocalDataNodeHeight), probe.DataNodeLagKnown()
+			},
+		},
+	)
+}
+
+func lagChart(metrics []components.ProbeMetrics) *svgChart {
+	return newSVGChart("Lag in blocks",
+		metrics,
+		chartSeriesSpec{
+			name:  "core lag",
+			color: "#d62728",
+			value: func(probe components.ProbeMetrics) (float64, bool) {
+				return float64(probe.CoreLag), probe.LocalNodeUp
+			},
+		},
+		chartSeriesSpec{
+			name:  "data-node lag",
+			color: "#9467bd",
+			value: func(probe components.ProbeMetrics) (float64, bool) {
+				return float64(probe.DataNodeLag), probe.DataNodeLagKnown()
+			},
+		},
+	)
+}
@@ -0,0 +1,115 @@
+package report
+
+import (
+	"fmt"
+
+	"github.com/vegaprotocol/snapshot-testing/components"
+)
+
+type RegressionKind string
+
+const (
+	RegressionStatus    RegressionKind = "STATUS"
+	RegressionCatchUp   RegressionKind = "CATCH_UP_DURATION"
+	RegressionMaxLag    RegressionKind = "MAX_LAG"
+	RegressionSnapshots RegressionKind = "SNAPSHOTS"
+)
+
+// Thresholds describe how much worse than the baseline the run can be before it is reported as a regression.
+type Thresholds struct {
+	// Max increase of the catch-up duration in percent
+	CatchUpDurationPercent float64
+	// Max increase of the max core and data-node lag in percent
+	MaxLagPercent float64
+	// Min increase of the max core and data-node lag in blocks, so a few blocks of lag against the
+	// baseline without any lag are not reported
+	MinLagIncrease uint64
+}
+
+var DefaultThresholds = Thresholds{
+	CatchUpDurationPercent: 20,
+	MaxLagPercent:          50,
+	MinLagIncrease:         10,
+}
+
+type Regression struct {
+	Run      string         `json:"run"`
+	Baseline string         `json:"baseline"`
+	Kind     RegressionKind `json:"kind"`
+	Message  string         `json:"message"`
+}
+
+// Compare compares every run with the baseline, the first run, and returns the regressions.
+func Compare(summaries []RunSummary, thresholds Thresholds) []Regression {
+	regressions := []Regression{}
+	if len(summaries) < 2 {
+		return regressions
+	}
+
+	baseline := summaries[0]
+	for _, run := range summaries[1:] {
+		regression := func(kind RegressionKind, format string, args ...any) {
+			regressions = append(regressions, Regression{
+				Run:      run.Name,
+				Baseline: baseline.Name,
+				Kind:     kind,
+				Message:  fmt.Sprintf(format, args...),
+			})
+		}
+
+		if baseline.Status == components.Healthy && run.Status != components.Healthy {
+			regression(RegressionStatus, "status is %s(%s), baseline is %s", run.Status, run.ReasonCode, baseline.Status)
+		}
+
+		switch {
+		case baseline.CatchUpDurationSeconds == nil:
+		case run.CatchUpDurationSeconds == nil:
+			regression(RegressionCatchUp, "node never caught up, baseline caught up in %s", formatSeconds(baseline.CatchUpDurationSeconds))
+		case exceeds(*run.CatchUpDurationSeconds, *baseline.CatchUpDurationSeconds, thresholds.CatchUpDurationPercent, 0):
+			regression(
+				RegressionCatchUp,
+				"catch-up took %s, baseline %s, threshold +%.0f%%",
+				formatSeconds(run.CatchUpDurationSeconds),
+				formatSeconds(baseline.CatchUpDurationSeconds),
+				thresholds.CatchUpDurationPercent,
+			)
+		}
+
+		if exceeds(float64(run.MaxCoreLag), float64(baseline.MaxCoreLag), thresholds.MaxLagPercent, float64(thresholds.MinLagIncrease)) {
+			regression(
+				RegressionMaxLag,
+				"max core lag is %d blocks, baseline %d, threshold +%.0f%%",
+				run.MaxCoreLag,
+				baseline.MaxCoreLag,
+				thresholds.MaxLagPercent,
+			)
+		}
+		if exceeds(float64(run.MaxDataNodeLag), float64(baseline.MaxDataNodeLag), thresholds.MaxLagPercent, float64(thresholds.MinLagIncrease)) {
+			regression(
+				RegressionMaxLag,
+				"max data-node lag is %d blocks, baseline %d, threshold +%.0f%%",
+				run.MaxDataNodeLag,
+				baseline.MaxDataNodeLag,
+				thresholds.MaxLagPercent,
+			)
+		}
+
+		if baseline.producedSnapshots() && !run.producedSnapshots() {
+			regression(
+				RegressionSnapshots,
+				"node did not produce any snapshot, the latest is at block %d, baseline produced snapshots up to block %d",
+				run.SnapshotMax,
+				baseline.SnapshotMax,
+			)
+		}
+	}
+
+	return regressions
+}
+
+// exceeds tells if the value is more than the given percent above the baseline, and the increase is more
+// than the minIncrease. Any increase is more than the percent of the zero baseline, so the minIncrease
+// keeps the small values from being reported.
+func exceeds(value, baseline, percent, minIncrease float64) bool {
+	return value-baseline > minIncrease && value > baseline*(1+percent/100)
+}
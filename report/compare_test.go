@@ -0,0 +1,160 @@
+package report
+
+import (
+	"testing"
+
+	"github.com/vegaprotocol/snapshot-testing/components"
+)
+
+func float64Pointer(value float64) *float64 {
+	return &value
+}
+
+func TestExceeds(t *testing.T) {
+	testCases := []struct {
+		name        string
+		value       float64
+		baseline    float64
+		percent     float64
+		minIncrease float64
+		expected    bool
+	}{
+		{name: "below the percent", value: 110, baseline: 100, percent: 20, expected: false},
+		{name: "at the percent", value: 120, baseline: 100, percent: 20, expected: false},
+		{name: "above the percent", value: 121, baseline: 100, percent: 20, expected: true},
+		{name: "decrease", value: 50, baseline: 100, percent: 20, expected: false},
+		{name: "zero baseline without min increase", value: 1, baseline: 0, percent: 50, expected: true},
+		{name: "zero baseline within the min increase", value: 10, baseline: 0, percent: 50, minIncrease: 10, expected: false},
+		{name: "zero baseline above the min increase", value: 11, baseline: 0, percent: 50, minIncrease: 10, expected: true},
+		{name: "above the percent within the min increase", value: 8, baseline: 2, percent: 50, minIncrease: 10, expected: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := exceeds(tc.value, tc.baseline, tc.percent, tc.minIncrease); actual != tc.expected {
+				t.Errorf("expected %t, got %t", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestCompare(t *testing.T) {
+	baseline := RunSummary{
+		Name:                   "baseline",
+		Status:                 components.Healthy,
+		CatchUpDurationSeconds: float64Pointer(100),
+		MaxCoreLag:             0,
+		MaxDataNodeLag:         100,
+		RestartSnapshotHeight:  uint64Pointer(1000),
+		SnapshotMax:            1600,
+	}
+
+	testCases := []struct {
+		name          string
+		run           func(run *RunSummary)
+		expectedKinds []RegressionKind
+	}{
+		{
+			name: "same as the baseline",
+			run:  func(run *RunSummary) {},
+		},
+		{
+			name: "unhealthy status",
+			run: func(run *RunSummary) {
+				run.Status, run.ReasonCode = components.Unhealthy, components.ReasonStalled
+			},
+			expectedKinds: []RegressionKind{RegressionStatus},
+		},
+		{
+			name: "catch-up within the threshold",
+			run: func(run *RunSummary) {
+				run.CatchUpDurationSeconds = float64Pointer(120)
+			},
+		},
+		{
+			name: "catch-up above the threshold",
+			run: func(run *RunSummary) {
+				run.CatchUpDurationSeconds = float64Pointer(121)
+			},
+			expectedKinds: []RegressionKind{RegressionCatchUp},
+		},
+		{
+			name: "never caught up",
+			run: func(run *RunSummary) {
+				run.Status, run.ReasonCode = components.Unhealthy, components.ReasonNeverCaughtUp
+				run.CatchUpDurationSeconds = nil
+			},
+			expectedKinds: []RegressionKind{RegressionStatus, RegressionCatchUp},
+		},
+		{
+			name: "small lag against the baseline without lag",
+			run: func(run *RunSummary) {
+				run.MaxCoreLag = 5
+			},
+		},
+		{
+			name: "lag against the baseline without lag",
+			run: func(run *RunSummary) {
+				run.MaxCoreLag = 50
+			},
+			expectedKinds: []RegressionKind{RegressionMaxLag},
+		},
+		{
+			name: "data-node lag above the threshold",
+			run: func(run *RunSummary) {
+				run.MaxDataNodeLag = 151
+			},
+			expectedKinds: []RegressionKind{RegressionMaxLag},
+		},
+		{
+			name: "no snapshots produced",
+			run: func(run *RunSummary) {
+				run.SnapshotMax = 1000
+			},
+			expectedKinds: []RegressionKind{RegressionSnapshots},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			run := baseline
+			run.Name = "run"
+			tc.run(&run)
+
+			regressions := Compare([]RunSummary{baseline, run}, DefaultThresholds)
+			if len(regressions) != len(tc.expectedKinds) {
+				t.Fatalf("expected %d regressions, got %+v", len(tc.expectedKinds), regressions)
+			}
+			for i, regression := range regressions {
+				if regression.Kind != tc.expectedKinds[i] {
+					t.Errorf("expected the %s regression, got %+v", tc.expectedKinds[i], regression)
+				}
+				if regression.Run != "run" || regression.Baseline != "baseline" {
+					t.Errorf("expected the regression of the run against the baseline, got %+v", regression)
+				}
+			}
+		})
+	}
+}
+
+func TestRunSummaryUsesMetrics(t *testing.T) {
+	results := testResults(func(results *components.Results) {
+		results.Watchdog.Metrics = components.MetricsSummaryResults{MaxCoreLag: 1, MaxDataNodeLag: 1}
+	})
+	metrics := testMetrics(results.StartedAt, 3)
+	metrics[0].DataNodeLag = 30
+	// Lag of the data-node that did not respond is not known
+	metrics[1].LocalDataNodeDown = true
+	metrics[1].DataNodeLag = 3000
+
+	summary := Run{Name: "run", Results: results, Metrics: metrics}.Summary()
+	if summary.MaxCoreLag != metrics[0].CoreLag {
+		t.Errorf("expected the max core lag %d from the metrics, got %d", metrics[0].CoreLag, summary.MaxCoreLag)
+	}
+	if summary.MaxDataNodeLag != 30 {
+		t.Errorf("expected the max data-node lag 30 from the metrics, got %d", summary.MaxDataNodeLag)
+	}
+	if summary.Snapshots != 3 || summary.SnapshotMax != 1600 {
+		t.Errorf("expected 3 snapshots up to 1600, got %d up to %d", summary.Snapshots, summary.SnapshotMax)
+	}
+}
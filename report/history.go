@@ -0,0 +1,118 @@
+package report
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/vegaprotocol/snapshot-testing/components"
+)
+
+// HistoryEntry is a single run recorded in the history file. Runs are keyed by the environment, chain ID,
+// app version and the restart snapshot height.
+type HistoryEntry struct {
+	RecordedAt            time.Time  `json:"recorded_at"`
+	Environment           string     `json:"environment"`
+	ChainID               string     `json:"chain_id"`
+	AppVersion            string     `json:"app_version"`
+	RestartSnapshotHeight *uint64    `json:"restart_snapshot_height"`
+	ShouldSkipFailure     bool       `json:"should_skip_failure"`
+	Summary               RunSummary `json:"summary"`
+}
+
+// NewHistoryEntry creates the history entry for the run.
+func NewHistoryEntry(run Run, recordedAt time.Time) HistoryEntry {
+	entry := HistoryEntry{
+		RecordedAt:        recordedAt,
+		ShouldSkipFailure: run.Results.ShouldSkipFailure,
+		Summary:           run.Summary(),
+	}
+
+	if run.Results.Config != nil {
+		entry.Environment = run.Results.Config.Environment
+	}
+	if run.Results.Setup != nil {
+		entry.ChainID = run.Results.Setup.ChainID
+		entry.AppVersion = run.Results.Setup.AppVersion
+		entry.RestartSnapshotHeight = run.Results.Setup.RestartSnapshotHeight
+	}
+
+	return entry
+}
+
+// failed tells if the run failed because of the local node, runs that can skip the failure are not counted.
+func (he HistoryEntry) failed() bool {
+	return he.Summary.Status != components.Healthy && !he.ShouldSkipFailure
+}
+
+// AppendHistory appends the entry as a line of the history file. The file is created when it does not exist.
+func AppendHistory(historyFile string, entry HistoryEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal history entry: %w", err)
+	}
+
+	out, err := os.OpenFile(historyFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open history file: %w", err)
+	}
+	defer out.Close()
+
+	if _, err := out.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write history entry: %w", err)
+	}
+
+	return nil
+}
+
+// HistoryFilter selects entries of the history, empty fields match all entries.
+type HistoryFilter struct {
+	Environment string
+	ChainID     string
+	AppVersion  string
+}
+
+func (hf HistoryFilter) matches(entry HistoryEntry) bool {
+	return (hf.Environment == "" || hf.Environment == entry.Environment) &&
+		(hf.ChainID == "" || hf.ChainID == entry.ChainID) &&
+		(hf.AppVersion == "" || hf.AppVersion == entry.AppVersion)
+}
+
+// LoadHistory reads entries matching the filter from the history file, ordered by the record time.
+func LoadHistory(historyFile string, filter HistoryFilter) ([]HistoryEntry, error) {
+	in, err := os.Open(historyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history file: %w", err)
+	}
+	defer in.Close()
+
+	entries := []HistoryEntry{}
+	scanner := bufio.NewScanner(in)
+	// Lines are short, but the reason may contain long vegavisor messages
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		entry := HistoryEntry{}
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal history entry: %w", err)
+		}
+		if filter.matches(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history file: %w", err)
+	}
+
+	slices.SortStableFunc(entries, func(a, b HistoryEntry) int {
+		return a.RecordedAt.Compare(b.RecordedAt)
+	})
+
+	return entries, nil
+}
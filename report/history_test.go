@@ -0,0 +1,223 @@
+package report
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/vegaprotocol/snapshot-testing/components"
+)
+
+func TestMedian(t *testing.T) {
+	testCases := []struct {
+		name     string
+		values   []float64
+		expected float64
+	}{
+		{name: "empty", values: nil, expected: 0},
+		{name: "single value", values: []float64{5}, expected: 5},
+		{name: "odd count", values: []float64{9, 1, 5}, expected: 5},
+		{name: "even count", values: []float64{4, 1, 3, 2}, expected: 2.5},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			values := append([]float64{}, tc.values...)
+			if actual := median(values); actual != tc.expected {
+				t.Errorf("expected %f, got %f", tc.expected, actual)
+			}
+			for i := range values {
+				if values[i] != tc.values[i] {
+					t.Fatalf("expected the values not to be sorted in place, got %v", values)
+				}
+			}
+		})
+	}
+}
+
+// testHistoryEntry returns the healthy run of the app version with the given catch-up duration, recorded
+// the given number of hours after the first run.
+func testHistoryEntry(environment, chainID, appVersion string, hour int, catchUpSeconds float64) HistoryEntry {
+	return HistoryEntry{
+		RecordedAt:  time.Date(2024, 1, 1, hour, 0, 0, 0, time.UTC),
+		Environment: environment,
+		ChainID:     chainID,
+		AppVersion:  appVersion,
+		Summary: RunSummary{
+			Status:                 components.Healthy,
+			CatchUpDurationSeconds: float64Pointer(catchUpSeconds),
+		},
+	}
+}
+
+func failedEntry(entry HistoryEntry, shouldSkipFailure bool) HistoryEntry {
+	entry.Summary.Status = components.Unhealthy
+	entry.Summary.CatchUpDurationSeconds = nil
+	entry.ShouldSkipFailure = shouldSkipFailure
+
+	return entry
+}
+
+func TestNewHistory(t *testing.T) {
+	testCases := []struct {
+		name                string
+		entries             []HistoryEntry
+		expectedTrends      int
+		expectedRegressions []RegressionKind
+	}{
+		{
+			name: "same median catch-up",
+			entries: []HistoryEntry{
+				testHistoryEntry("devnet", "chain-1", "v1", 0, 100),
+				testHistoryEntry("devnet", "chain-1", "v1", 1, 300),
+				testHistoryEntry("devnet", "chain-1", "v2", 2, 110),
+				testHistoryEntry("devnet", "chain-1", "v2", 3, 90),
+			},
+			expectedTrends: 2,
+		},
+		{
+			name: "median catch-up regression",
+			entries: []HistoryEntry{
+				testHistoryEntry("devnet", "chain-1", "v1", 0, 100),
+				testHistoryEntry("devnet", "chain-1", "v2", 1, 150),
+				// Single slow run does not move the median
+				testHistoryEntry("devnet", "chain-1", "v2", 2, 1000),
+				testHistoryEntry("devnet", "chain-1", "v2", 3, 140),
+			},
+			expectedTrends:      2,
+			expectedRegressions: []RegressionKind{RegressionCatchUp},
+		},
+		{
+			name: "failure rate regression",
+			entries: []HistoryEntry{
+				testHistoryEntry("devnet", "chain-1", "v1", 0, 100),
+				testHistoryEntry("devnet", "chain-1", "v2", 1, 100),
+				failedEntry(testHistoryEntry("devnet", "chain-1", "v2", 2, 0), false),
+			},
+			expectedTrends:      2,
+			expectedRegressions: []RegressionKind{RegressionFailureRate},
+		},
+		{
+			name: "failures that can be skipped are not counted",
+			entries: []HistoryEntry{
+				testHistoryEntry("devnet", "chain-1", "v1", 0, 100),
+				testHistoryEntry("devnet", "chain-1", "v2", 1, 100),
+				failedEntry(testHistoryEntry("devnet", "chain-1", "v2", 2, 0), true),
+			},
+			expectedTrends: 2,
+		},
+		{
+			name: "versions of different environments are not compared",
+			entries: []HistoryEntry{
+				testHistoryEntry("devnet", "chain-1", "v1", 0, 100),
+				testHistoryEntry("stagnet", "chain-2", "v2", 1, 1000),
+			},
+			expectedTrends: 2,
+		},
+		{
+			name: "versions of different chains are not compared",
+			entries: []HistoryEntry{
+				testHistoryEntry("devnet", "chain-1", "v1", 0, 100),
+				testHistoryEntry("devnet", "chain-2", "v1", 1, 1000),
+				testHistoryEntry("devnet", "chain-1", "v2", 2, 110),
+			},
+			expectedTrends: 3,
+		},
+		{
+			name: "version compared with the previous version of the same chain",
+			entries: []HistoryEntry{
+				testHistoryEntry("devnet", "chain-1", "v1", 0, 100),
+				testHistoryEntry("devnet", "chain-2", "v1", 1, 50),
+				testHistoryEntry("devnet", "chain-1", "v2", 2, 200),
+			},
+			expectedTrends:      3,
+			expectedRegressions: []RegressionKind{RegressionCatchUp},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			history := NewHistory(tc.entries, DefaultHistoryThresholds)
+
+			if len(history.Trends) != tc.expectedTrends {
+				t.Errorf("expected %d trends, got %+v", tc.expectedTrends, history.Trends)
+			}
+			if len(history.Regressions) != len(tc.expectedRegressions) {
+				t.Fatalf("expected %d regressions, got %+v", len(tc.expectedRegressions), history.Regressions)
+			}
+			for i, regression := range history.Regressions {
+				if regression.Kind != tc.expectedRegressions[i] {
+					t.Errorf("expected the %s regression, got %+v", tc.expectedRegressions[i], regression)
+				}
+			}
+		})
+	}
+}
+
+func TestNewHistoryEntry(t *testing.T) {
+	results := testResults(func(results *components.Results) {
+		results.Config = &components.RunConfigResults{Environment: "devnet"}
+	})
+
+	entry := NewHistoryEntry(Run{Name: "run", Results: results}, time.Now())
+	if entry.Environment != "devnet" || entry.ChainID != "test-chain" || entry.AppVersion != "v0.74.0" {
+		t.Errorf("expected the devnet test-chain v0.74.0 entry, got %+v", entry)
+	}
+	if entry.RestartSnapshotHeight == nil || *entry.RestartSnapshotHeight != 1000 {
+		t.Errorf("expected the restart snapshot height 1000, got %v", entry.RestartSnapshotHeight)
+	}
+	if entry.Summary.Status != components.Healthy {
+		t.Errorf("expected the healthy summary, got %s", entry.Summary.Status)
+	}
+}
+
+func TestLoadHistoryFilter(t *testing.T) {
+	historyFile := filepath.Join(t.TempDir(), "history.jsonl")
+	entries := []HistoryEntry{
+		testHistoryEntry("devnet", "chain-2", "v2", 3, 100),
+		testHistoryEntry("devnet", "chain-1", "v1", 0, 100),
+		testHistoryEntry("stagnet", "chain-3", "v1", 1, 100),
+		testHistoryEntry("devnet", "chain-1", "v2", 2, 100),
+	}
+	for _, entry := range entries {
+		if err := AppendHistory(historyFile, entry); err != nil {
+			t.Fatalf("failed to append history entry: %v", err)
+		}
+	}
+
+	testCases := []struct {
+		name          string
+		filter        HistoryFilter
+		expectedHours []int
+	}{
+		{name: "all entries ordered by the record time", filter: HistoryFilter{}, expectedHours: []int{0, 1, 2, 3}},
+		{name: "environment", filter: HistoryFilter{Environment: "devnet"}, expectedHours: []int{0, 2, 3}},
+		{name: "chain ID", filter: HistoryFilter{ChainID: "chain-1"}, expectedHours: []int{0, 2}},
+		{name: "app version", filter: HistoryFilter{AppVersion: "v1"}, expectedHours: []int{0, 1}},
+		{name: "all fields", filter: HistoryFilter{Environment: "devnet", ChainID: "chain-1", AppVersion: "v2"}, expectedHours: []int{2}},
+		{name: "no match", filter: HistoryFilter{Environment: "mainnet"}, expectedHours: []int{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			loaded, err := LoadHistory(historyFile, tc.filter)
+			if err != nil {
+				t.Fatalf("failed to load history: %v", err)
+			}
+
+			hours := []int{}
+			for _, entry := range loaded {
+				hours = append(hours, entry.RecordedAt.Hour())
+			}
+			if len(hours) != len(tc.expectedHours) {
+				t.Fatalf("expected entries recorded at %v, got %v", tc.expectedHours, hours)
+			}
+			for i := range hours {
+				if hours[i] != tc.expectedHours[i] {
+					t.Errorf("expected entries recorded at %v, got %v", tc.expectedHours, hours)
+					break
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package report
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"time"
+
+	"github.com/vegaprotocol/snapshot-testing/components"
+)
+
+const FormatHTML = "html"
+
+var (
+	//go:embed html_report.tmpl
+	htmlReportTemplateText string
+	//go:embed html_comparison.tmpl
+	htmlComparisonTemplateText string
+)
+
+var htmlFuncs = template.FuncMap{
+	"formatTime":     formatTime,
+	"formatSeconds":  formatSeconds,
+	"formatOptional": formatOptional,
+}
+
+var (
+	htmlTemplate           = template.Must(template.New("report").Funcs(htmlFuncs).Parse(htmlReportTemplateText))
+	htmlComparisonTemplate = template.Must(template.New("comparison").Funcs(htmlFuncs).Parse(htmlComparisonTemplateText))
+)
+
+type htmlReportData struct {
+	Title       string
+	GeneratedAt time.Time
+	Results     *components.Results
+	HeightChart *svgChart
+	LagChart    *svgChart
+	// Why the charts could not be drawn
+	MetricsError string
+}
+
+// WriteHTML writes the self-contained HTML report of the run. Charts are drawn from the probe metrics
+// recorded by the watchdog during the run, the report is written without charts when metrics are empty.
+func WriteHTML(path string, title string, results *components.Results, metrics []components.ProbeMetrics) error {
+	out, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create html report file: %w", err)
+	}
+	defer out.Close()
+
+	return RenderHTML(out, title, results, metrics)
+}
+
+// RenderHTML renders the HTML report of the run into the writer.
+func RenderHTML(out io.Writer, title string, results *components.Results, metrics []components.ProbeMetrics) error {
+	metrics = metricsDuringRun(results, metrics)
+	data := htmlReportData{
+		Title:       title,
+		GeneratedAt: time.Now(),
+		Results:     results,
+		HeightChart: heightChart(metrics),
+		LagChart:    lagChart(metrics),
+	}
+	if data.HeightChart == nil {
+		data.MetricsError = "Not enough watchdog metrics recorded to draw the charts"
+	}
+
+	if err := htmlTemplate.Execute(out, data); err != nil {
+		return fmt.Errorf("failed to render html report: %w", err)
+	}
+
+	return nil
+}
+
+// metricsDuringRun drops probes recorded outside of the run, e.g. by the previous run in the same work dir.
+func metricsDuringRun(results *components.Results, metrics []components.ProbeMetrics) []components.ProbeMetrics {
+	filtered := []components.ProbeMetrics{}
+	for _, probe := range metrics {
+		if probe.Time.Before(results.StartedAt) || (!results.FinishedAt.IsZero() && probe.Time.After(results.FinishedAt)) {
+			continue
+		}
+		filtered = append(filtered, probe)
+	}
+
+	return filtered
+}
+
+func formatTime(value any) string {
+	switch t := value.(type) {
+	case time.Time:
+		if t.IsZero() {
+			return "N/A"
+		}
+		return t.UTC().Format(time.RFC3339)
+	case *time.Time:
+		if t == nil || t.IsZero() {
+			return "N/A"
+		}
+		return t.UTC().Format(time.RFC3339)
+	default:
+		return "N/A"
+	}
+}
+
+func formatSeconds(value any) string {
+	switch seconds := value.(type) {
+	case float64:
+		return (time.Duration(seconds * float64(time.Second))).Round(time.Second).String()
+	case *float64:
+		if seconds == nil {
+			return "N/A"
+		}
+		return formatSeconds(*seconds)
+	default:
+		return "N/A"
+	}
+}
+
+func formatOptional(value any) string {
+	switch v := value.(type) {
+	case *uint64:
+		if v == nil {
+			return "N/A"
+		}
+		return fmt.Sprint(*v)
+	case *int:
+		if v == nil {
+			return "N/A"
+		}
+		return fmt.Sprint(*v)
+	case *float64:
+		if v == nil {
+			return "N/A"
+		}
+		return fmt.Sprintf("%.2f", *v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
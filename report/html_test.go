@@ -0,0 +1,149 @@
+package report
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vegaprotocol/snapshot-testing/components"
+)
+
+// testMetrics returns probes taken every minute from the start of the run, the node catches up
+// by 100 blocks per probe.
+func testMetrics(startedAt time.Time, probes int) []components.ProbeMetrics {
+	metrics := []components.ProbeMetrics{}
+	for i := 0; i < probes; i++ {
+		coreHeight := uint64(1000 + 100*i)
+		networkHeight := uint64(1500 + 10*i)
+		metrics = append(metrics, components.ProbeMetrics{
+			Time:                startedAt.Add(time.Duration(i+1) * time.Minute),
+			LocalNodeUp:         true,
+			LocalCoreHeight:     coreHeight,
+			LocalDataNodeHeight: coreHeight,
+			NetworkHeight:       networkHeight,
+			CoreLag:             networkHeight - min(networkHeight, coreHeight),
+		})
+	}
+
+	return metrics
+}
+
+func TestRenderHTML(t *testing.T) {
+	testCases := []struct {
+		name             string
+		results          *components.Results
+		metrics          func(results *components.Results) []components.ProbeMetrics
+		expectedContent  []string
+		forbiddenContent []string
+	}{
+		{
+			name:    "run with metrics",
+			results: testResults(nil),
+			metrics: func(results *components.Results) []components.ProbeMetrics {
+				return testMetrics(results.StartedAt, 10)
+			},
+			expectedContent: []string{
+				"<title>snapshot-testing devnet</title>",
+				"status-HEALTHY",
+				"test-chain",
+				"<h3>Block height</h3>",
+				"<h3>Lag in blocks</h3>",
+				"<polyline",
+				"<td>download-binary</td>",
+			},
+			forbiddenContent: []string{"Not enough watchdog metrics"},
+		},
+		{
+			name:    "metrics of other runs are ignored",
+			results: testResults(nil),
+			metrics: func(results *components.Results) []components.ProbeMetrics {
+				// All probes were recorded before the run started
+				return testMetrics(results.StartedAt.Add(-time.Hour), 10)[:5]
+			},
+			expectedContent:  []string{"Not enough watchdog metrics recorded to draw the charts"},
+			forbiddenContent: []string{"<polyline"},
+		},
+		{
+			name: "setup failed",
+			results: testResults(func(results *components.Results) {
+				results.Status, results.ReasonCode = "", ""
+				results.Watchdog, results.Snapshots = nil, nil
+				results.Setup.Steps[1] = components.SetupStepResults{Name: "init-node", Error: "failed to init node"}
+			}),
+			expectedContent: []string{
+				`class="failed">failed to init node`,
+				"Not enough watchdog metrics recorded to draw the charts",
+			},
+			forbiddenContent: []string{"<polyline"},
+		},
+		{
+			name: "reason is escaped",
+			results: testResults(func(results *components.Results) {
+				results.SetStatus(components.Unhealthy, components.ReasonStalled, "<script>alert(1)</script>")
+			}),
+			expectedContent:  []string{"&lt;script&gt;alert(1)&lt;/script&gt;"},
+			forbiddenContent: []string{"<script>"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var metrics []components.ProbeMetrics
+			if tc.metrics != nil {
+				metrics = tc.metrics(tc.results)
+			}
+
+			out := bytes.Buffer{}
+			if err := RenderHTML(&out, "snapshot-testing devnet", tc.results, metrics); err != nil {
+				t.Fatalf("failed to render html: %v", err)
+			}
+
+			content := out.String()
+			for _, expected := range tc.expectedContent {
+				if !strings.Contains(content, expected) {
+					t.Errorf("expected the report to contain %q", expected)
+				}
+			}
+			for _, forbidden := range tc.forbiddenContent {
+				if strings.Contains(content, forbidden) {
+					t.Errorf("expected the report not to contain %q", forbidden)
+				}
+			}
+		})
+	}
+}
+
+func TestLagChartSkipsDataNodeDown(t *testing.T) {
+	metrics := testMetrics(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), 4)
+	metrics[1].LocalDataNodeDown = true
+	metrics[1].LocalDataNodeHeight, metrics[1].DataNodeLag = 0, 0
+	metrics[2].LocalNodeUp = false
+
+	chart := lagChart(metrics)
+	if chart == nil {
+		t.Fatal("expected the lag chart")
+	}
+
+	expectedPoints := map[string]int{"core lag": 3, "data-node lag": 2}
+	for _, series := range chart.Series {
+		if points := len(strings.Fields(series.Points)); points != expectedPoints[series.Name] {
+			t.Errorf("expected %d points of the %s, got %d", expectedPoints[series.Name], series.Name, points)
+		}
+	}
+}
+
+func TestDownsample(t *testing.T) {
+	metrics := testMetrics(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), 2500)
+
+	result := downsample(metrics, chartMaxPoints)
+	if len(result) > chartMaxPoints {
+		t.Errorf("expected at most %d points, got %d", chartMaxPoints, len(result))
+	}
+	if !result[len(result)-1].Time.Equal(metrics[len(metrics)-1].Time) {
+		t.Error("expected the last probe to be kept")
+	}
+	if short := metrics[:10]; len(downsample(short, chartMaxPoints)) != len(short) {
+		t.Error("expected the short series to be kept as it is")
+	}
+}
@@ -0,0 +1,284 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/vegaprotocol/snapshot-testing/components"
+)
+
+const (
+	FormatJSON  = "json"
+	FormatJUnit = "junit"
+)
+
+// JUnitTestSuites is the root element of the JUnit XML report.
+type JUnitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     float64          `xml:"time,attr"`
+	Suites   []JUnitTestSuite `xml:"testsuite"`
+}
+
+type JUnitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      float64         `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr,omitempty"`
+	TestCases []JUnitTestCase `xml:"testcase"`
+}
+
+type JUnitTestCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Time      float64       `xml:"time,attr"`
+	Failure   *JUnitFailure `xml:"failure,omitempty"`
+	Skipped   *JUnitSkipped `xml:"skipped,omitempty"`
+}
+
+type JUnitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Details string `xml:",chardata"`
+}
+
+type JUnitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+// Fail marks the test case as failed.
+func (tc *JUnitTestCase) Fail(failureType, message, details string) {
+	tc.Failure = &JUnitFailure{
+		Message: message,
+		Type:    failureType,
+		Details: details,
+	}
+}
+
+// Skip marks the test case as skipped.
+func (tc *JUnitTestCase) Skip(message string) {
+	tc.Skipped = &JUnitSkipped{Message: message}
+}
+
+// NewJUnitTestSuite creates the test suite and counts its failed and skipped test cases. The suite
+// time is the sum of the test cases time when the duration is zero.
+func NewJUnitTestSuite(name string, timestamp time.Time, duration float64, testCases []JUnitTestCase) JUnitTestSuite {
+	suite := JUnitTestSuite{
+		Name:      name,
+		Tests:     len(testCases),
+		Time:      duration,
+		TestCases: testCases,
+	}
+	if !timestamp.IsZero() {
+		suite.Timestamp = timestamp.UTC().Format(time.RFC3339)
+	}
+
+	for _, testCase := range testCases {
+		if duration == 0 {
+			suite.Time += testCase.Time
+		}
+		if testCase.Failure != nil {
+			suite.Failures++
+		}
+		if testCase.Skipped != nil {
+			suite.Skipped++
+		}
+	}
+
+	return suite
+}
+
+// NewJUnitTestSuites creates the report from the test suites.
+func NewJUnitTestSuites(name string, suites ...JUnitTestSuite) JUnitTestSuites {
+	report := JUnitTestSuites{
+		Name:   name,
+		Suites: suites,
+	}
+
+	for _, suite := range suites {
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Skipped += suite.Skipped
+		report.Time += suite.Time
+	}
+
+	return report
+}
+
+// WriteJUnit writes the JUnit XML report into the given file.
+func WriteJUnit(path string, report JUnitTestSuites) error {
+	content, err := xml.MarshalIndent(report, "", "    ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal junit report: %w", err)
+	}
+
+	content = append([]byte(xml.Header), content...)
+	if err := os.WriteFile(path, content, os.ModePerm); err != nil {
+		return fmt.Errorf("failed to write junit report to %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// RunTestSuite maps results of the snapshot-testing run to the test suite. Each setup step, the catch-up,
+// the sustained health, the snapshot production and each pass criterion is a separate test case.
+func RunTestSuite(name string, results *components.Results) JUnitTestSuite {
+	testCases := SetupTestCases(name, results.Setup)
+	testCases = append(testCases,
+		catchUpTestCase(name, results),
+		sustainedHealthTestCase(name, results),
+		snapshotProductionTestCase(name, results),
+	)
+	testCases = append(testCases, passCriteriaTestCases(name, results)...)
+
+	return NewJUnitTestSuite(name, results.StartedAt, results.DurationSeconds, testCases)
+}
+
+// SetupTestCases maps each step of the local node setup to the test case.
+func SetupTestCases(className string, setup *components.SetupResults) []JUnitTestCase {
+	if setup == nil {
+		return nil
+	}
+
+	testCases := []JUnitTestCase{}
+	for _, step := range setup.Steps {
+		testCase := JUnitTestCase{
+			ClassName: className,
+			Name:      "setup/" + step.Name,
+			Time:      step.DurationSeconds,
+		}
+		if !step.Passed {
+			testCase.Fail(string(components.ReasonSetupFailed), step.Error, "")
+		}
+		testCases = append(testCases, testCase)
+	}
+
+	return testCases
+}
+
+func catchUpTestCase(className string, results *components.Results) JUnitTestCase {
+	testCase := JUnitTestCase{
+		ClassName: className,
+		Name:      "catch-up",
+	}
+
+	if results.Watchdog == nil {
+		testCase.Skip("Watchdog did not run")
+		return testCase
+	}
+
+	if results.Watchdog.CatchUpDurationSeconds != nil {
+		testCase.Time = *results.Watchdog.CatchUpDurationSeconds
+	}
+	if results.Watchdog.CaughtUpAt == nil {
+		message := results.Reason
+		if message == "" {
+			message = "Node never caught up rest of the network"
+		}
+		// The same as the sustained health, e.g. the node could not catch up the halted network
+		if results.ShouldSkipFailure {
+			testCase.Skip(fmt.Sprintf("%s: %s", results.Status, message))
+			return testCase
+		}
+		failWithResults(&testCase, results, message)
+	}
+
+	return testCase
+}
+
+func sustainedHealthTestCase(className string, results *components.Results) JUnitTestCase {
+	testCase := JUnitTestCase{
+		ClassName: className,
+		Name:      "sustained-health",
+		Time:      results.DurationSeconds,
+	}
+
+	switch {
+	case results.Status == "":
+		testCase.Skip("Watchdog did not run")
+	case results.Status == components.Healthy:
+	case results.ShouldSkipFailure:
+		testCase.Skip(fmt.Sprintf("%s: %s", results.Status, results.Reason))
+	default:
+		failWithResults(&testCase, results, results.Reason)
+	}
+
+	return testCase
+}
+
+func snapshotProductionTestCase(className string, results *components.Results) JUnitTestCase {
+	testCase := JUnitTestCase{
+		ClassName: className,
+		Name:      "snapshot-production",
+	}
+
+	if results.Snapshots == nil {
+		testCase.Skip("Local node did not run")
+		return testCase
+	}
+
+	// The node is started from the restart snapshot, so only the later snapshots are produced by the node
+	var restartHeight int64
+	if results.Setup != nil && results.Setup.RestartSnapshotHeight != nil {
+		restartHeight = int64(*results.Setup.RestartSnapshotHeight)
+	}
+	if results.Snapshots.Max <= restartHeight {
+		testCase.Fail("NO_SNAPSHOTS", fmt.Sprintf(
+			"Local node did not produce any snapshot after block %d, the latest snapshot is at block %d",
+			restartHeight,
+			results.Snapshots.Max,
+		), visorLogLines(results))
+	}
+
+	return testCase
+}
+
+func passCriteriaTestCases(className string, results *components.Results) []JUnitTestCase {
+	if results.Watchdog == nil {
+		return nil
+	}
+
+	testCases := []JUnitTestCase{}
+	for _, criterion := range results.Watchdog.PassCriteria {
+		testCase := JUnitTestCase{
+			ClassName: className,
+			Name:      "pass-criteria/" + criterion.Name,
+		}
+		if !criterion.Passed {
+			testCase.Fail(string(components.ReasonPassCriteriaFailed), fmt.Sprintf(
+				"%s is %s, threshold %s",
+				criterion.Name,
+				criterion.Actual,
+				criterion.Threshold,
+			), "")
+		}
+		testCases = append(testCases, testCase)
+	}
+
+	return testCases
+}
+
+// failWithResults marks the test case as failed with the reason code of the run and the vegavisor logs.
+func failWithResults(testCase *JUnitTestCase, results *components.Results, message string) {
+	failureType := string(results.ReasonCode)
+	if failureType == "" {
+		failureType = string(components.Unhealthy)
+	}
+
+	testCase.Fail(failureType, message, visorLogLines(results))
+}
+
+func visorLogLines(results *components.Results) string {
+	if results.Visor == nil {
+		return ""
+	}
+
+	return results.Visor.ExtraLogLines
+}
@@ -0,0 +1,224 @@
+package report
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/vegaprotocol/snapshot-testing/components"
+)
+
+const (
+	outcomePassed  = "passed"
+	outcomeFailed  = "failed"
+	outcomeSkipped = "skipped"
+)
+
+func testCaseOutcomes(suite JUnitTestSuite) map[string]string {
+	outcomes := map[string]string{}
+	for _, testCase := range suite.TestCases {
+		switch {
+		case testCase.Failure != nil:
+			outcomes[testCase.Name] = outcomeFailed
+		case testCase.Skipped != nil:
+			outcomes[testCase.Name] = outcomeSkipped
+		default:
+			outcomes[testCase.Name] = outcomePassed
+		}
+	}
+
+	return outcomes
+}
+
+func timePointer(value time.Time) *time.Time {
+	return &value
+}
+
+func uint64Pointer(value uint64) *uint64 {
+	return &value
+}
+
+// testResults returns results of the healthy run that produced snapshots after the restart snapshot.
+func testResults(modify func(results *components.Results)) *components.Results {
+	startedAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	catchUpDuration := 120.0
+
+	results := components.NewResults(startedAt)
+	results.Finish(startedAt.Add(time.Hour))
+	results.SetStatus(components.Healthy, components.ReasonNone, "")
+	results.Setup = &components.SetupResults{
+		ChainID:               "test-chain",
+		AppVersion:            "v0.74.0",
+		RestartSnapshotHeight: uint64Pointer(1000),
+		Steps: []components.SetupStepResults{
+			{Name: "download-binary", DurationSeconds: 10, Passed: true},
+			{Name: "init-node", DurationSeconds: 5, Passed: true},
+		},
+	}
+	results.Snapshots = components.NewSnapshotsResults([]int64{1000, 1300, 1600})
+	results.Watchdog = &components.WatchdogResults{
+		CaughtUpAt:             timePointer(startedAt.Add(2 * time.Minute)),
+		CatchUpDurationSeconds: &catchUpDuration,
+	}
+	if modify != nil {
+		modify(results)
+	}
+
+	return results
+}
+
+func TestRunTestSuite(t *testing.T) {
+	testCases := []struct {
+		name             string
+		results          *components.Results
+		expectedOutcomes map[string]string
+		expectedFailures int
+		expectedSkipped  int
+	}{
+		{
+			name:    "healthy run",
+			results: testResults(nil),
+			expectedOutcomes: map[string]string{
+				"setup/download-binary": outcomePassed,
+				"setup/init-node":       outcomePassed,
+				"catch-up":              outcomePassed,
+				"sustained-health":      outcomePassed,
+				"snapshot-production":   outcomePassed,
+			},
+		},
+		{
+			name: "setup failed",
+			results: testResults(func(results *components.Results) {
+				results.Setup.Steps[1] = components.SetupStepResults{Name: "init-node", Error: "failed to init node"}
+				results.Status, results.ReasonCode = "", ""
+				results.Watchdog, results.Snapshots = nil, nil
+			}),
+			expectedOutcomes: map[string]string{
+				"setup/download-binary": outcomePassed,
+				"setup/init-node":       outcomeFailed,
+				"catch-up":              outcomeSkipped,
+				"sustained-health":      outcomeSkipped,
+				"snapshot-production":   outcomeSkipped,
+			},
+			expectedFailures: 1,
+			expectedSkipped:  3,
+		},
+		{
+			name: "never caught up",
+			results: testResults(func(results *components.Results) {
+				results.SetStatus(components.Unhealthy, components.ReasonNeverCaughtUp, "Node never caught up rest of the network")
+				results.Watchdog.CaughtUpAt, results.Watchdog.CatchUpDurationSeconds = nil, nil
+			}),
+			expectedOutcomes: map[string]string{
+				"catch-up":            outcomeFailed,
+				"sustained-health":    outcomeFailed,
+				"snapshot-production": outcomePassed,
+			},
+			expectedFailures: 2,
+		},
+		{
+			name: "network halted before the catch-up",
+			results: testResults(func(results *components.Results) {
+				results.SetStatus(components.NetworkHalted, components.ReasonNetworkHalted, "Network halted")
+				results.ShouldSkipFailure = true
+				results.Watchdog.CaughtUpAt, results.Watchdog.CatchUpDurationSeconds = nil, nil
+			}),
+			expectedOutcomes: map[string]string{
+				"catch-up":         outcomeSkipped,
+				"sustained-health": outcomeSkipped,
+			},
+			expectedSkipped: 2,
+		},
+		{
+			name: "lagging after the catch-up",
+			results: testResults(func(results *components.Results) {
+				results.SetStatus(components.MaybeHealthy, components.ReasonLagging, "Node caught up at some point but then started lagging")
+			}),
+			expectedOutcomes: map[string]string{
+				"catch-up":         outcomePassed,
+				"sustained-health": outcomeFailed,
+			},
+			expectedFailures: 1,
+		},
+		{
+			name: "no snapshots after the restart snapshot",
+			results: testResults(func(results *components.Results) {
+				results.Snapshots = components.NewSnapshotsResults([]int64{900, 1000})
+			}),
+			expectedOutcomes: map[string]string{
+				"snapshot-production": outcomeFailed,
+			},
+			expectedFailures: 1,
+		},
+		{
+			name: "failed pass criterion",
+			results: testResults(func(results *components.Results) {
+				results.Watchdog.PassCriteria = []components.CriterionResults{
+					{Name: "max_catch_up_duration", Threshold: "10m0s", Actual: "2m0s", Passed: true},
+					{Name: "max_lag_episodes", Threshold: "0", Actual: "2", Passed: false},
+				}
+			}),
+			expectedOutcomes: map[string]string{
+				"pass-criteria/max_catch_up_duration": outcomePassed,
+				"pass-criteria/max_lag_episodes":      outcomeFailed,
+			},
+			expectedFailures: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			suite := RunTestSuite("devnet", tc.results)
+
+			outcomes := testCaseOutcomes(suite)
+			for name, expected := range tc.expectedOutcomes {
+				if outcomes[name] != expected {
+					t.Errorf("expected the %s test case to be %s, got %q", name, expected, outcomes[name])
+				}
+			}
+			if suite.Failures != tc.expectedFailures {
+				t.Errorf("expected %d failures, got %d", tc.expectedFailures, suite.Failures)
+			}
+			if suite.Skipped != tc.expectedSkipped {
+				t.Errorf("expected %d skipped test cases, got %d", tc.expectedSkipped, suite.Skipped)
+			}
+			if suite.Tests != len(suite.TestCases) {
+				t.Errorf("expected %d tests, got %d", len(suite.TestCases), suite.Tests)
+			}
+		})
+	}
+}
+
+func TestWriteJUnit(t *testing.T) {
+	suites := NewJUnitTestSuites(
+		"snapshot-testing",
+		RunTestSuite("first", testResults(nil)),
+		RunTestSuite("second", testResults(func(results *components.Results) {
+			results.SetStatus(components.Unhealthy, components.ReasonStalled, "Node stalled")
+		})),
+	)
+
+	path := filepath.Join(t.TempDir(), "junit.xml")
+	if err := WriteJUnit(path, suites); err != nil {
+		t.Fatalf("failed to write junit report: %v", err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read junit report: %v", err)
+	}
+
+	written := JUnitTestSuites{}
+	if err := xml.Unmarshal(content, &written); err != nil {
+		t.Fatalf("failed to unmarshal junit report: %v", err)
+	}
+
+	if written.Tests != 10 || written.Failures != 1 || len(written.Suites) != 2 {
+		t.Errorf("expected 10 tests with 1 failure in 2 suites, got %d tests, %d failures, %d suites", written.Tests, written.Failures, len(written.Suites))
+	}
+	if failure := written.Suites[1].TestCases[3].Failure; failure == nil || failure.Type != string(components.ReasonStalled) {
+		t.Errorf("expected the sustained health failure with the reason code, got %+v", failure)
+	}
+}
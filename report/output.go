@@ -0,0 +1,111 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+)
+
+const FormatTable = "table"
+
+// Comparison is the summary of the runs with regressions found against the baseline.
+type Comparison struct {
+	Summaries   []RunSummary `json:"runs"`
+	Regressions []Regression `json:"regressions"`
+}
+
+// NewComparison summarizes the runs and compares them with the first run.
+func NewComparison(runs []Run, thresholds Thresholds) Comparison {
+	summaries := []RunSummary{}
+	for _, run := range runs {
+		summaries = append(summaries, run.Summary())
+	}
+
+	return Comparison{
+		Summaries:   summaries,
+		Regressions: Compare(summaries, thresholds),
+	}
+}
+
+// WriteTable writes the comparison as the plain text table.
+func (c Comparison) WriteTable(out io.Writer) error {
+	table := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "RUN\tSTATUS\tREASON CODE\tAPP VERSION\tDURATION\tCATCH-UP\tMAX CORE LAG\tMAX DATA-NODE LAG\tRESTART SNAPSHOT\tSNAPSHOTS")
+	for _, summary := range c.Summaries {
+		fmt.Fprintf(
+			table,
+			"%s\t%s\t%s\t%s\t%s\t%s\t%d\t%d\t%s\t%d (%d - %d)\n",
+			summary.Name,
+			summary.Status,
+			summary.ReasonCode,
+			summary.AppVersion,
+			formatSeconds(summary.DurationSeconds),
+			formatSeconds(summary.CatchUpDurationSeconds),
+			summary.MaxCoreLag,
+			summary.MaxDataNodeLag,
+			formatOptional(summary.RestartSnapshotHeight),
+			summary.Snapshots,
+			summary.SnapshotMin,
+			summary.SnapshotMax,
+		)
+	}
+	if err := table.Flush(); err != nil {
+		return fmt.Errorf("failed to write runs table: %w", err)
+	}
+
+	if len(c.Summaries) < 2 {
+		return nil
+	}
+
+	fmt.Fprintln(out)
+	if len(c.Regressions) == 0 {
+		fmt.Fprintf(out, "No regressions found against the baseline %s\n", c.Summaries[0].Name)
+		return nil
+	}
+
+	fmt.Fprintf(out, "Regressions found against the baseline %s:\n", c.Summaries[0].Name)
+	table = tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	for _, regression := range c.Regressions {
+		fmt.Fprintf(table, "%s\t%s\t%s\n", regression.Run, regression.Kind, regression.Message)
+	}
+	if err := table.Flush(); err != nil {
+		return fmt.Errorf("failed to write regressions table: %w", err)
+	}
+
+	return nil
+}
+
+// WriteJSON writes the comparison as JSON.
+func (c Comparison) WriteJSON(out io.Writer) error {
+	content, err := json.MarshalIndent(c, "", "    ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal comparison into JSON: %w", err)
+	}
+
+	if _, err := out.Write(append(content, '\n')); err != nil {
+		return fmt.Errorf("failed to write comparison: %w", err)
+	}
+
+	return nil
+}
+
+// WriteHTML writes the comparison as the self-contained HTML page.
+func (c Comparison) WriteHTML(out io.Writer, title string) error {
+	data := struct {
+		Comparison
+		Title       string
+		GeneratedAt time.Time
+	}{
+		Comparison:  c,
+		Title:       title,
+		GeneratedAt: time.Now(),
+	}
+
+	if err := htmlComparisonTemplate.Execute(out, data); err != nil {
+		return fmt.Errorf("failed to render html comparison: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,152 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/vegaprotocol/snapshot-testing/components"
+	"github.com/vegaprotocol/snapshot-testing/networkutils"
+)
+
+// Run is the stored result of a single snapshot-testing run with the watchdog metrics it recorded.
+type Run struct {
+	Name    string
+	WorkDir string
+	Results *components.Results
+	Metrics []components.ProbeMetrics
+}
+
+// storedResults is the part of the run and scenario results used to tell them apart.
+type storedResults struct {
+	SchemaVersion int `json:"schema_version"`
+	Phases        []struct {
+		Name    string              `json:"name"`
+		Results *components.Results `json:"results"`
+	} `json:"phases"`
+}
+
+// LoadRuns loads the results stored in the work dir. The scenario results contain one run for each
+// run phase. Metrics are loaded from the metrics file in the work dir and limited to the run duration.
+func LoadRuns(workDir string) ([]Run, error) {
+	pathManager := networkutils.NewPathManager(workDir)
+
+	content, err := os.ReadFile(pathManager.Results())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read results file: %w", err)
+	}
+
+	stored := storedResults{}
+	if err := json.Unmarshal(content, &stored); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal results: %w", err)
+	}
+	if stored.SchemaVersion != components.ResultsSchemaVersion {
+		return nil, fmt.Errorf(
+			"unsupported results schema version %d, expected %d",
+			stored.SchemaVersion,
+			components.ResultsSchemaVersion,
+		)
+	}
+
+	// Metrics may be missing, e.g. when the setup failed
+	metrics, _ := components.ReadProbeMetrics(pathManager.WatchdogMetrics())
+
+	if stored.Phases != nil {
+		runs := []Run{}
+		for _, phase := range stored.Phases {
+			if phase.Results == nil {
+				continue
+			}
+			runs = append(runs, Run{
+				Name:    fmt.Sprintf("%s:%s", workDir, phase.Name),
+				WorkDir: workDir,
+				Results: phase.Results,
+				// Metrics file contains all of the run phases, the probes are split by the phase time
+				Metrics: metricsDuringRun(phase.Results, metrics),
+			})
+		}
+		return runs, nil
+	}
+
+	results := &components.Results{}
+	if err := json.Unmarshal(content, results); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal results: %w", err)
+	}
+
+	return []Run{{
+		Name:    workDir,
+		WorkDir: workDir,
+		Results: results,
+		Metrics: metricsDuringRun(results, metrics),
+	}}, nil
+}
+
+// RunSummary contains the values of the run compared across runs.
+type RunSummary struct {
+	Name       string                   `json:"name"`
+	Status     components.HealthyStatus `json:"status"`
+	ReasonCode components.ReasonCode    `json:"reason_code"`
+	AppVersion string                   `json:"app_version"`
+
+	DurationSeconds float64 `json:"duration_seconds"`
+	// Null when the node never caught up
+	CatchUpDurationSeconds *float64 `json:"catch_up_duration_seconds"`
+	MaxCoreLag             uint64   `json:"max_core_lag"`
+	MaxDataNodeLag         uint64   `json:"max_data_node_lag"`
+
+	// Null when the restart snapshot is not known
+	RestartSnapshotHeight *uint64 `json:"restart_snapshot_height"`
+	SnapshotMin           int64   `json:"snapshot_min"`
+	SnapshotMax           int64   `json:"snapshot_max"`
+	Snapshots             int     `json:"snapshots"`
+}
+
+// Summary summarizes the run. Max lags are computed from the metrics when they are available.
+func (r Run) Summary() RunSummary {
+	results := r.Results
+	summary := RunSummary{
+		Name:            r.Name,
+		Status:          results.Status,
+		ReasonCode:      results.ReasonCode,
+		DurationSeconds: results.DurationSeconds,
+	}
+
+	if results.Setup != nil {
+		summary.AppVersion = results.Setup.AppVersion
+		summary.RestartSnapshotHeight = results.Setup.RestartSnapshotHeight
+	}
+
+	if results.Watchdog != nil {
+		summary.CatchUpDurationSeconds = results.Watchdog.CatchUpDurationSeconds
+		summary.MaxCoreLag = results.Watchdog.Metrics.MaxCoreLag
+		summary.MaxDataNodeLag = results.Watchdog.Metrics.MaxDataNodeLag
+	}
+
+	if len(r.Metrics) > 0 {
+		summary.MaxCoreLag, summary.MaxDataNodeLag = 0, 0
+		for _, probe := range r.Metrics {
+			summary.MaxCoreLag = max(summary.MaxCoreLag, probe.CoreLag)
+			if probe.DataNodeLagKnown() {
+				summary.MaxDataNodeLag = max(summary.MaxDataNodeLag, probe.DataNodeLag)
+			}
+		}
+	}
+
+	if results.Snapshots != nil {
+		summary.SnapshotMin = results.Snapshots.Min
+		summary.SnapshotMax = results.Snapshots.Max
+		summary.Snapshots = len(results.Snapshots.Heights)
+	}
+
+	return summary
+}
+
+// producedSnapshots tells if the node produced any snapshot after the restart snapshot.
+func (rs RunSummary) producedSnapshots() bool {
+	var restartHeight int64
+	if rs.RestartSnapshotHeight != nil {
+		restartHeight = int64(*rs.RestartSnapshotHeight)
+	}
+
+	return rs.SnapshotMax > restartHeight
+}
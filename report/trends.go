@@ -0,0 +1,242 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"text/tabwriter"
+	"time"
+)
+
+const RegressionFailureRate RegressionKind = "FAILURE_RATE"
+
+// VersionTrend aggregates runs of the app version on the chain of the environment.
+type VersionTrend struct {
+	Environment     string    `json:"environment"`
+	ChainID         string    `json:"chain_id"`
+	AppVersion      string    `json:"app_version"`
+	FirstRecordedAt time.Time `json:"first_recorded_at"`
+	LastRecordedAt  time.Time `json:"last_recorded_at"`
+	Runs            int       `json:"runs"`
+	Failures        int       `json:"failures"`
+	FailureRate     float64   `json:"failure_rate"`
+	// Null when the node never caught up in any run
+	MedianCatchUpDurationSeconds *float64 `json:"median_catch_up_duration_seconds"`
+	MedianMaxCoreLag             float64  `json:"median_max_core_lag"`
+}
+
+func (vt VersionTrend) name() string {
+	return fmt.Sprintf("%s/%s@%s", vt.Environment, vt.ChainID, vt.AppVersion)
+}
+
+// HistoryThresholds describe how much worse than the previous version the next version can be before
+// it is reported as a regression.
+type HistoryThresholds struct {
+	// Max increase of the median catch-up duration in percent
+	CatchUpDurationPercent float64
+	// Max increase of the failure rate in percentage points
+	FailureRatePoints float64
+}
+
+var DefaultHistoryThresholds = HistoryThresholds{
+	CatchUpDurationPercent: 20,
+	FailureRatePoints:      20,
+}
+
+// History contains trends of the recorded runs with regressions between consecutive app versions.
+type History struct {
+	Trends      []VersionTrend `json:"trends"`
+	Regressions []Regression   `json:"regressions"`
+}
+
+// NewHistory groups the entries by the environment, the chain ID and the app version. Versions of each
+// chain are ordered by the time they were recorded first, and each version is compared with the previous
+// one on the same chain. The environment can be reset with the new chain, where the runs are not comparable.
+func NewHistory(entries []HistoryEntry, thresholds HistoryThresholds) History {
+	type versionKey struct {
+		environment string
+		chainID     string
+		appVersion  string
+	}
+
+	grouped := map[versionKey][]HistoryEntry{}
+	order := []versionKey{}
+	for _, entry := range entries {
+		key := versionKey{environment: entry.Environment, chainID: entry.ChainID, appVersion: entry.AppVersion}
+		if _, ok := grouped[key]; !ok {
+			order = append(order, key)
+		}
+		grouped[key] = append(grouped[key], entry)
+	}
+
+	history := History{
+		Trends:      []VersionTrend{},
+		Regressions: []Regression{},
+	}
+	for _, key := range order {
+		history.Trends = append(history.Trends, newVersionTrend(key.environment, key.chainID, key.appVersion, grouped[key]))
+	}
+	slices.SortStableFunc(history.Trends, func(a, b VersionTrend) int {
+		if a.Environment != b.Environment {
+			if a.Environment < b.Environment {
+				return -1
+			}
+			return 1
+		}
+		return a.FirstRecordedAt.Compare(b.FirstRecordedAt)
+	})
+
+	type chainKey struct {
+		environment string
+		chainID     string
+	}
+	previousVersions := map[chainKey]VersionTrend{}
+	for _, current := range history.Trends {
+		key := chainKey{environment: current.Environment, chainID: current.ChainID}
+		if previous, ok := previousVersions[key]; ok {
+			history.Regressions = append(history.Regressions, compareVersions(previous, current, thresholds)...)
+		}
+		previousVersions[key] = current
+	}
+
+	return history
+}
+
+func newVersionTrend(environment, chainID, appVersion string, entries []HistoryEntry) VersionTrend {
+	trend := VersionTrend{
+		Environment:     environment,
+		ChainID:         chainID,
+		AppVersion:      appVersion,
+		FirstRecordedAt: entries[0].RecordedAt,
+		LastRecordedAt:  entries[len(entries)-1].RecordedAt,
+		Runs:            len(entries),
+	}
+
+	catchUpDurations := []float64{}
+	maxCoreLags := []float64{}
+	for _, entry := range entries {
+		if entry.failed() {
+			trend.Failures++
+		}
+		if entry.Summary.CatchUpDurationSeconds != nil {
+			catchUpDurations = append(catchUpDurations, *entry.Summary.CatchUpDurationSeconds)
+		}
+		maxCoreLags = append(maxCoreLags, float64(entry.Summary.MaxCoreLag))
+	}
+
+	trend.FailureRate = float64(trend.Failures) / float64(trend.Runs)
+	if len(catchUpDurations) > 0 {
+		medianCatchUp := median(catchUpDurations)
+		trend.MedianCatchUpDurationSeconds = &medianCatchUp
+	}
+	trend.MedianMaxCoreLag = median(maxCoreLags)
+
+	return trend
+}
+
+func compareVersions(previous, current VersionTrend, thresholds HistoryThresholds) []Regression {
+	regressions := []Regression{}
+
+	if current.MedianCatchUpDurationSeconds != nil && previous.MedianCatchUpDurationSeconds != nil &&
+		exceeds(*current.MedianCatchUpDurationSeconds, *previous.MedianCatchUpDurationSeconds, thresholds.CatchUpDurationPercent, 0) {
+		regressions = append(regressions, Regression{
+			Run:      current.name(),
+			Baseline: previous.name(),
+			Kind:     RegressionCatchUp,
+			Message: fmt.Sprintf(
+				"median catch-up is %s, previous version %s, threshold +%.0f%%",
+				formatSeconds(current.MedianCatchUpDurationSeconds),
+				formatSeconds(previous.MedianCatchUpDurationSeconds),
+				thresholds.CatchUpDurationPercent,
+			),
+		})
+	}
+
+	if (current.FailureRate-previous.FailureRate)*100 > thresholds.FailureRatePoints {
+		regressions = append(regressions, Regression{
+			Run:      current.name(),
+			Baseline: previous.name(),
+			Kind:     RegressionFailureRate,
+			Message: fmt.Sprintf(
+				"failure rate is %.0f%%, previous version %.0f%%, threshold +%.0f points",
+				current.FailureRate*100,
+				previous.FailureRate*100,
+				thresholds.FailureRatePoints,
+			),
+		})
+	}
+
+	return regressions
+}
+
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[middle-1] + sorted[middle]) / 2
+	}
+
+	return sorted[middle]
+}
+
+// WriteTable writes the trends and regressions as the plain text table.
+func (h History) WriteTable(out io.Writer) error {
+	table := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "ENVIRONMENT\tCHAIN ID\tAPP VERSION\tFIRST RUN\tLAST RUN\tRUNS\tFAILURES\tFAILURE RATE\tMEDIAN CATCH-UP\tMEDIAN MAX CORE LAG")
+	for _, trend := range h.Trends {
+		fmt.Fprintf(
+			table,
+			"%s\t%s\t%s\t%s\t%s\t%d\t%d\t%.0f%%\t%s\t%.0f\n",
+			trend.Environment,
+			trend.ChainID,
+			trend.AppVersion,
+			formatTime(trend.FirstRecordedAt),
+			formatTime(trend.LastRecordedAt),
+			trend.Runs,
+			trend.Failures,
+			trend.FailureRate*100,
+			formatSeconds(trend.MedianCatchUpDurationSeconds),
+			trend.MedianMaxCoreLag,
+		)
+	}
+	if err := table.Flush(); err != nil {
+		return fmt.Errorf("failed to write trends table: %w", err)
+	}
+
+	fmt.Fprintln(out)
+	if len(h.Regressions) == 0 {
+		fmt.Fprintln(out, "No regressions found between app versions")
+		return nil
+	}
+
+	fmt.Fprintln(out, "Regressions found between app versions:")
+	table = tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	for _, regression := range h.Regressions {
+		fmt.Fprintf(table, "%s\t%s\t%s\n", regression.Run, regression.Kind, regression.Message)
+	}
+	if err := table.Flush(); err != nil {
+		return fmt.Errorf("failed to write regressions table: %w", err)
+	}
+
+	return nil
+}
+
+// WriteJSON writes the trends and regressions as JSON.
+func (h History) WriteJSON(out io.Writer) error {
+	content, err := json.MarshalIndent(h, "", "    ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal history into JSON: %w", err)
+	}
+
+	if _, err := out.Write(append(content, '\n')); err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+
+	return nil
+}
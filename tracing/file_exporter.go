@@ -0,0 +1,89 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// spanRecord is a single line of the traces file.
+type spanRecord struct {
+	Name            string         `json:"name"`
+	TraceID         string         `json:"trace_id"`
+	SpanID          string         `json:"span_id"`
+	ParentSpanID    string         `json:"parent_span_id"`
+	Kind            string         `json:"kind"`
+	StartTime       time.Time      `json:"start_time"`
+	EndTime         time.Time      `json:"end_time"`
+	DurationSeconds float64        `json:"duration_seconds"`
+	Status          string         `json:"status"`
+	Error           string         `json:"error"`
+	Attributes      map[string]any `json:"attributes"`
+}
+
+// fileExporter writes spans as JSON lines to the file.
+type fileExporter struct {
+	// mut protects the out file
+	mut sync.Mutex
+	out *os.File
+}
+
+func newFileExporter(path string) (*fileExporter, error) {
+	out, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open traces file: %w", err)
+	}
+
+	return &fileExporter{out: out}, nil
+}
+
+// ExportSpans implements sdktrace.SpanExporter.
+func (fe *fileExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	fe.mut.Lock()
+	defer fe.mut.Unlock()
+
+	encoder := json.NewEncoder(fe.out)
+	for _, span := range spans {
+		record := spanRecord{
+			Name:            span.Name(),
+			TraceID:         span.SpanContext().TraceID().String(),
+			SpanID:          span.SpanContext().SpanID().String(),
+			Kind:            span.SpanKind().String(),
+			StartTime:       span.StartTime(),
+			EndTime:         span.EndTime(),
+			DurationSeconds: span.EndTime().Sub(span.StartTime()).Seconds(),
+			Status:          span.Status().Code.String(),
+			Error:           span.Status().Description,
+			Attributes:      map[string]any{},
+		}
+		if span.Parent().IsValid() {
+			record.ParentSpanID = span.Parent().SpanID().String()
+		}
+		for _, attribute := range span.Attributes() {
+			record.Attributes[string(attribute.Key)] = attribute.Value.AsInterface()
+		}
+
+		if err := encoder.Encode(record); err != nil {
+			return fmt.Errorf("failed to write span: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Shutdown implements sdktrace.SpanExporter.
+func (fe *fileExporter) Shutdown(ctx context.Context) error {
+	fe.mut.Lock()
+	defer fe.mut.Unlock()
+
+	if err := fe.out.Close(); err != nil {
+		return fmt.Errorf("failed to close traces file: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,74 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+const serviceName = "snapshot-testing"
+
+// Config tells where traces are exported. Tracing is disabled when none of the exporters is set.
+type Config struct {
+	// URL of the OTLP/HTTP collector, e.g. http://localhost:4318
+	OTLPEndpoint string
+	// Spans are written as JSON lines to this file
+	File string
+	// Extra attributes of all traces, e.g. the environment name
+	Attributes []attribute.KeyValue
+}
+
+func (c Config) Enabled() bool {
+	return c.OTLPEndpoint != "" || c.File != ""
+}
+
+// Setup registers the global tracer provider exporting spans to the configured exporters. The returned
+// function flushes the remaining spans and shuts the exporters down.
+func Setup(ctx context.Context, conf Config) (func(context.Context) error, error) {
+	if !conf.Enabled() {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	options := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(resource.NewSchemaless(
+			append([]attribute.KeyValue{attribute.String("service.name", serviceName)}, conf.Attributes...)...,
+		)),
+	}
+
+	if conf.OTLPEndpoint != "" {
+		exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(conf.OTLPEndpoint))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+		}
+		options = append(options, sdktrace.WithBatcher(exporter))
+	}
+
+	if conf.File != "" {
+		exporter, err := newFileExporter(conf.File)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create file exporter: %w", err)
+		}
+		options = append(options, sdktrace.WithBatcher(exporter))
+	}
+
+	provider := sdktrace.NewTracerProvider(options...)
+	otel.SetTracerProvider(provider)
+
+	return func(ctx context.Context) error {
+		errs := []error{}
+		if err := provider.ForceFlush(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to flush spans: %w", err))
+		}
+		if err := provider.Shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to shutdown tracer provider: %w", err))
+		}
+
+		return errors.Join(errs...)
+	}, nil
+}